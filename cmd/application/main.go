@@ -12,22 +12,35 @@ import (
 	"LogDb/internal/adapters/memtable"
 	"LogDb/internal/adapters/merge"
 	"LogDb/internal/adapters/monitoring"
+	"LogDb/internal/adapters/presenters"
 	"LogDb/internal/adapters/query"
+	"LogDb/internal/adapters/query/parser"
+	"LogDb/internal/adapters/schema"
 	"LogDb/internal/adapters/serializer"
+	"LogDb/internal/adapters/wal"
 	"LogDb/internal/domain"
 	"LogDb/internal/domain/compression_types"
 	"LogDb/internal/ports"
 	"context"
+	"errors"
+	"flag"
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"syscall"
 	"time"
 )
 
 const BaseDir = ".storage"
 const DataFileExt = "chunk"
+const SchemaFile = "schemas.json"
+const WALDir = "wal"
 
 func init() {
 	log.SetFormatter(&log.JSONFormatter{})
@@ -36,10 +49,32 @@ func init() {
 }
 
 func main() {
+	walSync := flag.String("wal-sync", wal.SyncPerBatch.String(), "When the WAL is synced before records are acknowledged: request, batch or interval")
+	walSyncInterval := flag.Duration("wal-sync-interval", time.Second, "Sync interval of the WAL with -wal-sync=interval")
+	shutdownTimeout := flag.Duration("shutdown-timeout", 30*time.Second, "Deadline to drain the MemTable and close the writers on SIGINT or SIGTERM")
+	scanWorkers := flag.Int("scan-workers", runtime.NumCPU(), "Data files a query reads at the same time, 1 reads them one after the other")
+	scanSplitDataFiles := flag.Bool("scan-split-data-files", true, "Read the hours of a data file with different workers")
+	queryMaxScannedBytes := flag.Uint64("query-max-scanned-bytes", 0, "Bytes a query may scan, 0 without limit")
+	queryMaxScannedRecords := flag.Uint64("query-max-scanned-records", 0, "Records a query may scan, 0 without limit")
+	queryMaxResultBytes := flag.Uint64("query-max-result-bytes", 0, "Bytes of the records of a query result, 0 without limit")
+	queryMaxDataFiles := flag.Int("query-max-data-files", 0, "Data files a query may open, 0 without limit")
+	flag.Parse()
+	walSyncPolicy, err := wal.ParseSyncPolicy(*walSync)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// The background routines stop on SIGINT or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
 	prometheusExporter := monitoring.NewPrometheusAdapter()
 	prometheusExporter.StartHTTPServer("9090")
 	r := gin.Default()
 	codec := serializer.Default
+	schemas, err := schema.NewFileStore(filepath.Join(BaseDir, SchemaFile))
+	if err != nil {
+		log.Fatalf("Failed to load schemas: %v", err)
+	}
 	compressionFactory := compression.Factory
 	repo := datastor.NewDataFileRepository(BaseDir, codec, DataFileExt)
 	dataFileFactory := datastor.NewDataFileWriterFactory(repo, log.NewEntry(log.StandardLogger()))
@@ -62,13 +97,15 @@ func main() {
 		compressionFactory,
 		compression_types.Zstd,
 	)
-	compressionPolicy := compressor.NewIntervalCompressPolicy(context.Background(), 60*time.Second)
+	compressionPolicy := compressor.NewIntervalCompressPolicy(ctx, 60*time.Second)
 	idx := index.NewTimestamp(repo, merger, dataCompressor)
 	compressionPolicy.Apply(idx)
 	dataFilesChangesBus := bus.NewDataFilesManager()
 	dataFilesChangesBus.OnDataFileCreated(
 		func(header *domain.DataFileHeader) {
-			_ = idx.AddDataFile(header)
+			if err := idx.AddDataFile(header); err != nil {
+				log.WithError(err).Errorf("Failed to add data file %s to the index", header)
+			}
 		},
 	)
 
@@ -78,21 +115,85 @@ func main() {
 		dataFilesChangesBus,
 	)
 	flusher := memtable.NewFlusher(sequentialWriter)
-	defer flusher.Close()
-	memTable := memtable.NewMemTable(1024*1024*1024, 1_000_000, func(maxSize, maxRecords int) ports.HeapChunk {
+	newChunk := func(maxSize, maxRecords int) ports.HeapChunk {
 		return memtable.NewHeapChunk(maxSize, maxRecords)
-	}, flusher, 60*time.Second)
+	}
+
+	// Records are acknowledged once they are in the WAL, a WAL file is removed after its records are flushed to data files
+	walWriter, err := wal.NewWALRepository(&wal.V1WriterConfig{
+		BaseDir:       filepath.Join(BaseDir, WALDir),
+		FlushInterval: *walSyncInterval,
+		SyncPolicy:    walSyncPolicy,
+	}, codec, nil)
+	if err != nil {
+		log.Fatalf("Failed to open WAL: %v", err)
+	}
+	durableMemTable := wal.NewDurableMemTable(walWriter)
+	durableMemTable.Bind(memtable.NewMemTable(ctx, 1024*1024*1024, 1_000_000, durableMemTable.NewChunk(newChunk), wal.NewCheckpointFlusher(walWriter, flusher), 60*time.Second))
 
-	storage := datastor.NewPersistentStorage(memTable, dataFileManagerFactory, dataPageReaderFactory, idx)
-	defer storage.Close()
+	storage := datastor.NewPersistentStorage(durableMemTable, dataFileManagerFactory, dataPageReaderFactory, idx)
+	storage.SetScanOptions(datastor.ScanOptions{Workers: *scanWorkers, SplitDataFiles: *scanSplitDataFiles})
+	storage.SetQueryBudget(domain.QueryBudget{
+		ScannedBytes:   *queryMaxScannedBytes,
+		ScannedRecords: *queryMaxScannedRecords,
+		ResultBytes:    *queryMaxResultBytes,
+		DataFiles:      *queryMaxDataFiles,
+	})
+	// Replay the records that were not flushed before the last shutdown, before the API accepts records
+	if _, err := walWriter.Recover(storage, flusher, newChunk); err != nil {
+		log.Fatalf("Failed to recover WAL: %v", err)
+	}
+	// Live tail subscribers receive the records once they are stored
+	recordsBus := bus.NewRecordsManager()
+	storage.BindRecordsPropagator(recordsBus)
 	queryBuilderFactory := query.NewQueryBuilderFactory()
-	queryProcessor := query.NewPreparer(filters.Factory, label_conditions.Factory)
+	queryProcessor := query.NewPreparer(filters.Factory, label_conditions.Factory, schemas)
 
-	api := web_api.NewWebApi(storage, queryBuilderFactory, queryProcessor) // Initialize storage
+	api := web_api.NewWebApi(storage, queryBuilderFactory, queryProcessor, parser.NewParser(), schemas, recordsBus, presenters.NewQueryResultPresenters(schemas, codec)) // Initialize storage
 	api.RegisterRoutes(r)
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-	err := r.Run(":8080")
-	if err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	server := &http.Server{Addr: ":8080", Handler: r}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	stop()
+	log.Infof("Shutting down, deadline %s", *shutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- shutdown(shutdownCtx, server, recordsBus, compressionPolicy, storage, walWriter)
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			log.Fatalf("Failed to shut down: %v", err)
+		}
+		log.Info("Shut down")
+	case <-shutdownCtx.Done():
+		log.Fatalf("Failed to shut down within %s, the records that are not flushed are replayed from the WAL on the next start", *shutdownTimeout)
+	}
+}
+
+// shutdown stops the intake of records and persists the records of the MemTable.
+// The compression and auto-flush routines are already stopped by the context of the application.
+func shutdown(ctx context.Context, server *http.Server, recordsBus *bus.RecordsManager, compressionPolicy *compressor.IntervalCompressPolicy, storage ports.DataStorage, walWriter *wal.V1Writer) error {
+	// End the live tail streams, the server waits for the running requests
+	recordsBus.Close()
+	// Stop accepting requests and wait for the running ones
+	if err := server.Shutdown(ctx); err != nil {
+		return err
+	}
+	// Data files are not merged and compressed while they are written
+	compressionPolicy.Wait()
+	// Flush the active chunk and wait until the flusher closed every data file writer
+	if err := storage.Close(); err != nil {
+		return err
 	}
+	// Every record is in a data file, the WAL files are removed
+	return walWriter.Close()
 }
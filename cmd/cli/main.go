@@ -1,64 +1,140 @@
 package main
 
 import (
+	"LogDb/internal/adapters/bus"
+	"LogDb/internal/adapters/compression"
+	"LogDb/internal/adapters/compressor"
 	"LogDb/internal/adapters/datastor"
 	"LogDb/internal/adapters/filters"
 	"LogDb/internal/adapters/filters/label_conditions"
 	"LogDb/internal/adapters/index"
+	"LogDb/internal/adapters/memtable"
+	"LogDb/internal/adapters/merge"
 	"LogDb/internal/adapters/presenters"
 	"LogDb/internal/adapters/query"
+	"LogDb/internal/adapters/query/parser"
+	"LogDb/internal/adapters/schema"
 	"LogDb/internal/adapters/serializer"
+	"LogDb/internal/domain"
+	"LogDb/internal/domain/compression_types"
 	"LogDb/internal/domain/query_types"
-	"LogDb/pkg/utils/usage"
+	"LogDb/internal/ports"
+	"bufio"
+	"context"
+	"flag"
 	"fmt"
-	"log"
+	log "github.com/sirupsen/logrus"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"time"
 )
 
-func init() {
-	go func() {
-		for {
-			usage.MemoryUsage()
-			time.Sleep(1 * time.Second)
-		}
-	}()
-}
-
 const BaseDir = ".storage"
+const DataFileExt = "chunk"
+const SchemaFile = "schemas.json"
 
 func main() {
-	codec := serializer.Default
-	idx := index.NewTimestamp(BaseDir, codec)
-	stor := datastor.NewPersistentStorage(BaseDir, codec, idx)
-	render := presenters.NewQueryResultPresenter(presenters.NewLogRecordRawStringPresenter())
-	queryProcessor := query.NewPreparer(filters.Factory, label_conditions.Factory)
+	queryText := flag.String("q", "", "query to execute, statements are read from stdin when empty")
+	scanWorkers := flag.Int("scan-workers", runtime.NumCPU(), "data files a query reads at the same time")
+	flag.Parse()
 
-	defer stor.Close()
-	FiveDaysAgo := time.Now().Add(-24 * time.Hour * 5)
-	RightNow := time.Now()
-	builder := query.NewQueryBuilder(query_types.Select, "audit", "logs").
-		SetPartition("shard1").
-		SetTimeRange(FiveDaysAgo, RightNow).
-		//Where("label.foo", query_types.Exists, nil).
-		//Where("label.bar", query_types.IsNotNull, nil).
-		//Where("label.baz", query_types.Equal, "fiz").
-		//Where("message", query_types.Contains, "DELETE").
-		Limit(10).
-		AggregateBy(query_types.Minute).
-		SetFormat(query_types.JSON)
-	q, err := builder.Build()
+	codec := serializer.Default
+	schemas, err := schema.NewFileStore(filepath.Join(BaseDir, SchemaFile))
 	if err != nil {
-		panic(err)
+		log.Fatalf("Failed to load schemas: %v", err)
 	}
-	log.Printf("Executing query: %s", q)
-	preparedQ, err := queryProcessor.PrepareQuery(q)
-	if err != nil {
-		panic(err)
+	repo := datastor.NewDataFileRepository(BaseDir, codec, DataFileExt)
+	dataFileFactory := datastor.NewDataFileWriterFactory(repo, log.NewEntry(log.StandardLogger()))
+	dataFileManagerFactory := datastor.NewDataFileManagerFactory(repo)
+	dataPageReaderFactory := datastor.NewDataPageReaderFactory(repo.Codec(), domain.SmallChunks)
+	merger := merge.NewMerger(dataFileFactory, dataFileManagerFactory, dataPageReaderFactory, repo)
+	dataCompressor := compressor.NewDataFileCompressor(
+		repo,
+		dataFileFactory,
+		dataFileManagerFactory,
+		compression.Factory,
+		compression_types.Zstd,
+	)
+	idx := index.NewTimestamp(repo, merger, dataCompressor)
+	dataFilesChangesBus := bus.NewDataFilesManager()
+	sequentialWriter := datastor.NewSequentialLogCollectorFactory(
+		dataFileFactory,
+		datastor.NewDataPageHeaderFactory(),
+		dataFilesChangesBus,
+	)
+	flusher := memtable.NewFlusher(sequentialWriter)
+	defer flusher.Close()
+	memTable := memtable.NewMemTable(context.Background(), 1024*1024, 1_000, func(maxSize, maxRecords int) ports.HeapChunk {
+		return memtable.NewHeapChunk(maxSize, maxRecords)
+	}, flusher, 60*time.Second)
+	stor := datastor.NewPersistentStorage(memTable, dataFileManagerFactory, dataPageReaderFactory, idx)
+	defer stor.Close()
+	stor.SetScanOptions(datastor.ScanOptions{Workers: *scanWorkers, SplitDataFiles: true})
+
+	queryParser := parser.NewParser()
+	queryProcessor := query.NewPreparer(filters.Factory, label_conditions.Factory, schemas)
+	renders := presenters.NewQueryResultPresenters(schemas, codec)
+
+	execute := func(text string) error {
+		q, err := queryParser.Parse(text)
+		if err != nil {
+			return err
+		}
+		preparedQ, err := queryProcessor.PrepareQuery(q)
+		if err != nil {
+			return err
+		}
+		format := q.Format
+		if format == query_types.DefaultFormat {
+			format = query_types.Text
+		}
+		render, err := renders.Presenter(format)
+		if err != nil {
+			return err
+		}
+		result, err := stor.Query(context.Background(), preparedQ)
+		if err != nil {
+			return err
+		}
+		output, err := render.Present(result)
+		if err != nil {
+			return err
+		}
+		if format == query_types.Binary {
+			_, err = os.Stdout.WriteString(output)
+			return err
+		}
+		fmt.Println(output)
+		return nil
 	}
 
-	result, err := stor.Query(preparedQ)
-	if err != nil {
-		panic(err)
+	if *queryText != "" {
+		if err := execute(*queryText); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Statements read from stdin are terminated by ';' and may span several lines
+	var statement strings.Builder
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		statement.WriteString(scanner.Text())
+		statement.WriteString("\n")
+		if !strings.HasSuffix(strings.TrimSpace(scanner.Text()), ";") {
+			continue
+		}
+		if err := execute(statement.String()); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		statement.Reset()
+	}
+	if strings.TrimSpace(statement.String()) != "" {
+		if err := execute(statement.String()); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
 	}
-	fmt.Println(render.Present(result))
 }
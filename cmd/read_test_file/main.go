@@ -2,21 +2,26 @@ package main
 
 import (
 	"LogDb/internal/adapters/presenters"
+	"LogDb/internal/adapters/schema"
 	"LogDb/internal/adapters/serializer"
 	"LogDb/internal/domain"
 	"LogDb/internal/ports"
 	"fmt"
 	"io"
 	"os"
-	"time"
 )
 
 const fileName = "test.chunk"
+const schemaFileName = "schemas.json"
 
 func main() {
 
 	codec := &serializer.BinarySerializer{}
-	presenter := presenters.NewLogRecordRawStringPresenter()
+	schemas, err := schema.NewFileStore(schemaFileName)
+	if err != nil {
+		panic(err)
+	}
+	presenter := presenters.NewLogRecordRawStringPresenter(schemas)
 	fh, err := os.OpenFile(fileName, os.O_RDWR|os.O_CREATE, 0600)
 	if err != nil {
 		panic(err)
@@ -90,7 +95,8 @@ func readRecord(codec ports.Serializer, reader io.Reader) (*domain.LogRecord, er
 	if err != nil {
 		return nil, err
 	}
-	record.Timestamp = time.Unix(int64(recordMeta.Timestamp), 0)
+	record.Timestamp = recordMeta.Time()
+	record.SchemaVersion = recordMeta.SchemaVersion
 	record.Message = message
 	return record, nil
 }
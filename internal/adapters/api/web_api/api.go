@@ -11,16 +11,22 @@ type WebApi struct {
 	storage           ports.DataStorage
 	queryBuilder      ports.QueryBuilderFactory
 	queryProcessor    ports.QueryPreparer
+	queryParser       ports.QueryParser
+	records           ports.RecordsSubscriber
+	presenters        ports.QueryResultPresenterFactory
 	recordTransformer *RecordTransformer
 }
 
 // NewWebApi creates a new instance of WebApi with injected storage dependency
-func NewWebApi(storage ports.DataStorage, qb ports.QueryBuilderFactory, qp ports.QueryPreparer) *WebApi {
+func NewWebApi(storage ports.DataStorage, qb ports.QueryBuilderFactory, qp ports.QueryPreparer, parser ports.QueryParser, schemas ports.SchemaStore, records ports.RecordsSubscriber, presenters ports.QueryResultPresenterFactory) *WebApi {
 	return &WebApi{
 		storage:           storage,
 		queryBuilder:      qb,
 		queryProcessor:    qp,
-		recordTransformer: DefaultRecordTransformer,
+		queryParser:       parser,
+		records:           records,
+		presenters:        presenters,
+		recordTransformer: NewRecordTransformer(schemas),
 	}
 }
 
@@ -31,5 +37,8 @@ func (api *WebApi) RegisterRoutes(router *gin.Engine) {
 		v1.POST("/search/records", api.SearchRecords)
 		v1.POST("/insert/record", api.InsertRecord)
 		v1.POST("/insert/records", api.InsertRecords)
+		v1.POST("/ingest/ndjson", api.IngestNDJSON)
+		v1.POST("/query", api.Query)
+		v1.GET("/tail", api.Tail)
 	}
 }
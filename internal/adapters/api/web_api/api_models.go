@@ -11,10 +11,59 @@ type StoreResult struct {
 	Error          string `json:"error,omitempty"`
 }
 
+// Record represents a log record, the timestamp keeps nanosecond precision
 type Record struct {
-	Timestamp    time.Time         `json:"timestamp"`
-	Message      string            `json:"message"`
-	StringLabels map[string]string `json:"string_labels"`
+	Timestamp     time.Time         `json:"timestamp"`
+	TimestampNano int64             `json:"timestamp_ns,omitempty"` // UNIX nanoseconds, takes precedence over timestamp when set
+	Message       string            `json:"message"`
+	StringLabels  map[string]string `json:"string_labels"`
+}
+
+// RecordError describes why the record at the index of a batch was not stored
+type RecordError struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+// StoreBatchResult represents the result of a batch insert operation, valid records are stored
+// even when other records of the batch fail
+type StoreBatchResult struct {
+	Success        bool           `json:"success"`
+	RecordInserted int            `json:"record_inserted"`
+	Errors         []*RecordError `json:"errors,omitempty"`
+}
+
+// LineError describes why the line of a NDJSON stream was not stored
+type LineError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// IngestResult represents the acknowledgement of the records of a NDJSON ingestion stored so far, or its summary when
+// done, only the first errors are listed
+type IngestResult struct {
+	Done           bool         `json:"done"`
+	Success        bool         `json:"success"`
+	LinesRead      int          `json:"lines_read"`
+	RecordInserted int          `json:"record_inserted"`
+	RecordFailed   int          `json:"record_failed"`
+	Errors         []*LineError `json:"errors,omitempty"`
+	Error          string       `json:"error,omitempty"` // set when the stream could not be read to the end
+}
+
+// TailEvent represents an event of the live tail, a record, the number of records dropped so far or
+// the reason the stream ended
+type TailEvent struct {
+	Record  *Record `json:"record,omitempty"`
+	Dropped uint64  `json:"dropped,omitempty"`
+	Error   string  `json:"error,omitempty"`
+}
+
+// SearchEvent represents a line of a streamed ndjson search result, a record or the report closing the result
+type SearchEvent struct {
+	Record *Record       `json:"record,omitempty"`
+	Report *SearchReport `json:"report,omitempty"`
+	Error  string        `json:"error,omitempty"` // set with the report when the query stopped early
 }
 
 // StoreRequest represents a request to search records
@@ -31,22 +80,77 @@ type StoreBatchRequest struct {
 
 // SearchRequest represents a request to search records
 type SearchRequest struct {
-	FromTime           time.Time `json:"from_time"`
-	ToTime             time.Time `json:"to_time"`
-	ShardingKey        string    `json:"sharding_key"`
-	MessageMustContain string    `json:"message_contains,omitempty"`
-	Limit              int       `json:"limit"`
+	FromTime           time.Time    `json:"from_time"`
+	ToTime             time.Time    `json:"to_time"`
+	ShardingKey        string       `json:"sharding_key"`
+	MessageMustContain string       `json:"message_contains,omitempty"`
+	Limit              int          `json:"limit"`
+	Order              string       `json:"order,omitempty"`         // asc (default) or desc
+	AggregatedBy       string       `json:"aggregated_by,omitempty"` // minute, hour, day, week, month, quarter or year
+	GroupBy            []string     `json:"group_by,omitempty"`      // labels grouping the aggregation, e.g. label.service
+	Format             string       `json:"format,omitempty"`        // text, csv, json, yaml or binary, else from the Accept header
+	Cursor             string       `json:"cursor,omitempty"`        // next_cursor of the previous page of results
+	Concurrency        int          `json:"concurrency,omitempty"`   // workers scanning the data files, at most the workers of the node
+	Timeout            string       `json:"timeout,omitempty"`       // time limit of the query, e.g. 5s, the result is truncated
+	Budget             *QueryBudget `json:"budget,omitempty"`        // limits of the resources of the query, at most the budget of the node
+	Explain            bool         `json:"explain,omitempty"`       // return the plan of the query instead of its records
+}
+
+// QueryRequest represents a request with a query written in the query language
+type QueryRequest struct {
+	Query   string       `json:"query" binding:"required"`
+	Cursor  string       `json:"cursor,omitempty"`  // next_cursor of the previous page of results
+	Timeout string       `json:"timeout,omitempty"` // time limit of the query, e.g. 5s, the result is truncated
+	Budget  *QueryBudget `json:"budget,omitempty"`  // limits of the resources of the query, at most the budget of the node
+	Explain bool         `json:"explain,omitempty"` // return the plan of the query instead of its records, as explain does
+}
+
+// QueryBudget represents the limits of the resources of a query, a missing or zero limit is unlimited
+type QueryBudget struct {
+	MaxScannedBytes   uint64 `json:"max_scanned_bytes,omitempty"`   // bytes of the data pages and MemTable records read
+	MaxScannedRecords uint64 `json:"max_scanned_records,omitempty"` // records read, in and out of the time range
+	MaxResultBytes    uint64 `json:"max_result_bytes,omitempty"`    // bytes of the records of the result
+	MaxDataFiles      int    `json:"max_data_files,omitempty"`      // data files opened
+}
+
+// QueryUsage represents what a query consumed of its budget
+type QueryUsage struct {
+	ScannedBytes   uint64 `json:"scanned_bytes"`
+	ScannedRecords uint64 `json:"scanned_records"`
+	ResultBytes    uint64 `json:"result_bytes"`
+	DataFiles      int    `json:"data_files"`
 }
 
 type SearchReport struct {
-	TotalRecords   int     `json:"total_records"`
-	ScannedRecords int     `json:"scanned_records"`
-	TimeTaken      float64 `json:"time_taken"`
+	TotalRecords   int         `json:"total_records"`
+	ScannedRecords int         `json:"scanned_records"`
+	TimeTaken      float64     `json:"time_taken"`
+	Truncated      bool        `json:"truncated,omitempty"` // the query was cancelled or timed out, more records may match
+	Usage          *QueryUsage `json:"usage"`               // what the query consumed of its budget
 }
 
 type SearchResult struct {
-	Records []*Record     `json:"records"`
-	Report  *SearchReport `json:"report"`
+	Records     []*Record          `json:"records"`
+	Report      *SearchReport      `json:"report"`
+	Aggregation *AggregationResult `json:"aggregation,omitempty"` // set for aggregated queries instead of the records
+	NextCursor  string             `json:"next_cursor,omitempty"` // set when more records follow the limit
+}
+
+// AggregationBucket represents the number of matching records in a time bucket with the same grouping label values
+type AggregationBucket struct {
+	Start   time.Time           `json:"start"`
+	Labels  map[string]string   `json:"labels,omitempty"`
+	Count   uint64              `json:"count"`
+	Metrics map[string]*float64 `json:"metrics,omitempty"` // keyed by metric, e.g. avg(label.duration), null without values
+}
+
+// AggregationResult represents the counts of an aggregated query ordered by bucket start and label values
+type AggregationResult struct {
+	Dimension string               `json:"dimension"`
+	GroupBy   []string             `json:"group_by,omitempty"`
+	Metrics   []string             `json:"metrics,omitempty"`
+	Total     uint64               `json:"total"`
+	Buckets   []*AggregationBucket `json:"buckets"`
 }
 
 // NewSearchResult creates a new instance of SearchResult
@@ -61,3 +165,16 @@ func NewSearchResult() *SearchResult {
 type ErrorResponse struct {
 	Error string `json:"error"`
 }
+
+// BudgetErrorResponse represents a query that consumed more than its budget and what it consumed
+type BudgetErrorResponse struct {
+	Error  string        `json:"error"`
+	Report *SearchReport `json:"report"`
+}
+
+// QueryErrorResponse represents a query that failed to parse and where the problem is
+type QueryErrorResponse struct {
+	Error  string `json:"error"`
+	Line   int    `json:"line,omitempty"`
+	Column int    `json:"column,omitempty"`
+}
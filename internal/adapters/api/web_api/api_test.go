@@ -0,0 +1,80 @@
+package web_api
+
+import (
+	"LogDb/internal/adapters/filters"
+	"LogDb/internal/adapters/filters/label_conditions"
+	"LogDb/internal/adapters/presenters"
+	"LogDb/internal/adapters/query"
+	"LogDb/internal/adapters/query/parser"
+	"LogDb/internal/adapters/schema"
+	"LogDb/internal/adapters/serializer"
+	"LogDb/internal/domain"
+	"LogDb/internal/ports"
+	"bytes"
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+var _ ports.DataStorage = new(testStorage)
+
+// testStorage keeps the records it stores, its store function tells how many records of a batch are stored, and
+// answers queries with its query function
+type testStorage struct {
+	stored []*domain.LogRecord
+	store  func(records []*domain.LogRecord) (int, error)
+	query  func(query ports.PreparedQuery) (*domain.QueryResult, error)
+}
+
+func (s *testStorage) StoreLogRecord(record *domain.LogRecord) error {
+	_, err := s.StoreLogRecords([]*domain.LogRecord{record})
+	return err
+}
+
+func (s *testStorage) StoreLogRecords(records []*domain.LogRecord) (int, error) {
+	stored, err := len(records), error(nil)
+	if s.store != nil {
+		stored, err = s.store(records)
+	}
+	s.stored = append(s.stored, records[:stored]...)
+	return stored, err
+}
+
+func (s *testStorage) Close() error { return nil }
+
+func (s *testStorage) Query(_ context.Context, query ports.PreparedQuery) (*domain.QueryResult, error) {
+	query.Begin()
+	defer query.End()
+	if s.query != nil {
+		return s.query(query)
+	}
+	return query.Result()
+}
+
+// newTestRouter returns a router of the routes of an api over the storage, the schemas are kept in a temporary file
+func newTestRouter(t *testing.T, storage ports.DataStorage) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	schemas, err := schema.NewFileStore(filepath.Join(t.TempDir(), "schemas.json"))
+	require.NoError(t, err)
+	api := NewWebApi(storage, query.NewQueryBuilderFactory(), query.NewPreparer(filters.Factory, label_conditions.Factory, schemas),
+		parser.NewParser(), schemas, nil, presenters.NewQueryResultPresenters(schemas, serializer.Default))
+	router := gin.New()
+	api.RegisterRoutes(router)
+	return router
+}
+
+// serve sends the body to the route with the headers, given as name and value pairs
+func serve(router *gin.Engine, path string, body []byte, headers ...string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	for i := 0; i+1 < len(headers); i += 2 {
+		request.Header.Set(headers[i], headers[i+1])
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
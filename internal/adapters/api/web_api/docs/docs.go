@@ -15,9 +15,67 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/api/v1/ingest/ndjson": {
+            "post": {
+                "description": "Read newline-delimited JSON records (one Record per line) and store them while the body arrives,\nthe records are stored in batches of up to 1000 lines, a batch is stored as soon as the received\nlines are read. Every stored batch is acknowledged by an IngestResult line with the totals so far,\nthe last line is the summary with done set, also when the body fails after the first acknowledgement.\nMalformed or invalid lines are reported by their\nline number and do not stop the stream, only the first 100 line errors are listed.\nThe body can be compressed with gzip or zstd, up to 4 GiB decompressed.",
+                "consumes": [
+                    "application/x-ndjson"
+                ],
+                "produces": [
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "logs"
+                ],
+                "summary": "Ingest a stream of log records",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Compression of the body: gzip or zstd",
+                        "name": "Content-Encoding",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Records, one per line",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/web_api.Record"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/web_api.IngestResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/web_api.IngestResult"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/web_api.IngestResult"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/web_api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/api/v1/insert/record": {
             "post": {
-                "description": "Insert a single log record into storage",
+                "description": "Insert a single log record into storage. The body can be compressed with gzip or zstd, up to 64 MiB\ndecompressed.",
                 "consumes": [
                     "application/json"
                 ],
@@ -29,6 +87,12 @@ const docTemplate = `{
                 ],
                 "summary": "Insert a single log record",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Compression of the body: gzip or zstd",
+                        "name": "Content-Encoding",
+                        "in": "header"
+                    },
                     {
                         "description": "Record to Insert",
                         "name": "body",
@@ -51,13 +115,25 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/web_api.ErrorResponse"
                         }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/web_api.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/web_api.ErrorResponse"
+                        }
                     }
                 }
             }
         },
         "/api/v1/insert/records": {
             "post": {
-                "description": "Insert multiple log records into storage",
+                "description": "Insert multiple log records into storage. Every record is validated on its own, the records\nthat fail are reported by their index in the batch and do not stop the others. The valid records\nare stored as one batch in order, when storing fails the records that were not stored are reported.\nThe body can be compressed with gzip or zstd, up to 64 MiB decompressed, set the Content-Encoding\nheader accordingly.",
                 "consumes": [
                     "application/json"
                 ],
@@ -69,6 +145,12 @@ const docTemplate = `{
                 ],
                 "summary": "Insert multiple log records",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Compression of the body: gzip or zstd",
+                        "name": "Content-Encoding",
+                        "in": "header"
+                    },
                     {
                         "description": "Records to Insert",
                         "name": "body",
@@ -83,7 +165,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/web_api.StoreResult"
+                            "$ref": "#/definitions/web_api.StoreBatchResult"
                         }
                     },
                     "400": {
@@ -91,19 +173,93 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/web_api.ErrorResponse"
                         }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/web_api.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/web_api.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/api/v1/search/records": {
+        "/api/v1/query": {
             "post": {
-                "description": "Search log records based on criteria",
+                "description": "Parse a query written in the query language (see docs/query.md) and execute it. The result is written\nin the format of the query, or else in the format of the Accept header, json by default.\nThe records of ndjson results and of json results without a limit or with a limit above 1000 are\nstreamed in the order they are found, the report comes last.\nA collected result reaching its limit has a next_cursor, passed as cursor it returns the following records.\nA query stopped by its timeout or by the client returns the records found so far, marked as truncated.\nA query consuming more than its budget fails with 422 and the report of what it consumed.\nAn explain statement (or explain in the request) returns the plan of the query instead of its records.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
+                    "application/json",
+                    "application/x-ndjson",
+                    "text/plain",
+                    "text/csv",
+                    "application/yaml",
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "logs"
+                ],
+                "summary": "Execute a query",
+                "parameters": [
+                    {
+                        "description": "Query",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/web_api.QueryRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/web_api.SearchResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/web_api.QueryErrorResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Gone",
+                        "schema": {
+                            "$ref": "#/definitions/web_api.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/web_api.BudgetErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/search/records": {
+            "post": {
+                "description": "Search log records based on criteria. The result is written in the format of the request, or else\nin the format of the Accept header (json, ndjson, text, csv, yaml or binary records), json by default.\nThe records of ndjson results and of json results without a limit or with a limit above 1000 are\nstreamed in the order they are found, the report comes last.\nA collected result reaching its limit has a next_cursor (also in the X-Next-Cursor header), passed\nas cursor it returns the records following the last one. A cursor whose data file was merged fails with 410.\nA query cancelled by the client or stopped by its timeout returns the records found so far, the report\nis marked as truncated.\nA query consuming more than its budget, the lower of the budget of the request and of the node, fails\nwith 422 and the report of what it consumed.\nWith explain the query is not executed, the result has the plan of the query instead of the records: the\ndata files selected by the index, the pages visited after time pruning, the compiled filters and the\nestimated records from the page directories.",
+                "consumes": [
                     "application/json"
                 ],
+                "produces": [
+                    "application/json",
+                    "application/x-ndjson",
+                    "text/plain",
+                    "text/csv",
+                    "application/yaml",
+                    "application/octet-stream"
+                ],
                 "tags": [
                     "logs"
                 ],
@@ -126,6 +282,70 @@ const docTemplate = `{
                             "$ref": "#/definitions/web_api.SearchResult"
                         }
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/web_api.ErrorResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Gone",
+                        "schema": {
+                            "$ref": "#/definitions/web_api.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/web_api.BudgetErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/tail": {
+            "get": {
+                "description": "Stream the records stored after the request as Server-Sent Events, or as WebSocket messages when the\nrequest upgrades the connection. Every event is a TailEvent, SSE events are named record, dropped and error.\nThe records are filtered by the message, by label conditions given as label.{name}={value} parameters\nand by the conditions of a query, the time range and the limit of the query are ignored.\nA subscriber that does not read fast enough either loses records (drop, the dropped event tells how many)\nor is disconnected (disconnect).",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "logs"
+                ],
+                "summary": "Stream newly stored log records",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Substring of the message",
+                        "name": "message_contains",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Query in the query language, only the conditions are used",
+                        "name": "query",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Records buffered for a slow subscriber, default 1000, max 10000",
+                        "name": "buffer",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Slow consumer policy: drop (default) or disconnect",
+                        "name": "on_slow",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/web_api.TailEvent"
+                        }
+                    },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
@@ -137,6 +357,70 @@ const docTemplate = `{
         }
     },
     "definitions": {
+        "web_api.AggregationBucket": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "labels": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "metrics": {
+                    "description": "keyed by metric, e.g. avg(label.duration), null without values",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number"
+                    }
+                },
+                "start": {
+                    "type": "string"
+                }
+            }
+        },
+        "web_api.AggregationResult": {
+            "type": "object",
+            "properties": {
+                "buckets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/web_api.AggregationBucket"
+                    }
+                },
+                "dimension": {
+                    "type": "string"
+                },
+                "group_by": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "metrics": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "web_api.BudgetErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "report": {
+                    "$ref": "#/definitions/web_api.SearchReport"
+                }
+            }
+        },
         "web_api.ErrorResponse": {
             "type": "object",
             "properties": {
@@ -145,6 +429,130 @@ const docTemplate = `{
                 }
             }
         },
+        "web_api.IngestResult": {
+            "type": "object",
+            "properties": {
+                "done": {
+                    "type": "boolean"
+                },
+                "error": {
+                    "description": "set when the stream could not be read to the end",
+                    "type": "string"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/web_api.LineError"
+                    }
+                },
+                "lines_read": {
+                    "type": "integer"
+                },
+                "record_failed": {
+                    "type": "integer"
+                },
+                "record_inserted": {
+                    "type": "integer"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "web_api.LineError": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "line": {
+                    "type": "integer"
+                }
+            }
+        },
+        "web_api.QueryBudget": {
+            "type": "object",
+            "properties": {
+                "max_data_files": {
+                    "description": "data files opened",
+                    "type": "integer"
+                },
+                "max_result_bytes": {
+                    "description": "bytes of the records of the result",
+                    "type": "integer"
+                },
+                "max_scanned_bytes": {
+                    "description": "bytes of the data pages and MemTable records read",
+                    "type": "integer"
+                },
+                "max_scanned_records": {
+                    "description": "records read, in and out of the time range",
+                    "type": "integer"
+                }
+            }
+        },
+        "web_api.QueryErrorResponse": {
+            "type": "object",
+            "properties": {
+                "column": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "line": {
+                    "type": "integer"
+                }
+            }
+        },
+        "web_api.QueryRequest": {
+            "type": "object",
+            "required": [
+                "query"
+            ],
+            "properties": {
+                "budget": {
+                    "description": "limits of the resources of the query, at most the budget of the node",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/web_api.QueryBudget"
+                        }
+                    ]
+                },
+                "cursor": {
+                    "description": "next_cursor of the previous page of results",
+                    "type": "string"
+                },
+                "explain": {
+                    "description": "return the plan of the query instead of its records, as explain does",
+                    "type": "boolean"
+                },
+                "query": {
+                    "type": "string"
+                },
+                "timeout": {
+                    "description": "time limit of the query, e.g. 5s, the result is truncated",
+                    "type": "string"
+                }
+            }
+        },
+        "web_api.QueryUsage": {
+            "type": "object",
+            "properties": {
+                "data_files": {
+                    "type": "integer"
+                },
+                "result_bytes": {
+                    "type": "integer"
+                },
+                "scanned_bytes": {
+                    "type": "integer"
+                },
+                "scanned_records": {
+                    "type": "integer"
+                }
+            }
+        },
         "web_api.Record": {
             "type": "object",
             "properties": {
@@ -159,6 +567,21 @@ const docTemplate = `{
                 },
                 "timestamp": {
                     "type": "string"
+                },
+                "timestamp_ns": {
+                    "description": "UNIX nanoseconds, takes precedence over timestamp when set",
+                    "type": "integer"
+                }
+            }
+        },
+        "web_api.RecordError": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "index": {
+                    "type": "integer"
                 }
             }
         },
@@ -173,24 +596,79 @@ const docTemplate = `{
                 },
                 "total_records": {
                     "type": "integer"
+                },
+                "truncated": {
+                    "description": "the query was cancelled or timed out, more records may match",
+                    "type": "boolean"
+                },
+                "usage": {
+                    "description": "what the query consumed of its budget",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/web_api.QueryUsage"
+                        }
+                    ]
                 }
             }
         },
         "web_api.SearchRequest": {
             "type": "object",
             "properties": {
+                "aggregated_by": {
+                    "description": "minute, hour, day, week, month, quarter or year",
+                    "type": "string"
+                },
+                "budget": {
+                    "description": "limits of the resources of the query, at most the budget of the node",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/web_api.QueryBudget"
+                        }
+                    ]
+                },
+                "concurrency": {
+                    "description": "workers scanning the data files, at most the workers of the node",
+                    "type": "integer"
+                },
+                "cursor": {
+                    "description": "next_cursor of the previous page of results",
+                    "type": "string"
+                },
+                "explain": {
+                    "description": "return the plan of the query instead of its records",
+                    "type": "boolean"
+                },
+                "format": {
+                    "description": "text, csv, json, yaml or binary, else from the Accept header",
+                    "type": "string"
+                },
                 "from_time": {
                     "type": "string"
                 },
+                "group_by": {
+                    "description": "labels grouping the aggregation, e.g. label.service",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "limit": {
                     "type": "integer"
                 },
                 "message_contains": {
                     "type": "string"
                 },
+                "order": {
+                    "description": "asc (default) or desc",
+                    "type": "string"
+                },
                 "sharding_key": {
                     "type": "string"
                 },
+                "timeout": {
+                    "description": "time limit of the query, e.g. 5s, the result is truncated",
+                    "type": "string"
+                },
                 "to_time": {
                     "type": "string"
                 }
@@ -199,6 +677,18 @@ const docTemplate = `{
         "web_api.SearchResult": {
             "type": "object",
             "properties": {
+                "aggregation": {
+                    "description": "set for aggregated queries instead of the records",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/web_api.AggregationResult"
+                        }
+                    ]
+                },
+                "next_cursor": {
+                    "description": "set when more records follow the limit",
+                    "type": "string"
+                },
                 "records": {
                     "type": "array",
                     "items": {
@@ -224,6 +714,23 @@ const docTemplate = `{
                 }
             }
         },
+        "web_api.StoreBatchResult": {
+            "type": "object",
+            "properties": {
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/web_api.RecordError"
+                    }
+                },
+                "record_inserted": {
+                    "type": "integer"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
         "web_api.StoreRequest": {
             "type": "object",
             "properties": {
@@ -248,6 +755,20 @@ const docTemplate = `{
                     "type": "boolean"
                 }
             }
+        },
+        "web_api.TailEvent": {
+            "type": "object",
+            "properties": {
+                "dropped": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "record": {
+                    "$ref": "#/definitions/web_api.Record"
+                }
+            }
         }
     }
 }`
@@ -0,0 +1,136 @@
+package web_api
+
+import (
+	"LogDb/internal/domain"
+	"LogDb/internal/internal_errors"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"sort"
+)
+
+// maxIngestLineErrors is the number of line errors listed in the ingestion summary
+const maxIngestLineErrors = 100
+
+// ingestBatchSize is the largest number of records stored and acknowledged together
+const ingestBatchSize = 1000
+
+// IngestNDJSON godoc
+// @Summary Ingest a stream of log records
+// @Description Read newline-delimited JSON records (one Record per line) and store them while the body arrives,
+// @Description the records are stored in batches of up to 1000 lines, a batch is stored as soon as the received
+// @Description lines are read. Every stored batch is acknowledged by an IngestResult line with the totals so far,
+// @Description the last line is the summary with done set, also when the body fails after the first acknowledgement.
+// @Description Malformed or invalid lines are reported by their
+// @Description line number and do not stop the stream, only the first 100 line errors are listed.
+// @Description The body can be compressed with gzip or zstd, up to 4 GiB decompressed.
+// @Tags logs
+// @Accept application/x-ndjson
+// @Produce application/x-ndjson
+// @Param Content-Encoding header string false "Compression of the body: gzip or zstd"
+// @Param body body Record true "Records, one per line"
+// @Success 200 {object} IngestResult
+// @Failure 400 {object} IngestResult
+// @Failure 413 {object} IngestResult
+// @Failure 415 {object} ErrorResponse
+// @Router /api/v1/ingest/ndjson [post]
+func (api *WebApi) IngestNDJSON(c *gin.Context) {
+	body, err := requestBody(c, maxDecompressedStreamBytes)
+	if err != nil {
+		c.JSON(bindErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	defer body.Close()
+
+	var result IngestResult
+	records := make([]*domain.LogRecord, 0, ingestBatchSize)
+	lines := make([]int, 0, ingestBatchSize)
+	store := func() {
+		stored, err := api.storage.StoreLogRecords(records)
+		result.RecordInserted += stored
+		if err != nil {
+			for _, line := range lines[stored:] {
+				result.addLineError(line, err)
+			}
+		}
+		records, lines = records[:0], lines[:0]
+	}
+	started := false
+	acknowledge := func() {
+		if !started {
+			started = true
+			c.Header("Content-Type", "application/x-ndjson")
+			c.Status(http.StatusOK)
+		}
+		result.sortErrors()
+		_ = json.NewEncoder(c.Writer).Encode(result)
+		c.Writer.Flush()
+	}
+
+	reader := NewNDJSONReader(body)
+	for {
+		line, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		result.LinesRead = reader.Line()
+		if err != nil && !errors.Is(err, internal_errors.NDJSONLineTooLong) {
+			// the body itself failed, the following lines are lost
+			store()
+			result.Done = true
+			result.Error = err.Error()
+			if !started {
+				result.sortErrors()
+				c.JSON(bindErrorStatus(err), result)
+				return
+			}
+			acknowledge()
+			return
+		}
+		var record *domain.LogRecord
+		if err == nil {
+			record, err = api.decodeLine(line)
+		}
+		if err != nil {
+			result.addLineError(reader.Line(), err)
+		} else {
+			records = append(records, record)
+			lines = append(lines, reader.Line())
+		}
+		// the records received so far are stored before waiting for the next line of a slow stream
+		if len(records) == ingestBatchSize || len(records) > 0 && !reader.HasLine() {
+			store()
+			acknowledge()
+		}
+	}
+	store()
+	result.Done = true
+	result.Success = result.RecordFailed == 0
+	acknowledge()
+}
+
+// decodeLine decodes and validates the record of a single line
+func (api *WebApi) decodeLine(line []byte) (*domain.LogRecord, error) {
+	var record *Record
+	if err := json.Unmarshal(line, &record); err != nil {
+		return nil, err
+	}
+	return api.recordTransformer.ToInternal(record)
+}
+
+// addLineError counts the failed line, only the first errors are listed
+func (r *IngestResult) addLineError(line int, err error) {
+	r.RecordFailed++
+	if len(r.Errors) < maxIngestLineErrors {
+		r.Errors = append(r.Errors, &LineError{Line: line, Error: err.Error()})
+	}
+}
+
+// sortErrors orders the line errors by line, errors of stored batches are added after the lines that follow them
+func (r *IngestResult) sortErrors() {
+	sort.Slice(r.Errors, func(i, j int) bool {
+		return r.Errors[i].Line < r.Errors[j].Line
+	})
+}
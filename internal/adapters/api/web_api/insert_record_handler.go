@@ -7,26 +7,42 @@ import (
 
 // InsertRecord godoc
 // @Summary Insert a single log record
-// @Description Insert a single log record into storage
+// @Description Insert a single log record into storage. The body can be compressed with gzip or zstd, up to 64 MiB
+// @Description decompressed.
 // @Tags logs
 // @Accept json
 // @Produce json
+// @Param Content-Encoding header string false "Compression of the body: gzip or zstd"
 // @Param body body StoreRequest true "Record to Insert"
 // @Success 200 {object} StoreResult
 // @Failure 400 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Failure 415 {object} ErrorResponse
 // @Router /api/v1/insert/record [post]
 func (api *WebApi) InsertRecord(c *gin.Context) {
 	var request StoreRequest
 	var result StoreResult
-	if err := c.ShouldBindJSON(&request); err != nil {
+	if err := bindJSON(c, &request); err != nil {
+		c.JSON(bindErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if err := api.recordTransformer.Validate(request.Record); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	err := api.storage.StoreLogRecord(api.recordTransformer.ToInternal(request.Record))
+	record, err := api.recordTransformer.ToInternal(request.Record)
 	if err != nil {
 		result.Success = false
 		result.Error = err.Error()
 		c.JSON(http.StatusInternalServerError, result)
+		return
+	}
+	err = api.storage.StoreLogRecord(record)
+	if err != nil {
+		result.Success = false
+		result.Error = err.Error()
+		c.JSON(http.StatusInternalServerError, result)
+		return
 	}
 	result.Success = true
 	result.RecordInserted = 1
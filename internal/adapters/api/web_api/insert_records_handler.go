@@ -1,27 +1,58 @@
 package web_api
 
 import (
+	"LogDb/internal/domain"
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"sort"
 )
 
 // InsertRecords godoc
 // @Summary Insert multiple log records
-// @Description Insert multiple log records into storage
+// @Description Insert multiple log records into storage. Every record is validated on its own, the records
+// @Description that fail are reported by their index in the batch and do not stop the others. The valid records
+// @Description are stored as one batch in order, when storing fails the records that were not stored are reported.
+// @Description The body can be compressed with gzip or zstd, up to 64 MiB decompressed, set the Content-Encoding
+// @Description header accordingly.
 // @Tags logs
 // @Accept json
 // @Produce json
+// @Param Content-Encoding header string false "Compression of the body: gzip or zstd"
 // @Param body body StoreBatchRequest true "Records to Insert"
-// @Success 200 {object} StoreResult
+// @Success 200 {object} StoreBatchResult
 // @Failure 400 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Failure 415 {object} ErrorResponse
 // @Router /api/v1/insert/records [post]
 func (api *WebApi) InsertRecords(c *gin.Context) {
 	var request StoreBatchRequest
-	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := bindJSON(c, &request); err != nil {
+		c.JSON(bindErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
-	// not implemented
-	c.JSON(http.StatusNotImplemented, gin.H{"error": "not implemented"})
-	return
+	var result StoreBatchResult
+	records := make([]*domain.LogRecord, 0, len(request.Records))
+	indexes := make([]int, 0, len(request.Records))
+	for i, record := range request.Records {
+		internalRecord, err := api.recordTransformer.ToInternal(record)
+		if err != nil {
+			result.Errors = append(result.Errors, &RecordError{Index: i, Error: err.Error()})
+			continue
+		}
+		records = append(records, internalRecord)
+		indexes = append(indexes, i)
+	}
+	// the valid records are stored as one batch, the records following a failure are not stored
+	stored, err := api.storage.StoreLogRecords(records)
+	result.RecordInserted = stored
+	if err != nil {
+		for _, i := range indexes[stored:] {
+			result.Errors = append(result.Errors, &RecordError{Index: i, Error: err.Error()})
+		}
+		sort.Slice(result.Errors, func(i, j int) bool {
+			return result.Errors[i].Index < result.Errors[j].Index
+		})
+	}
+	result.Success = len(result.Errors) == 0
+	c.JSON(http.StatusOK, result)
 }
@@ -0,0 +1,211 @@
+package web_api
+
+import (
+	"LogDb/internal/adapters/compression"
+	"LogDb/internal/domain"
+	"LogDb/internal/domain/compression_types"
+	"LogDb/internal/internal_errors"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testBatch is a batch of five records, the second and the fourth ones are invalid
+const testBatch = `{"records":[
+	{"timestamp":"2024-05-01T10:00:00Z","message":"first"},
+	{"timestamp":"2024-05-01T10:00:01Z","message":""},
+	{"timestamp":"2024-05-01T10:00:02Z","message":"third"},
+	null,
+	{"timestamp":"2024-05-01T10:00:04Z","message":"fifth"}]}`
+
+func compress(t *testing.T, compressionType compression_types.CompressionType, data []byte) []byte {
+	compressed, err := compression.Factory(compressionType).Compress(data)
+	require.NoError(t, err)
+	return compressed
+}
+
+func decodeBatchResult(t *testing.T, body *bytes.Buffer) StoreBatchResult {
+	var result StoreBatchResult
+	require.NoError(t, json.Unmarshal(body.Bytes(), &result))
+	return result
+}
+
+func storedMessages(records []*domain.LogRecord) []string {
+	result := make([]string, len(records))
+	for i, record := range records {
+		result[i] = string(record.Message)
+	}
+	return result
+}
+
+// TestInsertRecords_InvalidRecords tests that the invalid records of a batch are reported by their index and that the
+// valid ones are stored
+func TestInsertRecords_InvalidRecords(t *testing.T) {
+	storage := &testStorage{}
+	recorder := serve(newTestRouter(t, storage), "/api/v1/insert/records", []byte(testBatch))
+
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	result := decodeBatchResult(t, recorder.Body)
+	assert.False(t, result.Success)
+	assert.Equal(t, 3, result.RecordInserted)
+	require.Len(t, result.Errors, 2)
+	assert.Equal(t, 1, result.Errors[0].Index)
+	assert.Contains(t, result.Errors[0].Error, internal_errors.RecordMessageMissing.Error())
+	assert.Equal(t, 3, result.Errors[1].Index)
+	assert.Contains(t, result.Errors[1].Error, internal_errors.RecordMissing.Error())
+	assert.Equal(t, []string{"first", "third", "fifth"}, storedMessages(storage.stored))
+}
+
+// TestInsertRecords_StoreFails tests that when storing fails only the records that were not stored are reported,
+// in the order of the batch with the invalid records
+func TestInsertRecords_StoreFails(t *testing.T) {
+	storage := &testStorage{store: func(records []*domain.LogRecord) (int, error) {
+		return 1, errors.New("disk full")
+	}}
+	recorder := serve(newTestRouter(t, storage), "/api/v1/insert/records", []byte(testBatch))
+
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	result := decodeBatchResult(t, recorder.Body)
+	assert.False(t, result.Success)
+	assert.Equal(t, 1, result.RecordInserted)
+	indexes := make([]int, len(result.Errors))
+	for i, recordError := range result.Errors {
+		indexes[i] = recordError.Index
+	}
+	assert.Equal(t, []int{1, 2, 3, 4}, indexes)
+	assert.Equal(t, "disk full", result.Errors[1].Error)
+	assert.Equal(t, "disk full", result.Errors[3].Error)
+	assert.Equal(t, []string{"first"}, storedMessages(storage.stored))
+}
+
+// TestInsertRecords_Compressed tests that gzip and zstd bodies are decompressed and that unknown encodings are
+// unsupported
+func TestInsertRecords_Compressed(t *testing.T) {
+	tests := []struct {
+		encoding        string
+		compressionType compression_types.CompressionType
+	}{
+		{"gzip", compression_types.Gzip},
+		{"zstd", compression_types.Zstd},
+		{" ZSTD ", compression_types.Zstd},
+	}
+	for _, test := range tests {
+		t.Run(test.encoding, func(t *testing.T) {
+			storage := &testStorage{}
+			body := compress(t, test.compressionType, []byte(testBatch))
+			recorder := serve(newTestRouter(t, storage), "/api/v1/insert/records", body, "Content-Encoding", test.encoding)
+
+			require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+			assert.Equal(t, 3, decodeBatchResult(t, recorder.Body).RecordInserted)
+			assert.Equal(t, []string{"first", "third", "fifth"}, storedMessages(storage.stored))
+		})
+	}
+
+	recorder := serve(newTestRouter(t, &testStorage{}), "/api/v1/insert/records", []byte(testBatch), "Content-Encoding", "br")
+	assert.Equal(t, http.StatusUnsupportedMediaType, recorder.Code)
+	recorder = serve(newTestRouter(t, &testStorage{}), "/api/v1/insert/records", []byte(testBatch), "Content-Encoding", "gzip")
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+// TestInsertRecords_TooLarge tests that a compressed body larger than the limit once decompressed is rejected
+// before it is read to the end
+func TestInsertRecords_TooLarge(t *testing.T) {
+	storage := &testStorage{}
+	document := `{"records":[{"timestamp":"2024-05-01T10:00:00Z","message":"` + strings.Repeat("a", maxDecompressedBodyBytes) + `"}]}`
+	body := compress(t, compression_types.Gzip, []byte(document))
+	recorder := serve(newTestRouter(t, storage), "/api/v1/insert/records", body, "Content-Encoding", "gzip")
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), internal_errors.RequestBodyTooLarge.Error())
+	assert.Empty(t, storage.stored)
+}
+
+// TestIngestNDJSON_StoreFails tests that when storing a batch fails only the lines that were not stored are reported
+func TestIngestNDJSON_StoreFails(t *testing.T) {
+	storage := &testStorage{store: func(records []*domain.LogRecord) (int, error) {
+		for i, record := range records {
+			if string(record.Message) == "third" {
+				return i, errors.New("disk full")
+			}
+		}
+		return len(records), nil
+	}}
+	body := "{\"timestamp\":\"2024-05-01T10:00:00Z\",\"message\":\"first\"}\n" +
+		"not json\n" +
+		"{\"timestamp\":\"2024-05-01T10:00:02Z\",\"message\":\"third\"}\n"
+	recorder := serve(newTestRouter(t, storage), "/api/v1/ingest/ndjson", compress(t, compression_types.Zstd, []byte(body)), "Content-Encoding", "zstd")
+
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	acknowledgements := strings.Split(strings.TrimSpace(recorder.Body.String()), "\n")
+	var result IngestResult
+	require.NoError(t, json.Unmarshal([]byte(acknowledgements[len(acknowledgements)-1]), &result))
+	assert.True(t, result.Done)
+	assert.Equal(t, 3, result.LinesRead)
+	assert.Equal(t, 1, result.RecordInserted)
+	assert.Equal(t, 2, result.RecordFailed)
+	require.Len(t, result.Errors, 2)
+	assert.Equal(t, 2, result.Errors[0].Line)
+	assert.Equal(t, 3, result.Errors[1].Line)
+	assert.Equal(t, "disk full", result.Errors[1].Error)
+	assert.Equal(t, []string{"first"}, storedMessages(storage.stored))
+}
+
+// flushRecorder is a response recorder sending the body written so far on every flush
+type flushRecorder struct {
+	*httptest.ResponseRecorder
+	flushed chan string
+}
+
+func (r *flushRecorder) Flush() {
+	r.flushed <- r.Body.String()
+}
+
+// TestIngestNDJSON_SlowStream tests that the lines received so far are stored and acknowledged while the stream waits
+// for the next line
+func TestIngestNDJSON_SlowStream(t *testing.T) {
+	storage := &testStorage{}
+	router := newTestRouter(t, storage)
+	body, stream := io.Pipe()
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/ingest/ndjson", body)
+	recorder := &flushRecorder{ResponseRecorder: httptest.NewRecorder(), flushed: make(chan string, 10)}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		router.ServeHTTP(recorder, request)
+	}()
+
+	_, err := io.WriteString(stream, "{\"timestamp\":\"2024-05-01T10:00:00Z\",\"message\":\"first\"}\n{\"timestamp\":\"2024-05-01T10:00:01Z\",")
+	require.NoError(t, err)
+	var acknowledgement IngestResult
+	select {
+	case written := <-recorder.flushed:
+		require.NoError(t, json.Unmarshal([]byte(written), &acknowledgement))
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "the received line was not acknowledged")
+	}
+	assert.False(t, acknowledgement.Done)
+	assert.Equal(t, 1, acknowledgement.LinesRead)
+	assert.Equal(t, 1, acknowledgement.RecordInserted)
+
+	_, err = io.WriteString(stream, "\"message\":\"second\"}\n")
+	require.NoError(t, err)
+	require.NoError(t, stream.Close())
+	<-done
+
+	assert.Equal(t, "application/x-ndjson", recorder.Header().Get("Content-Type"))
+	acknowledgements := strings.Split(strings.TrimSpace(recorder.Body.String()), "\n")
+	var result IngestResult
+	require.NoError(t, json.Unmarshal([]byte(acknowledgements[len(acknowledgements)-1]), &result))
+	assert.True(t, result.Done)
+	assert.True(t, result.Success)
+	assert.Equal(t, 2, result.RecordInserted)
+	assert.Equal(t, []string{"first", "second"}, storedMessages(storage.stored))
+}
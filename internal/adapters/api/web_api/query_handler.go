@@ -0,0 +1,67 @@
+package web_api
+
+import (
+	"LogDb/internal/adapters/query/parser"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// Query godoc
+// @Summary Execute a query
+// @Description Parse a query written in the query language (see docs/query.md) and execute it. The result is written
+// @Description in the format of the query, or else in the format of the Accept header, json by default.
+// @Description The records of ndjson results and of json results without a limit or with a limit above 1000 are
+// @Description streamed in the order they are found, the report comes last.
+// @Description A collected result reaching its limit has a next_cursor, passed as cursor it returns the following records.
+// @Description A query stopped by its timeout or by the client returns the records found so far, marked as truncated.
+// @Description A query consuming more than its budget fails with 422 and the report of what it consumed.
+// @Description An explain statement (or explain in the request) returns the plan of the query instead of its records.
+// @Tags logs
+// @Accept json
+// @Produce json,application/x-ndjson,plain,text/csv,application/yaml,octet-stream
+// @Param body body QueryRequest true "Query"
+// @Success 200 {object} SearchResult
+// @Failure 400 {object} QueryErrorResponse
+// @Failure 410 {object} ErrorResponse
+// @Failure 422 {object} BudgetErrorResponse
+// @Router /api/v1/query [post]
+func (api *WebApi) Query(c *gin.Context) {
+	var request QueryRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query, err := api.queryParser.Parse(request.Query)
+	if err != nil {
+		response := QueryErrorResponse{Error: err.Error()}
+		var syntaxErr *parser.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			response.Line = syntaxErr.Line
+			response.Column = syntaxErr.Column
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	if request.Timeout != "" {
+		if query.Timeout, err = parseTimeout(request.Timeout); err != nil {
+			c.JSON(http.StatusBadRequest, QueryErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+	if request.Budget != nil {
+		query.Budget = toQueryBudget(request.Budget)
+	}
+	if request.Explain {
+		query.Explain = true
+	}
+	if request.Cursor != "" {
+		if query.After, err = decodeCursor(request.Cursor); err != nil {
+			c.JSON(http.StatusBadRequest, QueryErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+	api.search(c, query)
+}
@@ -0,0 +1,67 @@
+package web_api
+
+import (
+	"LogDb/internal/domain"
+	"LogDb/internal/internal_errors"
+	"LogDb/internal/ports"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+// overBudget is the query of a storage consuming one more than every limit of the budget of the query
+func overBudget(query ports.PreparedQuery) (*domain.QueryResult, error) {
+	budget, usage := query.Budget(), query.Usage()
+	usage.DataFiles = budget.DataFiles + 1
+	usage.ScannedBytes = budget.ScannedBytes + 1
+	usage.ScannedRecords = budget.ScannedRecords + 1
+	usage.ResultBytes = budget.ResultBytes + 1
+	query.SetError(fmt.Errorf("%w: over budget", internal_errors.QueryBudgetExceeded))
+	return query.Result()
+}
+
+// TestSearchRecords_BudgetExceeded tests that a query over a limit of its budget is unprocessable and that the
+// response reports what the query consumed
+func TestSearchRecords_BudgetExceeded(t *testing.T) {
+	tests := []struct {
+		name   string
+		budget string
+		usage  func(*QueryUsage) bool
+	}{
+		{"data files", `{"max_data_files":2}`, func(u *QueryUsage) bool { return u.DataFiles == 3 }},
+		{"scanned bytes", `{"max_scanned_bytes":4096}`, func(u *QueryUsage) bool { return u.ScannedBytes == 4097 }},
+		{"scanned records", `{"max_scanned_records":100}`, func(u *QueryUsage) bool { return u.ScannedRecords == 101 }},
+		{"result bytes", `{"max_result_bytes":1024}`, func(u *QueryUsage) bool { return u.ResultBytes == 1025 }},
+	}
+	router := newTestRouter(t, &testStorage{query: overBudget})
+	for _, test := range tests {
+		// the records of a query without a small limit are streamed, the error is written before the first record
+		requests := []struct{ path, body string }{
+			{"/api/v1/search/records", `{"from_time":"2024-05-01T00:00:00Z","to_time":"2024-05-02T00:00:00Z","format":"json","limit":10,"budget":` + test.budget + `}`},
+			{"/api/v1/search/records", `{"from_time":"2024-05-01T00:00:00Z","to_time":"2024-05-02T00:00:00Z","format":"json","budget":` + test.budget + `}`},
+			{"/api/v1/search/records", `{"from_time":"2024-05-01T00:00:00Z","to_time":"2024-05-02T00:00:00Z","format":"ndjson","budget":` + test.budget + `}`},
+			{"/api/v1/query", `{"query":"select * from audit.logs","budget":` + test.budget + `}`},
+		}
+		for _, request := range requests {
+			t.Run(test.name+" "+request.path, func(t *testing.T) {
+				recorder := serve(router, request.path, []byte(request.body))
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code, recorder.Body.String())
+				var response BudgetErrorResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+				assert.Contains(t, response.Error, internal_errors.QueryBudgetExceeded.Error())
+				require.NotNil(t, response.Report)
+				assert.True(t, test.usage(response.Report.Usage), "usage %+v", response.Report.Usage)
+			})
+		}
+	}
+}
+
+// TestSearchRecords_WithinBudget tests that a query within its budget returns its records
+func TestSearchRecords_WithinBudget(t *testing.T) {
+	router := newTestRouter(t, &testStorage{})
+	recorder := serve(router, "/api/v1/search/records", []byte(`{"from_time":"2024-05-01T00:00:00Z","to_time":"2024-05-02T00:00:00Z","format":"json","budget":{"max_data_files":2}}`))
+	assert.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+}
@@ -0,0 +1,58 @@
+package web_api
+
+import (
+	"LogDb/internal/domain"
+	"LogDb/internal/domain/query_types"
+	"LogDb/internal/internal_errors"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// cursorHeader carries the cursor of the next page for the formats without a place for it in the document
+const cursorHeader = "X-Next-Cursor"
+
+// cursorToken is the content of the opaque cursor given to clients
+type cursorToken struct {
+	Order     query_types.Order `json:"o"`
+	Timestamp int64             `json:"t"` // UNIX nanoseconds
+	DataFile  string            `json:"f,omitempty"`
+	Page      uint32            `json:"p,omitempty"`
+	Offset    uint32            `json:"r,omitempty"`
+}
+
+// encodeCursor encodes the cursor as an opaque URL safe token, an empty token when there is no next page
+func encodeCursor(cursor *domain.Cursor) string {
+	if cursor == nil {
+		return ""
+	}
+	data, _ := json.Marshal(cursorToken{
+		Order:     cursor.Order,
+		Timestamp: cursor.Timestamp.UnixNano(),
+		DataFile:  cursor.Position.DataFile,
+		Page:      cursor.Position.Page,
+		Offset:    cursor.Position.Offset,
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor decodes a token created by encodeCursor
+func decodeCursor(token string) (*domain.Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", internal_errors.InvalidCursor, err)
+	}
+	var t cursorToken
+	if err = json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("%w: %s", internal_errors.InvalidCursor, err)
+	}
+	if !t.Order.IsValid() {
+		return nil, fmt.Errorf("%w: %s", internal_errors.UnsupportedOrder, t.Order)
+	}
+	return &domain.Cursor{
+		Order:     t.Order,
+		Timestamp: time.Unix(0, t.Timestamp).UTC(),
+		Position:  domain.RecordPosition{DataFile: t.DataFile, Page: t.Page, Offset: t.Offset},
+	}, nil
+}
@@ -0,0 +1,31 @@
+package web_api
+
+import (
+	"LogDb/internal/domain"
+	"LogDb/internal/domain/query_types"
+	"LogDb/internal/internal_errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// TestCursorToken tests that a cursor survives the round trip through its token and that broken tokens are rejected
+func TestCursorToken(t *testing.T) {
+	cursor := &domain.Cursor{
+		Order:     query_types.Descending,
+		Timestamp: time.Date(2024, 5, 1, 10, 0, 0, 123, time.UTC),
+		Position:  domain.RecordPosition{DataFile: "2024-05-01.4164052702", Page: 600, Offset: 7},
+	}
+
+	token := encodeCursor(cursor)
+	decoded, err := decodeCursor(token)
+	require.NoError(t, err)
+	assert.Equal(t, cursor, decoded)
+	assert.Empty(t, encodeCursor(nil))
+
+	_, err = decodeCursor("not a token")
+	assert.ErrorIs(t, err, internal_errors.InvalidCursor)
+	_, err = decodeCursor(token[:len(token)-4])
+	assert.ErrorIs(t, err, internal_errors.InvalidCursor)
+}
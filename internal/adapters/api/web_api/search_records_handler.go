@@ -1,24 +1,65 @@
 package web_api
 
 import (
+	"LogDb/internal/domain"
 	"LogDb/internal/domain/query_types"
+	"LogDb/internal/internal_errors"
+	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"time"
 )
 
+// acceptedMediaTypes are the media types of the result formats, json comes first as the default of the API
+var acceptedMediaTypes = []string{
+	gin.MIMEJSON,
+	"application/x-ndjson",
+	gin.MIMEPlain,
+	"text/csv",
+	gin.MIMEYAML,
+	"application/yaml",
+	"application/octet-stream",
+}
+
+// acceptedFormats maps the negotiated media type to the result format, a client accepting none of them gets json
+var acceptedFormats = map[string]query_types.Format{
+	"":                         query_types.JSON,
+	gin.MIMEJSON:               query_types.JSON,
+	"application/x-ndjson":     query_types.NDJSON,
+	gin.MIMEPlain:              query_types.Text,
+	"text/csv":                 query_types.CSV,
+	gin.MIMEYAML:               query_types.YAML,
+	"application/yaml":         query_types.YAML,
+	"application/octet-stream": query_types.Binary,
+}
+
 // SearchRecords godoc
 // @Summary Search for log records
-// @Description Search log records based on criteria
+// @Description Search log records based on criteria. The result is written in the format of the request, or else
+// @Description in the format of the Accept header (json, ndjson, text, csv, yaml or binary records), json by default.
+// @Description The records of ndjson results and of json results without a limit or with a limit above 1000 are
+// @Description streamed in the order they are found, the report comes last.
+// @Description A collected result reaching its limit has a next_cursor (also in the X-Next-Cursor header), passed
+// @Description as cursor it returns the records following the last one. A cursor whose data file was merged fails with 410.
+// @Description A query cancelled by the client or stopped by its timeout returns the records found so far, the report
+// @Description is marked as truncated.
+// @Description A query consuming more than its budget, the lower of the budget of the request and of the node, fails
+// @Description with 422 and the report of what it consumed.
+// @Description With explain the query is not executed, the result has the plan of the query instead of the records: the
+// @Description data files selected by the index, the pages visited after time pruning, the compiled filters and the
+// @Description estimated records from the page directories.
 // @Tags logs
 // @Accept json
-// @Produce json
+// @Produce json,application/x-ndjson,plain,text/csv,application/yaml,octet-stream
 // @Param body body SearchRequest true "Search Criteria"
 // @Success 200 {object} SearchResult
 // @Failure 400 {object} ErrorResponse
+// @Failure 410 {object} ErrorResponse
+// @Failure 422 {object} BudgetErrorResponse
 // @Router /api/v1/search/records [post]
 func (api *WebApi) SearchRecords(c *gin.Context) {
 	var request SearchRequest
-	result := NewSearchResult()
 	if err := c.ShouldBindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -30,6 +71,41 @@ func (api *WebApi) SearchRecords(c *gin.Context) {
 	}
 	qb.SetTimeRange(request.FromTime, request.ToTime)
 	qb.Limit(request.Limit)
+	if request.Order != "" {
+		qb.OrderBy(query_types.Order(request.Order))
+	}
+	if request.Format != "" {
+		qb.SetFormat(query_types.Format(request.Format))
+	}
+	if request.AggregatedBy != "" {
+		qb.AggregateBy(query_types.Dimension(request.AggregatedBy))
+		qb.GroupBy(request.GroupBy...)
+	}
+	if request.Concurrency > 0 {
+		qb.Concurrency(request.Concurrency)
+	}
+	if request.Timeout != "" {
+		timeout, err := parseTimeout(request.Timeout)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		qb.Timeout(timeout)
+	}
+	if request.Budget != nil {
+		qb.Budget(toQueryBudget(request.Budget))
+	}
+	if request.Explain {
+		qb.Explain()
+	}
+	if request.Cursor != "" {
+		cursor, err := decodeCursor(request.Cursor)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		qb.After(cursor)
+	}
 	query, err := qb.Build()
 
 	if err != nil {
@@ -37,21 +113,152 @@ func (api *WebApi) SearchRecords(c *gin.Context) {
 		return
 	}
 
+	api.search(c, query)
+}
+
+// search prepares and executes the query and writes the result in the format of the query, or else in the format
+// accepted by the client
+func (api *WebApi) search(c *gin.Context, query *domain.Query) {
+	format := query.Format
+	if format == query_types.DefaultFormat {
+		format = acceptedFormats[c.NegotiateFormat(acceptedMediaTypes...)]
+	}
+	presenter, err := api.presenters.Presenter(format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	preparedQuery, err := api.queryProcessor.PrepareQuery(query)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if sink := api.newRecordStream(c, query, format); sink != nil {
+		// the sink writes the records, the report and the error of the query
+		preparedQuery.Stream(sink)
+		_, _ = api.storage.Query(c.Request.Context(), preparedQuery)
+		return
+	}
+	// a client going away cancels the query
+	queryResult, err := api.storage.Query(c.Request.Context(), preparedQuery)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// the records following the cursor cannot be found again and a query over its budget returns no records
+	if err = preparedQuery.Error(); errors.Is(err, internal_errors.CursorDataFileRewritten) || errors.Is(err, internal_errors.QueryBudgetExceeded) {
+		writeQueryError(c, queryResult.Report, err)
+		return
+	}
+	if cursor := encodeCursor(queryResult.NextCursor()); cursor != "" {
+		c.Header(cursorHeader, cursor)
+	}
 
-	queryResult, err := api.storage.Query(preparedQuery)
+	// the plan is presented in the json document of the presenter
+	if format == query_types.JSON && queryResult.Plan == nil {
+		c.JSON(http.StatusOK, api.toSearchResult(queryResult))
+		return
+	}
+	output, err := presenter.Present(queryResult)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	c.Data(http.StatusOK, presenter.ContentType(), []byte(output))
+}
+
+// toSearchResult converts the result of the query to the external representation
+func (api *WebApi) toSearchResult(queryResult *domain.QueryResult) *SearchResult {
+	result := NewSearchResult()
 	result.Records = api.recordTransformer.ToExternalBatch(queryResult.Records)
-	result.Report.TotalRecords = queryResult.Report.Hits
-	result.Report.ScannedRecords = queryResult.Report.ScannedItems
-	result.Report.TimeTaken = queryResult.Report.ElapsedTime.Seconds()
-	c.JSON(http.StatusOK, result)
-	return
+	if queryResult.Aggregation != nil {
+		result.Aggregation = toExternalAggregation(queryResult.Aggregation)
+	}
+	result.Report = toSearchReport(queryResult.Report)
+	result.NextCursor = encodeCursor(queryResult.NextCursor())
+	return result
+}
+
+// toSearchReport converts the report of the query to the external representation
+func toSearchReport(report *domain.QueryReport) *SearchReport {
+	return &SearchReport{
+		TotalRecords:   report.Hits,
+		ScannedRecords: report.ScannedItems,
+		TimeTaken:      report.ElapsedTime.Seconds(),
+		Truncated:      report.Truncated,
+		Usage: &QueryUsage{
+			ScannedBytes:   report.Usage.ScannedBytes,
+			ScannedRecords: report.Usage.ScannedRecords,
+			ResultBytes:    report.Usage.ResultBytes,
+			DataFiles:      report.Usage.DataFiles,
+		},
+	}
+}
+
+// toQueryBudget converts the budget of a request to the budget of the query
+func toQueryBudget(budget *QueryBudget) domain.QueryBudget {
+	return domain.QueryBudget{
+		ScannedBytes:   budget.MaxScannedBytes,
+		ScannedRecords: budget.MaxScannedRecords,
+		ResultBytes:    budget.MaxResultBytes,
+		DataFiles:      budget.MaxDataFiles,
+	}
+}
+
+// writeQueryError writes the error of a query that failed before its records were written. A query over its budget
+// fails with the report of what it consumed, the records following a cursor of a rewritten data file are gone.
+func writeQueryError(c *gin.Context, report *domain.QueryReport, err error) {
+	switch {
+	case errors.Is(err, internal_errors.QueryBudgetExceeded):
+		c.JSON(http.StatusUnprocessableEntity, BudgetErrorResponse{Error: err.Error(), Report: toSearchReport(report)})
+	case errors.Is(err, internal_errors.CursorDataFileRewritten):
+		c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
+
+// parseTimeout parses the timeout of a request, e.g. 500ms or 5s
+func parseTimeout(value string) (time.Duration, error) {
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout <= 0 {
+		return 0, fmt.Errorf("timeout must be a positive duration, e.g. 5s: %s", value)
+	}
+	return timeout, nil
+}
+
+// toExternalAggregation converts the aggregation to the external representation, the label values are keyed by label
+// and the metric values by metric
+func toExternalAggregation(aggregation *domain.Aggregation) *AggregationResult {
+	result := &AggregationResult{
+		Dimension: string(aggregation.Dimension),
+		GroupBy:   aggregation.GroupBy,
+		Total:     aggregation.Total,
+		Buckets:   make([]*AggregationBucket, 0),
+	}
+	for _, metric := range aggregation.Metrics {
+		result.Metrics = append(result.Metrics, metric.String())
+	}
+	for _, bucket := range aggregation.Buckets() {
+		external := &AggregationBucket{Start: bucket.Start, Count: bucket.Count}
+		if len(aggregation.GroupBy) > 0 {
+			external.Labels = make(map[string]string, len(aggregation.GroupBy))
+			for i, field := range aggregation.GroupBy {
+				external.Labels[field] = bucket.Labels[i]
+			}
+		}
+		if len(bucket.Metrics) > 0 {
+			external.Metrics = make(map[string]*float64, len(bucket.Metrics))
+			for _, metric := range bucket.Metrics {
+				if value, ok := metric.Value(); ok {
+					external.Metrics[metric.Metric.String()] = &value
+				} else {
+					external.Metrics[metric.Metric.String()] = nil
+				}
+			}
+		}
+		result.Buckets = append(result.Buckets, external)
+	}
+	return result
 }
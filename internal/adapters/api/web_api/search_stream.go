@@ -0,0 +1,125 @@
+package web_api
+
+import (
+	"LogDb/internal/domain"
+	"LogDb/internal/domain/query_types"
+	"LogDb/internal/ports"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// streamRecordsAbove is the limit above which the records of a json result are streamed, the records of a smaller
+// result are collected and sorted by timestamp
+const streamRecordsAbove = 1000
+
+// newRecordStream returns the sink streaming the records of the query in the format, nil when the result is
+// collected: aggregations, explained queries, the formats presenting a whole result and json results with a small limit
+func (api *WebApi) newRecordStream(c *gin.Context, query *domain.Query, format query_types.Format) ports.RecordSink {
+	if query.AggregatedBy != nil || query.Explain {
+		return nil
+	}
+	switch {
+	case format == query_types.NDJSON:
+		return &ndjsonRecordStream{c: c, transformer: api.recordTransformer}
+	case format == query_types.JSON && (query.Limit == nil || *query.Limit > streamRecordsAbove):
+		return &jsonRecordStream{c: c, transformer: api.recordTransformer}
+	}
+	return nil
+}
+
+var _ ports.RecordSink = &jsonRecordStream{}
+
+// jsonRecordStream writes a SearchResult record by record, the report and the error of the query close the document
+type jsonRecordStream struct {
+	c           *gin.Context
+	transformer *RecordTransformer
+	started     bool
+}
+
+// Write writes the record to the records of the document, the first record starts the response
+func (s *jsonRecordStream) Write(record *domain.LogRecord) error {
+	data, err := json.Marshal(s.transformer.ToExternal(record))
+	if err != nil {
+		return err
+	}
+	separator := ","
+	if !s.started {
+		s.start()
+		separator = ""
+	}
+	if _, err = s.c.Writer.WriteString(separator); err != nil {
+		return err
+	}
+	_, err = s.c.Writer.Write(data)
+	return err
+}
+
+// Close ends the records and writes the report, a query failing before the first record writes the error only
+func (s *jsonRecordStream) Close(report *domain.QueryReport, err error) {
+	if !s.started {
+		if err != nil {
+			writeQueryError(s.c, report, err)
+			return
+		}
+		s.start()
+	}
+	trailer := SearchEvent{Report: toSearchReport(report)}
+	if err != nil {
+		trailer.Error = err.Error()
+	}
+	data, err := json.Marshal(trailer)
+	if err != nil {
+		return
+	}
+	// the fields of the trailer follow the records in the same object
+	_, _ = s.c.Writer.WriteString("],")
+	_, _ = s.c.Writer.Write(data[1:])
+}
+
+// start writes the headers and opens the document
+func (s *jsonRecordStream) start() {
+	s.started = true
+	s.c.Header("Content-Type", "application/json; charset=utf-8")
+	s.c.Status(http.StatusOK)
+	_, _ = s.c.Writer.WriteString(`{"records":[`)
+}
+
+var _ ports.RecordSink = &ndjsonRecordStream{}
+
+// ndjsonRecordStream writes a SearchEvent per line, a line per record followed by a line with the report
+type ndjsonRecordStream struct {
+	c           *gin.Context
+	transformer *RecordTransformer
+	started     bool
+}
+
+// Write writes the record line, the first record starts the response
+func (s *ndjsonRecordStream) Write(record *domain.LogRecord) error {
+	s.start()
+	return json.NewEncoder(s.c.Writer).Encode(SearchEvent{Record: s.transformer.ToExternal(record)})
+}
+
+// Close writes the report line, a query failing before the first record writes the error only
+func (s *ndjsonRecordStream) Close(report *domain.QueryReport, err error) {
+	if !s.started && err != nil {
+		writeQueryError(s.c, report, err)
+		return
+	}
+	s.start()
+	event := SearchEvent{Report: toSearchReport(report)}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	_ = json.NewEncoder(s.c.Writer).Encode(event)
+}
+
+// start writes the headers once
+func (s *ndjsonRecordStream) start() {
+	if s.started {
+		return
+	}
+	s.started = true
+	s.c.Header("Content-Type", "application/x-ndjson")
+	s.c.Status(http.StatusOK)
+}
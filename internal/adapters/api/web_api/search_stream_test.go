@@ -0,0 +1,109 @@
+package web_api
+
+import (
+	"LogDb/internal/adapters/schema"
+	"LogDb/internal/domain"
+	"LogDb/internal/internal_errors"
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newStreamContext(t *testing.T) (*gin.Context, *httptest.ResponseRecorder, *RecordTransformer) {
+	schemas, err := schema.NewFileStore(filepath.Join(t.TempDir(), "schemas.json"))
+	require.NoError(t, err)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	return c, recorder, NewRecordTransformer(schemas)
+}
+
+func newStreamRecord(message string) *domain.LogRecord {
+	record := domain.NewEmptyLogRecord()
+	record.Timestamp = time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	record.Message = []byte(message)
+	return record
+}
+
+// TestJSONRecordStream tests that the streamed document is a SearchResult closed by the report and the error
+func TestJSONRecordStream(t *testing.T) {
+	c, recorder, transformer := newStreamContext(t)
+	stream := &jsonRecordStream{c: c, transformer: transformer}
+
+	require.NoError(t, stream.Write(newStreamRecord("first")))
+	require.NoError(t, stream.Write(newStreamRecord("second")))
+	stream.Close(&domain.QueryReport{Hits: 2, ScannedItems: 5}, errors.New("stopped"))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var result struct {
+		SearchResult
+		Error string `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &result))
+	require.Len(t, result.Records, 2)
+	assert.Equal(t, "second", result.Records[1].Message)
+	assert.Equal(t, 2, result.Report.TotalRecords)
+	assert.Equal(t, 5, result.Report.ScannedRecords)
+	assert.Equal(t, "stopped", result.Error)
+}
+
+// TestJSONRecordStream_Empty tests that a result without records is an empty document and an early error a bad request
+func TestJSONRecordStream_Empty(t *testing.T) {
+	c, recorder, transformer := newStreamContext(t)
+	(&jsonRecordStream{c: c, transformer: transformer}).Close(&domain.QueryReport{}, nil)
+
+	var result SearchResult
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &result))
+	assert.Empty(t, result.Records)
+	assert.NotNil(t, result.Report)
+
+	c, recorder, transformer = newStreamContext(t)
+	(&jsonRecordStream{c: c, transformer: transformer}).Close(&domain.QueryReport{}, errors.New("failed"))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+// TestNDJSONRecordStream tests that every record is a line and the report is the last line
+func TestNDJSONRecordStream(t *testing.T) {
+	c, recorder, transformer := newStreamContext(t)
+	stream := &ndjsonRecordStream{c: c, transformer: transformer}
+
+	require.NoError(t, stream.Write(newStreamRecord("first")))
+	stream.Close(&domain.QueryReport{Hits: 1, ScannedItems: 1}, nil)
+
+	assert.Equal(t, "application/x-ndjson", recorder.Header().Get("Content-Type"))
+	var events []SearchEvent
+	scanner := bufio.NewScanner(recorder.Body)
+	for scanner.Scan() {
+		var event SearchEvent
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		events = append(events, event)
+	}
+	require.Len(t, events, 2)
+	assert.Equal(t, "first", events[0].Record.Message)
+	assert.Nil(t, events[0].Report)
+	assert.Equal(t, 1, events[1].Report.TotalRecords)
+	assert.Empty(t, events[1].Error)
+}
+
+// TestNDJSONRecordStream_BudgetExceeded tests that a query over its budget before the first record fails with the
+// report of what it consumed
+func TestNDJSONRecordStream_BudgetExceeded(t *testing.T) {
+	c, recorder, transformer := newStreamContext(t)
+	report := &domain.QueryReport{ScannedItems: 3, Usage: domain.QueryUsage{ScannedBytes: 2048, ScannedRecords: 3, DataFiles: 1}}
+	(&ndjsonRecordStream{c: c, transformer: transformer}).Close(report, fmt.Errorf("%w: 2048 bytes scanned, the budget is 1024", internal_errors.QueryBudgetExceeded))
+
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	var response BudgetErrorResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Contains(t, response.Error, "QueryBudgetExceeded")
+	require.NotNil(t, response.Report)
+	assert.Equal(t, &QueryUsage{ScannedBytes: 2048, ScannedRecords: 3, DataFiles: 1}, response.Report.Usage)
+}
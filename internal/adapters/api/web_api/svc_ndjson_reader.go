@@ -0,0 +1,79 @@
+package web_api
+
+import (
+	"LogDb/internal/internal_errors"
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+)
+
+// maxNDJSONLineSize is the largest line accepted by the NDJSON reader
+const maxNDJSONLineSize = 1 << 20
+
+// NDJSONReader reads the lines of a newline-delimited JSON stream one at a time
+type NDJSONReader struct {
+	reader      *bufio.Reader
+	line        []byte
+	number      int
+	maxLineSize int
+}
+
+// Next reads the next non-empty line, it returns io.EOF at the end of the stream.
+// A line longer than the limit is skipped and reported with NDJSONLineTooLong, the stream can still be read.
+func (r *NDJSONReader) Next() ([]byte, error) {
+	for {
+		line, err := r.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			return line, nil
+		}
+	}
+}
+
+// Line returns the number of the last line read, starting with 1
+func (r *NDJSONReader) Line() int {
+	return r.number
+}
+
+// HasLine reports whether a complete line is buffered, otherwise reading the next line waits for the stream
+func (r *NDJSONReader) HasLine() bool {
+	buffered, _ := r.reader.Peek(r.reader.Buffered())
+	return bytes.IndexByte(buffered, '\n') >= 0
+}
+
+// readLine reads a single line without its delimiter
+func (r *NDJSONReader) readLine() ([]byte, error) {
+	r.line = r.line[:0]
+	r.number++
+	tooLong := false
+	for {
+		chunk, err := r.reader.ReadSlice('\n')
+		if !tooLong && len(r.line)+len(chunk) > r.maxLineSize {
+			tooLong = true
+			r.line = r.line[:0]
+		}
+		if !tooLong {
+			r.line = append(r.line, chunk...)
+		}
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		if err != nil && !(errors.Is(err, io.EOF) && (len(r.line) > 0 || tooLong)) {
+			// the stream ended without another line
+			r.number--
+			return nil, err
+		}
+		if tooLong {
+			return nil, internal_errors.NDJSONLineTooLong
+		}
+		return r.line, nil
+	}
+}
+
+// NewNDJSONReader creates a new NDJSONReader reading lines up to maxNDJSONLineSize bytes
+func NewNDJSONReader(reader io.Reader) *NDJSONReader {
+	return &NDJSONReader{reader: bufio.NewReaderSize(reader, 64*1024), maxLineSize: maxNDJSONLineSize}
+}
@@ -0,0 +1,34 @@
+package web_api
+
+import (
+	"LogDb/internal/internal_errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"strings"
+	"testing"
+)
+
+// TestNDJSONReader_Next tests that empty lines are skipped and long lines do not stop the stream
+func TestNDJSONReader_Next(t *testing.T) {
+	reader := NewNDJSONReader(strings.NewReader("{\"a\":1}\n\n  \n" + strings.Repeat("x", 100) + "\r\n{\"b\":2}"))
+	reader.maxLineSize = 64
+
+	line, err := reader.Next()
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":1}`, string(line))
+	assert.Equal(t, 1, reader.Line())
+
+	_, err = reader.Next()
+	assert.ErrorIs(t, err, internal_errors.NDJSONLineTooLong)
+	assert.Equal(t, 4, reader.Line())
+
+	line, err = reader.Next()
+	require.NoError(t, err)
+	assert.Equal(t, `{"b":2}`, string(line))
+	assert.Equal(t, 5, reader.Line())
+
+	_, err = reader.Next()
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Equal(t, 5, reader.Line())
+}
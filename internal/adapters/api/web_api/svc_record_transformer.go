@@ -1,40 +1,81 @@
 package web_api
 
 import (
+	"LogDb/internal/adapters/schema"
 	"LogDb/internal/domain"
-	"fmt"
+	"LogDb/internal/internal_errors"
+	"LogDb/internal/ports"
+	"sort"
+	"time"
 )
 
 type RecordTransformer struct {
+	schemas ports.SchemaStore
 }
 
-func (rt *RecordTransformer) ToInternal(record *Record) *domain.LogRecord {
-	var labels []domain.Label
-	for _, value := range record.StringLabels {
+// Validate checks that the external record can be stored
+func (rt *RecordTransformer) Validate(record *Record) error {
+	if record == nil {
+		return internal_errors.RecordMissing
+	}
+	if record.Timestamp.IsZero() && record.TimestampNano == 0 {
+		return internal_errors.RecordTimestampMissing
+	}
+	if record.Message == "" {
+		return internal_errors.RecordMessageMissing
+	}
+	return nil
+}
+
+// ToInternal converts an external record to an internal one, labels are ordered by name and the schema
+// with the label names is resolved or created
+func (rt *RecordTransformer) ToInternal(record *Record) (*domain.LogRecord, error) {
+	if err := rt.Validate(record); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(record.StringLabels))
+	for name := range record.StringLabels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	labels := make([]domain.Label, 0, len(names))
+	fields := make([]ports.SchemaField, 0, len(names))
+	for _, name := range names {
+		value := record.StringLabels[name]
 		labels = append(labels, domain.Label{
 			Type:  domain.StringLabelType,
 			Size:  uint64(len(value)),
 			Value: []byte(value),
 		})
+		fields = append(fields, schema.NewField(name, domain.StringLabelType))
+	}
+	s, err := rt.schemas.ResolveSchema(fields)
+	if err != nil {
+		return nil, err
+	}
+	timestamp := record.Timestamp
+	if record.TimestampNano != 0 {
+		timestamp = time.Unix(0, record.TimestampNano)
 	}
 	return &domain.LogRecord{
-		Timestamp:     record.Timestamp,
-		SchemaVersion: 0,
+		Timestamp:     timestamp.UTC(),
+		SchemaVersion: s.ID(),
 		Labels:        labels,
 		Message:       []byte(record.Message),
-	}
+	}, nil
 }
 
 func (rt *RecordTransformer) ToExternal(record *domain.LogRecord) *Record {
 	labels := make(map[string]string)
-	for i, label := range record.Labels {
-		// TODO resolve from schema
-		labels[fmt.Sprintf("label_%d", i)] = string(label.Value)
+	for i, name := range schema.LabelNames(rt.schemas, record) {
+		labels[name] = string(record.Labels[i].Value)
 	}
 	return &Record{
-		Timestamp:    record.Timestamp,
-		Message:      string(record.Message),
-		StringLabels: labels,
+		Timestamp:     record.Timestamp.UTC(),
+		TimestampNano: record.Timestamp.UnixNano(),
+		Message:       string(record.Message),
+		StringLabels:  labels,
 	}
 }
 
@@ -48,12 +89,19 @@ func (rt *RecordTransformer) ToExternalBatch(records []*domain.LogRecord) []*Rec
 }
 
 // ToInternalBatch converts a slice of external records to a slice of internal records
-func (rt *RecordTransformer) ToInternalBatch(records []*Record) []*domain.LogRecord {
+func (rt *RecordTransformer) ToInternalBatch(records []*Record) ([]*domain.LogRecord, error) {
 	var internalRecords []*domain.LogRecord
 	for _, record := range records {
-		internalRecords = append(internalRecords, rt.ToInternal(record))
+		internalRecord, err := rt.ToInternal(record)
+		if err != nil {
+			return nil, err
+		}
+		internalRecords = append(internalRecords, internalRecord)
 	}
-	return internalRecords
+	return internalRecords, nil
 }
 
-var DefaultRecordTransformer = &RecordTransformer{}
+// NewRecordTransformer creates a new RecordTransformer resolving label names with the schema store
+func NewRecordTransformer(schemas ports.SchemaStore) *RecordTransformer {
+	return &RecordTransformer{schemas: schemas}
+}
@@ -0,0 +1,96 @@
+package web_api
+
+import (
+	"LogDb/internal/adapters/compression"
+	"LogDb/internal/domain/compression_types"
+	"LogDb/internal/internal_errors"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// contentEncodings maps the supported Content-Encoding values to the compression types
+var contentEncodings = map[string]compression_types.CompressionType{
+	"":         compression_types.None,
+	"identity": compression_types.None,
+	"gzip":     compression_types.Gzip,
+	"zstd":     compression_types.Zstd,
+}
+
+// maxDecompressedBodyBytes is the size limit of a decompressed JSON request body, the document is read at once
+const maxDecompressedBodyBytes = 64 << 20
+
+// maxDecompressedStreamBytes is the size limit of a decompressed NDJSON request body, the lines are read one by one
+const maxDecompressedStreamBytes = 4 << 30
+
+// requestBody returns the request body, gzip and zstd bodies are decompressed while they are read and fail with
+// RequestBodyTooLarge after limit bytes. The returned body must be closed to stop the decompression when it is not
+// read to the end.
+func requestBody(c *gin.Context, limit int64) (io.ReadCloser, error) {
+	encoding := strings.ToLower(strings.TrimSpace(c.GetHeader("Content-Encoding")))
+	compressionType, ok := contentEncodings[encoding]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", internal_errors.UnsupportedContentEncoding, encoding)
+	}
+	if compressionType == compression_types.None {
+		return c.Request.Body, nil
+	}
+	source := c.Request.Body
+	reader, writer := io.Pipe()
+	go func() {
+		_, err := compression.Factory(compressionType).DecompressStream(source, writer)
+		if err != nil {
+			err = fmt.Errorf("failed to decompress %s request body: %w", encoding, err)
+		}
+		writer.CloseWithError(err)
+	}()
+	return &limitedBody{ReadCloser: reader, limit: limit, remaining: limit}, nil
+}
+
+// limitedBody is a decompressed request body failing with RequestBodyTooLarge once it read more than its limit
+type limitedBody struct {
+	io.ReadCloser
+	limit     int64
+	remaining int64
+}
+
+// Read reads at most one byte past the limit to tell a body of the size of the limit from a larger one
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, fmt.Errorf("%w: more than %d bytes decompressed", internal_errors.RequestBodyTooLarge, b.limit)
+	}
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	if b.remaining < 0 {
+		return n - 1, fmt.Errorf("%w: more than %d bytes decompressed", internal_errors.RequestBodyTooLarge, b.limit)
+	}
+	return n, err
+}
+
+// bindJSON binds the JSON request body, gzip and zstd bodies are decompressed first
+func bindJSON(c *gin.Context, request any) error {
+	body, err := requestBody(c, maxDecompressedBodyBytes)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	c.Request.Body = body
+	return c.ShouldBindJSON(request)
+}
+
+// bindErrorStatus returns the HTTP status for an error of bindJSON
+func bindErrorStatus(err error) int {
+	if errors.Is(err, internal_errors.UnsupportedContentEncoding) {
+		return http.StatusUnsupportedMediaType
+	}
+	if errors.Is(err, internal_errors.RequestBodyTooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
@@ -0,0 +1,52 @@
+package web_api
+
+import (
+	"LogDb/internal/domain/compression_types"
+	"LogDb/internal/internal_errors"
+	"bytes"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newBodyContext(body []byte, encoding string) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	c.Request.Header.Set("Content-Encoding", encoding)
+	return c
+}
+
+// TestRequestBody_Limit tests that a decompressed body of the size of the limit is read and a larger one fails
+func TestRequestBody_Limit(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 100)
+	for _, encoding := range []string{"gzip", "zstd"} {
+		compressionType := contentEncodings[encoding]
+		t.Run(encoding, func(t *testing.T) {
+			body, err := requestBody(newBodyContext(compress(t, compressionType, data), encoding), int64(len(data)))
+			require.NoError(t, err)
+			read, err := io.ReadAll(body)
+			require.NoError(t, err)
+			assert.Equal(t, data, read)
+			require.NoError(t, body.Close())
+
+			body, err = requestBody(newBodyContext(compress(t, compressionType, data), encoding), int64(len(data)-1))
+			require.NoError(t, err)
+			read, err = io.ReadAll(body)
+			assert.ErrorIs(t, err, internal_errors.RequestBodyTooLarge)
+			assert.Equal(t, data[:len(data)-1], read)
+			require.NoError(t, body.Close())
+		})
+	}
+
+	// a body sent as is is not limited by the decompression
+	body, err := requestBody(newBodyContext(data, ""), 1)
+	require.NoError(t, err)
+	read, err := io.ReadAll(body)
+	require.NoError(t, err)
+	assert.Equal(t, data, read)
+	assert.Equal(t, compression_types.None, contentEncodings[""])
+}
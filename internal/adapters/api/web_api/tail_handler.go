@@ -0,0 +1,221 @@
+package web_api
+
+import (
+	"LogDb/internal/adapters/query"
+	"LogDb/internal/domain"
+	"LogDb/internal/domain/query_types"
+	"LogDb/internal/internal_errors"
+	"LogDb/internal/ports"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultTailBuffer is the number of records buffered for a subscriber that does not read fast enough
+const defaultTailBuffer = 1000
+
+// maxTailBuffer is the largest buffer a subscriber can ask for
+const maxTailBuffer = 10_000
+
+// tailKeepAlive is the interval of the comments keeping an idle event stream open through proxies
+const tailKeepAlive = 15 * time.Second
+
+// slowConsumerPolicies maps the on_slow parameter to the slow consumer policy
+var slowConsumerPolicies = map[string]domain.SlowConsumerPolicy{
+	"":                                     domain.DropRecords,
+	domain.DropRecords.String():            domain.DropRecords,
+	domain.DisconnectSlowConsumer.String(): domain.DisconnectSlowConsumer,
+}
+
+// Tail godoc
+// @Summary Stream newly stored log records
+// @Description Stream the records stored after the request as Server-Sent Events, or as WebSocket messages when the
+// @Description request upgrades the connection. Every event is a TailEvent, SSE events are named record, dropped and error.
+// @Description The records are filtered by the message, by label conditions given as label.{name}={value} parameters
+// @Description and by the conditions of a query, the time range and the limit of the query are ignored.
+// @Description A subscriber that does not read fast enough either loses records (drop, the dropped event tells how many)
+// @Description or is disconnected (disconnect).
+// @Tags logs
+// @Produce text/event-stream
+// @Param message_contains query string false "Substring of the message"
+// @Param query query string false "Query in the query language, only the conditions are used"
+// @Param buffer query int false "Records buffered for a slow subscriber, default 1000, max 10000"
+// @Param on_slow query string false "Slow consumer policy: drop (default) or disconnect"
+// @Success 200 {object} TailEvent
+// @Failure 400 {object} ErrorResponse
+// @Router /api/v1/tail [get]
+func (api *WebApi) Tail(c *gin.Context) {
+	subscription, err := api.subscribe(c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer subscription.Close()
+
+	if isWebSocketUpgrade(c.Request) {
+		api.tailWebSocket(c, subscription)
+		return
+	}
+	api.tailEventStream(c, subscription)
+}
+
+// subscribe builds the filter and the subscription from the parameters of the request
+func (api *WebApi) subscribe(params url.Values) (ports.RecordsSubscription, error) {
+	bufferSize := defaultTailBuffer
+	if value := params.Get("buffer"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil || size < 1 || size > maxTailBuffer {
+			return nil, fmt.Errorf("buffer must be between 1 and %d", maxTailBuffer)
+		}
+		bufferSize = size
+	}
+	policy, ok := slowConsumerPolicies[params.Get("on_slow")]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", internal_errors.SlowConsumerPolicyNotSupported, params.Get("on_slow"))
+	}
+
+	q, err := api.tailQuery(params)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := api.queryProcessor.PrepareFilter(q)
+	if err != nil {
+		return nil, err
+	}
+	return api.records.Subscribe(filter, bufferSize, policy), nil
+}
+
+// tailQuery collects the conditions of the query text, the message and the label parameters
+func (api *WebApi) tailQuery(params url.Values) (*domain.Query, error) {
+	var q *domain.Query
+	var err error
+	if text := params.Get("query"); text != "" {
+		q, err = api.queryParser.Parse(text)
+	} else {
+		q, err = api.queryBuilder.NewQueryBuilder().Build()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if contains := params.Get("message_contains"); contains != "" {
+		q.Conditions = append(q.Conditions, query_types.Condition{Field: "message", Operator: query_types.Contains, Value: contains})
+	}
+	for name, values := range params {
+		if !strings.HasPrefix(name, query.LabelFieldPrefix) {
+			continue
+		}
+		for _, value := range values {
+			q.Conditions = append(q.Conditions, query_types.Condition{Field: name, Operator: query_types.Equal, Value: value})
+		}
+	}
+	return q, nil
+}
+
+// tailEventStream writes the records as Server-Sent Events until the client goes away or the subscription ends
+func (api *WebApi) tailEventStream(c *gin.Context, subscription ports.RecordsSubscription) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(tailKeepAlive)
+	defer keepAlive.Stop()
+	var dropped uint64
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-subscription.Done():
+			if err := subscription.Err(); err != nil {
+				c.SSEvent("error", TailEvent{Error: err.Error()})
+				c.Writer.Flush()
+			}
+			return
+		case record := <-subscription.Records():
+			if count := subscription.Dropped(); count != dropped {
+				dropped = count
+				c.SSEvent("dropped", TailEvent{Dropped: dropped})
+			}
+			c.SSEvent("record", TailEvent{Record: api.recordTransformer.ToExternal(record)})
+			c.Writer.Flush()
+		case <-keepAlive.C:
+			if _, err := io.WriteString(c.Writer, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+// tailWebSocket sends the records as JSON messages until the client closes the connection or the subscription ends
+func (api *WebApi) tailWebSocket(c *gin.Context, subscription ports.RecordsSubscription) {
+	server := websocket.Server{
+		Handshake: sameOrigin,
+		Handler: func(conn *websocket.Conn) {
+			defer conn.Close()
+			// the client does not send messages, reading detects the closed connection
+			closed := make(chan struct{})
+			go func() {
+				_, _ = io.Copy(io.Discard, conn)
+				close(closed)
+			}()
+
+			var dropped uint64
+			for {
+				var event TailEvent
+				select {
+				case <-closed:
+					return
+				case <-subscription.Done():
+					if err := subscription.Err(); err != nil {
+						_ = websocket.JSON.Send(conn, TailEvent{Error: err.Error()})
+					}
+					return
+				case record := <-subscription.Records():
+					if count := subscription.Dropped(); count != dropped {
+						dropped = count
+						event.Dropped = dropped
+					}
+					event.Record = api.recordTransformer.ToExternal(record)
+				}
+				if err := websocket.JSON.Send(conn, event); err != nil {
+					return
+				}
+			}
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+// isWebSocketUpgrade reports whether the request asks to upgrade the connection to WebSocket
+func isWebSocketUpgrade(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket")
+}
+
+// sameOrigin accepts clients without an origin and browsers on the host of the API,
+// other pages must not read the records through the browser of a user
+func sameOrigin(config *websocket.Config, r *http.Request) error {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return nil
+	}
+	originURL, err := url.Parse(origin)
+	if err != nil {
+		return err
+	}
+	if originURL.Host != r.Host {
+		return errors.New("cross origin websocket request")
+	}
+	config.Origin = originURL
+	return nil
+}
@@ -0,0 +1,149 @@
+package bus
+
+import (
+	"LogDb/internal/domain"
+	"LogDb/internal/internal_errors"
+	"LogDb/internal/ports"
+	"sync"
+	"sync/atomic"
+)
+
+var _ ports.RecordsPropagator = &RecordsManager{}
+var _ ports.RecordsSubscriber = &RecordsManager{}
+
+// RecordsManager implements both RecordsPropagator and RecordsSubscriber interfaces.
+// Every subscription has its own buffer, a slow subscriber never blocks the propagation of records.
+type RecordsManager struct {
+	mu            sync.RWMutex
+	subscriptions map[*RecordsSubscription]struct{}
+	closed        bool
+}
+
+// RecordsStored passes the records to every subscription with a matching filter.
+func (m *RecordsManager) RecordsStored(records []*domain.LogRecord) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for subscription := range m.subscriptions {
+		subscription.publish(records)
+	}
+}
+
+// Subscribe creates a subscription receiving the stored records matching the filter.
+func (m *RecordsManager) Subscribe(filter ports.Filter, bufferSize int, policy domain.SlowConsumerPolicy) ports.RecordsSubscription {
+	subscription := &RecordsSubscription{
+		manager: m,
+		filter:  filter,
+		policy:  policy,
+		records: make(chan *domain.LogRecord, max(bufferSize, 1)),
+		done:    make(chan struct{}),
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		subscription.end(internal_errors.TailClosed)
+		return subscription
+	}
+	m.subscriptions[subscription] = struct{}{}
+	return subscription
+}
+
+// unsubscribe removes the subscription, no records are passed to it afterward.
+func (m *RecordsManager) unsubscribe(subscription *RecordsSubscription) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.subscriptions, subscription)
+}
+
+// Close ends every subscription, new subscriptions end right away.
+func (m *RecordsManager) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = true
+	for subscription := range m.subscriptions {
+		subscription.end(internal_errors.TailClosed)
+		delete(m.subscriptions, subscription)
+	}
+}
+
+// NewRecordsManager creates a new RecordsManager.
+func NewRecordsManager() *RecordsManager {
+	return &RecordsManager{
+		subscriptions: make(map[*RecordsSubscription]struct{}),
+	}
+}
+
+var _ ports.RecordsSubscription = &RecordsSubscription{}
+
+// RecordsSubscription buffers the records matching its filter until the subscriber reads them.
+type RecordsSubscription struct {
+	manager *RecordsManager
+	filter  ports.Filter
+	policy  domain.SlowConsumerPolicy
+	records chan *domain.LogRecord
+	dropped atomic.Uint64
+	done    chan struct{}
+	once    sync.Once
+	err     error
+}
+
+// publish buffers the matching records, the records channel is never closed so a running publish is safe.
+func (s *RecordsSubscription) publish(records []*domain.LogRecord) {
+	for _, record := range records {
+		select {
+		case <-s.done:
+			return
+		default:
+		}
+		if s.filter != nil && !s.filter.IsMatch(record) {
+			continue
+		}
+		select {
+		case s.records <- record:
+		default:
+			if s.policy == domain.DisconnectSlowConsumer {
+				s.end(internal_errors.TailSlowConsumer)
+				return
+			}
+			s.dropped.Add(1)
+		}
+	}
+}
+
+// end closes the done channel once with the reason the subscription ended
+func (s *RecordsSubscription) end(err error) {
+	s.once.Do(func() {
+		s.err = err
+		close(s.done)
+	})
+}
+
+// Records returns the channel of the matching records.
+func (s *RecordsSubscription) Records() <-chan *domain.LogRecord {
+	return s.records
+}
+
+// Done returns a channel closed when the subscription ended.
+func (s *RecordsSubscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns the reason the subscription ended, it is nil until Done is closed.
+func (s *RecordsSubscription) Err() error {
+	select {
+	case <-s.done:
+		return s.err
+	default:
+		return nil
+	}
+}
+
+// Dropped returns the number of records dropped because the buffer was full.
+func (s *RecordsSubscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close ends the subscription and removes it from the manager.
+func (s *RecordsSubscription) Close() {
+	s.end(nil)
+	s.manager.unsubscribe(s)
+}
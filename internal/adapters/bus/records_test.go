@@ -0,0 +1,93 @@
+package bus
+
+import (
+	"LogDb/internal/adapters/filters"
+	"LogDb/internal/domain"
+	"LogDb/internal/internal_errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func newRecords(messages ...string) []*domain.LogRecord {
+	records := make([]*domain.LogRecord, 0, len(messages))
+	for _, message := range messages {
+		record := domain.NewEmptyLogRecord()
+		record.Message = []byte(message)
+		records = append(records, record)
+	}
+	return records
+}
+
+func receive(subscription *RecordsSubscription) []string {
+	var messages []string
+	for {
+		select {
+		case record := <-subscription.Records():
+			messages = append(messages, string(record.Message))
+		default:
+			return messages
+		}
+	}
+}
+
+// TestRecordsManager_Filter tests that every subscription receives only the records matching its filter
+func TestRecordsManager_Filter(t *testing.T) {
+	manager := NewRecordsManager()
+	matching := manager.Subscribe(filters.NewContains([]byte("error")), 10, domain.DropRecords).(*RecordsSubscription)
+	all := manager.Subscribe(nil, 10, domain.DropRecords).(*RecordsSubscription)
+
+	manager.RecordsStored(newRecords("error 1", "info 1", "error 2"))
+
+	assert.Equal(t, []string{"error 1", "error 2"}, receive(matching))
+	assert.Equal(t, []string{"error 1", "info 1", "error 2"}, receive(all))
+}
+
+// TestRecordsManager_Drop tests that a slow subscriber loses the records that do not fit in its buffer
+func TestRecordsManager_Drop(t *testing.T) {
+	manager := NewRecordsManager()
+	subscription := manager.Subscribe(nil, 2, domain.DropRecords).(*RecordsSubscription)
+
+	manager.RecordsStored(newRecords("1", "2", "3", "4"))
+
+	assert.Equal(t, []string{"1", "2"}, receive(subscription))
+	assert.Equal(t, uint64(2), subscription.Dropped())
+	assert.NoError(t, subscription.Err())
+
+	manager.RecordsStored(newRecords("5"))
+	assert.Equal(t, []string{"5"}, receive(subscription))
+}
+
+// TestRecordsManager_Disconnect tests that a slow subscriber is disconnected when its buffer is full
+func TestRecordsManager_Disconnect(t *testing.T) {
+	manager := NewRecordsManager()
+	subscription := manager.Subscribe(nil, 2, domain.DisconnectSlowConsumer).(*RecordsSubscription)
+	other := manager.Subscribe(nil, 10, domain.DropRecords).(*RecordsSubscription)
+
+	manager.RecordsStored(newRecords("1", "2", "3", "4"))
+
+	require.ErrorIs(t, subscription.Err(), internal_errors.TailSlowConsumer)
+	assert.Equal(t, uint64(0), subscription.Dropped())
+	assert.Len(t, receive(other), 4)
+
+	manager.RecordsStored(newRecords("5"))
+	assert.Equal(t, []string{"1", "2"}, receive(subscription))
+}
+
+// TestRecordsManager_Close tests that closing the manager ends the current and the new subscriptions
+func TestRecordsManager_Close(t *testing.T) {
+	manager := NewRecordsManager()
+	subscription := manager.Subscribe(nil, 10, domain.DropRecords)
+	closed := manager.Subscribe(nil, 10, domain.DropRecords)
+	closed.Close()
+
+	manager.Close()
+
+	<-subscription.Done()
+	assert.ErrorIs(t, subscription.Err(), internal_errors.TailClosed)
+	assert.NoError(t, closed.Err())
+
+	late := manager.Subscribe(nil, 10, domain.DropRecords)
+	<-late.Done()
+	assert.ErrorIs(t, late.Err(), internal_errors.TailClosed)
+}
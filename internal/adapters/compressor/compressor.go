@@ -1,6 +1,7 @@
 package compressor
 
 import (
+	"LogDb/internal/adapters/datastor"
 	"LogDb/internal/domain"
 	"LogDb/internal/domain/compression_types"
 	"LogDb/internal/internal_errors"
@@ -23,7 +24,13 @@ func (d *DataFileCompressor) CompressDataFile(df *domain.DataFile) (*domain.Data
 	if df.Header.Compressed {
 		return nil, internal_errors.DataFileAlreadyCompressed
 	}
+	// record counts and timestamp ranges stay the same, offsets and checksums are taken from the compressed file
+	directory, err := datastor.ReadDataPageDirectory(df, d.codec)
+	if err != nil {
+		return nil, err
+	}
 	targetDataFileHeader := *df.Header
+	targetDataFileHeader.FooterOffset = 0
 	targetDataFileHeader.MarkCompressed()
 	targetDf, err := d.repo.CreateTempFromHeader(&targetDataFileHeader)
 	if err != nil {
@@ -39,14 +46,19 @@ func (d *DataFileCompressor) CompressDataFile(df *domain.DataFile) (*domain.Data
 	var dataPagesHeaders []domain.DataPageHeader
 
 	for {
-		selectedDataPageHeader, err := sourceDfReader.NextDataPage()
+		sourceDataPageHeader, err := sourceDfReader.NextDataPage()
 		if err != nil {
 			if errors.Is(err, internal_errors.NoDataPagesLeft) {
 				break
 			}
 			return nil, err
 		}
-		if _, err := d.codec.WriteDataPageHeader(selectedDataPageHeader, targetDfWriter.Source()); err != nil {
+		if err := sourceDfReader.VerifyDataPage(); err != nil {
+			return nil, err
+		}
+		// the reader uses the source header to find the next data page, so it must stay untouched
+		selectedDataPageHeader := *sourceDataPageHeader
+		if _, err := d.codec.WriteDataPageHeader(&selectedDataPageHeader, targetDfWriter.Source()); err != nil {
 			return nil, err
 		}
 
@@ -68,7 +80,7 @@ func (d *DataFileCompressor) CompressDataFile(df *domain.DataFile) (*domain.Data
 		// 341 for first page
 		// 917 total size
 		selectedDataPageHeader.CompressionAlgorithm = d.compressionType
-		dataPagesHeaders = append(dataPagesHeaders, *selectedDataPageHeader)
+		dataPagesHeaders = append(dataPagesHeaders, selectedDataPageHeader)
 	}
 	// Now we need to update the headers with the new data pages
 	// Seek to the beginning of the file + header size
@@ -84,6 +96,9 @@ func (d *DataFileCompressor) CompressDataFile(df *domain.DataFile) (*domain.Data
 			return nil, err
 		}
 	}
+	if err := datastor.WriteDataPageDirectory(targetDf, d.codec, directory); err != nil {
+		return nil, err
+	}
 	_ = sourceDfReader.Close()
 
 	if err := d.repo.DeleteByHeader(df.Header); err != nil {
@@ -94,6 +109,9 @@ func (d *DataFileCompressor) CompressDataFile(df *domain.DataFile) (*domain.Data
 		return nil, err
 	}
 	df.Header.MarkCompressed()
+	df.Header.Version = targetDf.Header.Version
+	df.Header.FooterOffset = targetDf.Header.FooterOffset
+	df.Header.FooterChecksum = targetDf.Header.FooterChecksum
 	return targetDf, nil
 }
 
@@ -4,6 +4,7 @@ import (
 	"LogDb/internal/ports"
 	"context"
 	log "github.com/sirupsen/logrus"
+	"sync"
 	"time"
 )
 
@@ -12,23 +13,32 @@ var _ ports.CompressionPolicy = (*IntervalCompressPolicy)(nil)
 type IntervalCompressPolicy struct {
 	interval time.Duration
 	ctx      context.Context
+	wg       sync.WaitGroup
 }
 
-// Apply applies the daily compress policy.
+// Apply applies the daily compress policy until the context is done.
 func (dcp *IntervalCompressPolicy) Apply(target ports.Compressible) {
+	dcp.wg.Add(1)
 	go dcp.execute(target)
 }
 
-// execute
+// Wait blocks until the running compressions finished after the context is done.
+func (dcp *IntervalCompressPolicy) Wait() {
+	dcp.wg.Wait()
+}
+
+// execute compresses the target every interval
 func (dcp *IntervalCompressPolicy) execute(target ports.Compressible) {
+	defer dcp.wg.Done()
+	ticker := time.NewTicker(dcp.interval)
+	defer ticker.Stop()
 	for {
-		// Compress the data
 		select {
 		case <-dcp.ctx.Done():
 			return
-		default:
-			<-time.After(dcp.interval)
+		case <-ticker.C:
 		}
+		// Compress the data
 		err := target.Compress()
 		if err != nil {
 			log.Error(err)
@@ -2,6 +2,7 @@ package datastor
 
 import (
 	"LogDb/internal/domain"
+	"LogDb/internal/internal_errors"
 	"LogDb/internal/ports"
 	"github.com/google/uuid"
 	log "github.com/sirupsen/logrus"
@@ -28,8 +29,13 @@ func (d *DataFileRepository) open(fullPath string) (*domain.DataFile, error) {
 	}
 	header := domain.NewEmptyDataFileHeader()
 	if _, err = d.codec.ReadFileHeader(header, fd); err != nil {
+		_ = fd.Close()
 		return nil, err
 	}
+	if !header.VerifyChecksum() {
+		_ = fd.Close()
+		return nil, internal_errors.DataFileHeaderChecksumMismatch
+	}
 	return domain.NewDataFile(header, fd), nil
 }
 
@@ -66,21 +72,21 @@ func (d *DataFileRepository) GetDataFileFullTempPath(name string) string {
 // Create creates a new data file in the repository Create(y, m, day uint64)
 func (d *DataFileRepository) Create(y, m, day uint64) (*domain.DataFile, error) {
 	id := uuid.New().ID()
-	dataFileHeader := domain.NewDataFileHeader(1, id, y, m, day)
+	dataFileHeader := domain.NewDataFileHeader(domain.DataFileVersion, id, y, m, day)
 	return d.CreateFromHeader(dataFileHeader)
 }
 
 // CreateFromHeader creates a new data file in the repository from a header
 func (d *DataFileRepository) CreateFromHeader(header *domain.DataFileHeader) (*domain.DataFile, error) {
 	log.Debugf("Creating data file: %s", header)
-	return domain.NewWriteOnlyDataFile(header, d.GetDataFileFullPath(header.String()))
+	return domain.NewReadWriteDataFile(header, d.GetDataFileFullPath(header.String()))
 	// TODO: automatically add header to the file
 }
 
 // CreateTempFromHeader creates a new temporary data file in the repository from a header
 func (d *DataFileRepository) CreateTempFromHeader(header *domain.DataFileHeader) (*domain.DataFile, error) {
 	log.Debugf("Creating temporary data file: %s", header)
-	df, err := domain.NewWriteOnlyDataFile(header, d.GetDataFileFullTempPath(header.String()))
+	df, err := domain.NewReadWriteDataFile(header, d.GetDataFileFullTempPath(header.String()))
 	if err != nil {
 		return nil, err
 	}
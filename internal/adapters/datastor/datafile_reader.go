@@ -4,6 +4,7 @@ import (
 	"LogDb/internal/domain"
 	"LogDb/internal/internal_errors"
 	"LogDb/internal/ports"
+	"errors"
 	"github.com/sirupsen/logrus"
 	"io"
 )
@@ -13,8 +14,11 @@ var _ ports.DataFileReader = &DataFileReader{}
 type DataFileReader struct {
 	source                    *domain.DataFile
 	currentDataPageHeader     *domain.DataPageHeader
+	directory                 *domain.DataPageDirectory
 	numberOfDataPageBytesRead int64
 	currentDataPageReader     io.ReadSeeker
+	currentDataPageOffset     int64             // offset of the header of the current data page
+	currentDataPagePayload    *io.SectionReader // payload of the current data page as stored, for its checksum
 	codec                     ports.Serializer
 	logger                    *logrus.Entry // Use logger for debug logs
 }
@@ -33,6 +37,9 @@ func (d *DataFileReader) GetHeader() (*domain.DataFileHeader, error) {
 		if _, err := d.codec.ReadFileHeader(d.source.Header, d.source); err != nil {
 			return nil, err
 		}
+		if !d.source.Header.VerifyChecksum() {
+			return nil, internal_errors.DataFileHeaderChecksumMismatch
+		}
 
 		d.logger.Debugf("File header read from disk. Seeking back to position: %d", ret)
 		ret, err = d.source.Seek(ret, io.SeekStart)
@@ -44,7 +51,7 @@ func (d *DataFileReader) GetHeader() (*domain.DataFileHeader, error) {
 	return d.source.Header, nil
 }
 
-// SelectDataPage returns the data page from the data file
+// SelectDataPage returns the data page from the data file, its payload is verified
 func (d *DataFileReader) SelectDataPage(pageNumber uint32) error {
 	d.logger.Debugf("Request to get data page number: %d", pageNumber)
 	header, err := d.GetHeader()
@@ -55,6 +62,19 @@ func (d *DataFileReader) SelectDataPage(pageNumber uint32) error {
 	if pageNumber < 0 || pageNumber > domain.MaxDataPagesInDataFile || pageNumber < header.FirstDataPageNumber || pageNumber > header.LastDataPageNumber {
 		return internal_errors.DataPageNumberOutOfRange
 	}
+	directory, err := d.dataPageDirectory()
+	if err != nil {
+		return err
+	}
+	if directory != nil {
+		if !directory.Entries[pageNumber].Exists() {
+			return internal_errors.DataPageNumberOutOfRange
+		}
+		if err := d.readDataPageAt(directory.Entries[pageNumber].Offset); err != nil {
+			return err
+		}
+		return d.VerifyDataPage()
+	}
 	_, err = d.GetCurrentDataPageHeader()
 	if err != nil {
 		return err
@@ -78,7 +98,7 @@ func (d *DataFileReader) SelectDataPage(pageNumber uint32) error {
 		return internal_errors.DataPageNumberOutOfRange
 	}
 	d.logger.Debugf("Successfully loaded data page number: %d", pageNumber)
-	return nil
+	return d.VerifyDataPage()
 }
 
 // CreateDataPage creates a new data page in the data file
@@ -109,7 +129,8 @@ func (d *DataFileReader) CreateDataPage(pageNumber uint32) error {
 	return nil
 }
 
-// readDataPage reads data page from the data file
+// readDataPage reads the header of the data page from the data file, the payload is only verified when the page
+// is decoded (see VerifyDataPage)
 func (d *DataFileReader) readDataPage() error {
 	d.logger.Debugf("Reading data page header from the file '%s'", d.source.File.Name())
 
@@ -128,18 +149,83 @@ func (d *DataFileReader) readDataPage() error {
 	// Log the current position for debugging
 	d.logger.Debugf("Current position after reading page header: %d", currentPosition)
 
+	if err := d.verifyDataPageHeader(); err != nil {
+		return err
+	}
 	// Create a new SectionReader from the current position with the size of the current page
+	size := int64(d.currentDataPageHeader.PageSize)
 	if d.currentDataPageHeader.CompressedPageSize != 0 {
-		d.currentDataPageReader = io.NewSectionReader(d.source, currentPosition, int64(d.currentDataPageHeader.CompressedPageSize))
-	} else {
-		d.currentDataPageReader = io.NewSectionReader(d.source, currentPosition, int64(d.currentDataPageHeader.PageSize))
+		size = int64(d.currentDataPageHeader.CompressedPageSize)
 	}
+	d.currentDataPageOffset = currentPosition - int64(domain.DataPageHeaderSize)
+	d.currentDataPagePayload = io.NewSectionReader(d.source, currentPosition, size)
+	d.currentDataPageReader = io.NewSectionReader(d.source, currentPosition, size)
 	// Reset the number of bytes read
 	d.numberOfDataPageBytesRead = 0
 
 	return nil
 }
 
+// verifyDataPageHeader verifies the header of the current data page when the data file has checksums
+func (d *DataFileReader) verifyDataPageHeader() error {
+	directory, err := d.dataPageDirectory()
+	if err != nil {
+		return err
+	}
+	if directory == nil || !d.source.Header.HasDataPageChecksums() {
+		return nil
+	}
+	if err := verifyDataPageHeader(directory, d.currentDataPageHeader); err != nil {
+		d.logger.WithError(err).Errorf("Data page header %d of '%s' is corrupted", d.currentDataPageHeader.Number, d.source.File.Name())
+		return err
+	}
+	return nil
+}
+
+// VerifyDataPage verifies the payload of the current data page when the data file has checksums. The payload is
+// read once more, it is verified right before the page is decoded and not when its header is read.
+func (d *DataFileReader) VerifyDataPage() error {
+	if d.currentDataPagePayload == nil {
+		return internal_errors.NoDataPagesLeft
+	}
+	directory, err := d.dataPageDirectory()
+	if err != nil {
+		return err
+	}
+	if directory == nil || !d.source.Header.HasDataPageChecksums() {
+		return nil
+	}
+	payload := io.NewSectionReader(d.currentDataPagePayload, 0, d.currentDataPagePayload.Size())
+	if err := verifyDataPagePayload(directory, d.currentDataPageHeader, payload); err != nil {
+		d.logger.WithError(err).Errorf("Data page %d of '%s' is corrupted", d.currentDataPageHeader.Number, d.source.File.Name())
+		return err
+	}
+	return nil
+}
+
+// readDataPageAt reads the data page which header starts at the given offset
+func (d *DataFileReader) readDataPageAt(offset uint64) error {
+	d.currentDataPageHeader = domain.NewEmptyDataPageHeader()
+	if _, err := d.source.Seek(int64(offset), io.SeekStart); err != nil {
+		return err
+	}
+	return d.readDataPage()
+}
+
+// dataPageDirectory returns the data page directory from the footer, nil for data files without it
+func (d *DataFileReader) dataPageDirectory() (*domain.DataPageDirectory, error) {
+	if d.directory != nil || !d.source.Header.HasDataPageDirectory() {
+		return d.directory, nil
+	}
+	d.logger.Debugf("Reading data page directory at %d", d.source.Header.FooterOffset)
+	directory, err := readFooter(d.source, d.codec)
+	if err != nil {
+		return nil, err
+	}
+	d.directory = directory
+	return d.directory, nil
+}
+
 // FirstDataPage returns the first data page in the data file
 func (d *DataFileReader) FirstDataPage() error {
 	d.logger.Debug("Seeking the first data page.")
@@ -159,7 +245,9 @@ func (d *DataFileReader) FirstDataPage() error {
 // GetCurrentDataPageHeader returns the current data page in the data file
 func (d *DataFileReader) GetCurrentDataPageHeader() (*domain.DataPageHeader, error) {
 	if d.currentDataPageHeader == nil {
-		return d.currentDataPageHeader, d.FirstDataPage()
+		if err := d.FirstDataPage(); err != nil {
+			return nil, err
+		}
 	}
 	return d.currentDataPageHeader, nil
 }
@@ -167,7 +255,10 @@ func (d *DataFileReader) GetCurrentDataPageHeader() (*domain.DataPageHeader, err
 // NextDataPage returns the next data page in the data file
 func (d *DataFileReader) NextDataPage() (*domain.DataPageHeader, error) {
 	if d.currentDataPageHeader == nil {
-		return d.currentDataPageHeader, d.FirstDataPage()
+		if err := d.FirstDataPage(); err != nil {
+			return nil, err
+		}
+		return d.currentDataPageHeader, nil
 	}
 	if d.currentDataPageHeader.Number >= domain.MaxDataPagesInDataFile || d.currentDataPageHeader.Number >= d.source.Header.LastDataPageNumber {
 		return nil, internal_errors.NoDataPagesLeft
@@ -189,6 +280,92 @@ func (d *DataFileReader) NextDataPage() (*domain.DataPageHeader, error) {
 	return d.currentDataPageHeader, nil
 }
 
+// SeekDataPage moves to the first data page with a number greater or equal to the given one.
+// Pages are stored in ascending order, so only the page headers in front of it are read.
+func (d *DataFileReader) SeekDataPage(pageNumber uint32) (*domain.DataPageHeader, error) {
+	header, err := d.GetHeader()
+	if err != nil {
+		return nil, err
+	}
+	if pageNumber > header.LastDataPageNumber {
+		return nil, internal_errors.NoDataPagesLeft
+	}
+	directory, err := d.dataPageDirectory()
+	if err != nil {
+		return nil, err
+	}
+	if directory != nil {
+		number, ok := directory.Next(pageNumber)
+		if !ok || number > header.LastDataPageNumber {
+			return nil, internal_errors.NoDataPagesLeft
+		}
+		if err := d.readDataPageAt(directory.Entries[number].Offset); err != nil {
+			return nil, err
+		}
+		d.logger.Debugf("Seeked to data page number %d for requested %d using the data page directory", number, pageNumber)
+		return d.currentDataPageHeader, nil
+	}
+	if d.currentDataPageHeader != nil && d.currentDataPageHeader.Number > pageNumber {
+		d.logger.Debugf("Current page number (%d) is greater than requested (%d). Seeking first page.", d.currentDataPageHeader.Number, pageNumber)
+		d.currentDataPageHeader = nil
+	}
+	pageHeader, err := d.GetCurrentDataPageHeader()
+	if err != nil {
+		return nil, err
+	}
+	for pageHeader.Number < pageNumber {
+		if pageHeader, err = d.NextDataPage(); err != nil {
+			return nil, err
+		}
+	}
+	d.logger.Debugf("Seeked to data page number %d for requested %d", pageHeader.Number, pageNumber)
+	return pageHeader, nil
+}
+
+// DataPages returns the data pages with records from the first to the last page number in file order. They are
+// taken from the data page directory without reading the data pages, the pages of data files without a directory
+// are listed by reading their headers.
+func (d *DataFileReader) DataPages(firstPage, lastPage uint32) ([]domain.DataPageLocation, error) {
+	header, err := d.GetHeader()
+	if err != nil {
+		return nil, err
+	}
+	directory, err := d.dataPageDirectory()
+	if err != nil {
+		return nil, err
+	}
+	if directory != nil {
+		return directory.Locations(firstPage, min(lastPage, header.LastDataPageNumber), header.FooterOffset), nil
+	}
+	var pages []domain.DataPageLocation
+	pageHeader, err := d.SeekDataPage(firstPage)
+	for ; err == nil && pageHeader.Number <= lastPage; pageHeader, err = d.NextDataPage() {
+		if pageHeader.RecordCount > 0 {
+			pages = append(pages, domain.DataPageLocation{
+				Number:      pageHeader.Number,
+				Offset:      uint64(d.currentDataPageOffset),
+				Size:        uint64(d.currentDataPagePayload.Size()),
+				RecordCount: pageHeader.RecordCount,
+			})
+		}
+	}
+	if err != nil && !errors.Is(err, internal_errors.NoDataPagesLeft) {
+		return nil, err
+	}
+	return pages, nil
+}
+
+// SeekDataPageAt moves to the data page listed by DataPages, only its header is read
+func (d *DataFileReader) SeekDataPageAt(page domain.DataPageLocation) (*domain.DataPageHeader, error) {
+	if err := d.readDataPageAt(page.Offset); err != nil {
+		return nil, err
+	}
+	if d.currentDataPageHeader.Number != page.Number {
+		return nil, internal_errors.DataPageNumberOutOfRange
+	}
+	return d.currentDataPageHeader, nil
+}
+
 // GetDataPageReader returns the reader for the current data page
 func (d *DataFileReader) GetDataPageReader() io.ReadSeeker {
 	return d.currentDataPageReader
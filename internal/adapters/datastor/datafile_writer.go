@@ -18,6 +18,8 @@ var _ ports.DataFileWriter = &DataFileWriter{}
 type DataFileWriter struct {
 	source                *domain.DataFile
 	currentDataPageHeader *domain.DataPageHeader
+	directory             *domain.DataPageDirectory
+	directoryTracked      bool
 	codec                 ports.Serializer
 	logger                *logrus.Entry
 	logsBuffer            *bytes.Buffer
@@ -80,11 +82,20 @@ func NewDataFileWriter(dataFile *domain.DataFile, codec ports.Serializer, logger
 		logsBuffer:           &bytes.Buffer{},
 		flushErrChan:         make(chan error, 1),
 		source:               dataFile,
+		directory:            domain.NewDataPageDirectory(),
 		bufferFlushSizeBytes: 1024 * 1024, // 1MB
 	}
 	return dfw
 }
 
+// NewDataFileWriterWithDirectory creates a new DataFileWriter that continues the given data page directory
+func NewDataFileWriterWithDirectory(dataFile *domain.DataFile, directory *domain.DataPageDirectory, codec ports.Serializer, logger *logrus.Entry) *DataFileWriter {
+	dfw := NewDataFileWriter(dataFile, codec, logger)
+	dfw.directory = directory
+	dfw.directoryTracked = true
+	return dfw
+}
+
 // WithAutoFlush(time time.Duration)
 func WithAutoFlush(interval time.Duration, dfw ports.DataFileWriter) {
 	go func() {
@@ -100,11 +111,19 @@ func WithAutoFlush(interval time.Duration, dfw ports.DataFileWriter) {
 	}()
 }
 
-// Close flushes any remaining data and closes the file
+// Close flushes any remaining data, writes the data page directory and closes the file
 func (d *DataFileWriter) Close() error {
-	if err := d.Sync(); err != nil {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.sync(); err != nil {
 		return err
 	}
+	// the directory is written only when it is known for every data page of the file
+	if d.directoryTracked {
+		if err := WriteDataPageDirectory(d.source, d.codec, d.directory); err != nil {
+			return err
+		}
+	}
 	return d.source.Close()
 }
 
@@ -190,6 +209,7 @@ func (d *DataFileWriter) AppendLogRecordToCurrentDataPage(record *domain.LogReco
 	d.currentDataPageHeader.PageSize += uint64(recordSize)
 	d.currentDataPageHeader.RecordCount++
 	d.source.Header.RecordCount++
+	d.directory.AddRecord(d.currentDataPageHeader.Number, uint64(record.Timestamp.UnixNano()))
 
 	// Flush the buffer if it exceeds 1MB
 	if d.logsBuffer.Len() >= d.bufferFlushSizeBytes {
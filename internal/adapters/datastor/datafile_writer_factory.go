@@ -53,7 +53,7 @@ func (f *DefaultDataFileFactory) Create(y, m, day uint64) (ports.DataFileWriter,
 	if err := f.init(dataFile); err != nil {
 		return nil, err
 	}
-	dataFileWriter := NewDataFileWriter(dataFile, f.codec, f.logger)
+	dataFileWriter := NewDataFileWriterWithDirectory(dataFile, domain.NewDataPageDirectory(), f.codec, f.logger)
 	return dataFileWriter, nil
 }
 
@@ -74,11 +74,21 @@ func (f *DefaultDataFileFactory) Open(fileName string) (ports.DataFileWriter, er
 		f.logger.WithError(err).Error("failed to read data file header")
 		return nil, err
 	}
+	// The footer is written again on close, new data pages go right after the last one
+	directory, err := ReadDataPageDirectory(dataFile, f.codec)
+	if err != nil {
+		f.logger.WithError(err).Error("failed to read data page directory")
+		return nil, err
+	}
+	if err := TruncateDataPageDirectory(dataFile); err != nil {
+		f.logger.WithError(err).Error("failed to truncate data page directory")
+		return nil, err
+	}
 	// Seek to the end of the file
 	if _, err := dataFile.Seek(0, io.SeekEnd); err != nil {
 		f.logger.WithError(err).Error("failed to seek to the end of the file")
 		return nil, err
 	}
-	dataFileWriter := NewDataFileWriter(dataFile, f.codec, f.logger)
+	dataFileWriter := NewDataFileWriterWithDirectory(dataFile, directory, f.codec, f.logger)
 	return dataFileWriter, nil
 }
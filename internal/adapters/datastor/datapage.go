@@ -153,7 +153,7 @@ func (dpr *DataPageReader) Record() (*domain.LogRecord, error) {
 	if err != nil {
 		return nil, err
 	}
-	ts := time.Unix(int64(dpr.recordMetadata.Timestamp), 0)
+	ts := dpr.recordMetadata.Time()
 	version := dpr.recordMetadata.SchemaVersion
 	return &domain.LogRecord{
 		Timestamp:     ts,
@@ -0,0 +1,55 @@
+package datastor
+
+import (
+	"LogDb/internal/adapters/serializer"
+	"LogDb/internal/domain"
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// TestDataPageReader_Record_TimestampFormats tests that nanosecond records keep their precision, also near the
+// epoch, and second records written by older versions are still read
+func TestDataPageReader_Record_TimestampFormats(t *testing.T) {
+	nano := time.Date(2024, 5, 1, 10, 20, 30, 123456789, time.UTC)
+	nearEpoch := time.Unix(0, 1_000).UTC()
+	legacy := time.Date(2024, 5, 1, 10, 20, 31, 0, time.UTC)
+
+	var page bytes.Buffer
+	for _, timestamp := range []time.Time{nano, nearEpoch} {
+		_, err := serializer.Default.WriteLogRecord(&domain.LogRecord{Timestamp: timestamp, SchemaVersion: 1, Message: []byte("nano")}, &page)
+		require.NoError(t, err)
+	}
+	meta := &domain.RecordMeta{
+		Timestamp:     uint64(legacy.Unix()),
+		RecordSize:    uint64(domain.RecordMetaSize + len("seconds")),
+		SchemaVersion: 1,
+		MessageSize:   uint64(len("seconds")),
+	}
+	_, err := serializer.Default.WriteLogRecordMeta(meta, &page)
+	require.NoError(t, err)
+	_, err = serializer.Default.WriteLogRecordMessage([]byte("seconds"), &page)
+	require.NoError(t, err)
+
+	header := &domain.DataPageHeader{Number: 620, PageSize: uint64(page.Len()), RecordCount: 3}
+	reader := NewDataPageReader(header, bytes.NewReader(page.Bytes()), serializer.Default)
+
+	for _, timestamp := range []time.Time{nano, nearEpoch} {
+		require.True(t, reader.Scan())
+		assert.Equal(t, domain.RecordFormatNanoseconds, reader.Metadata().Format())
+		assert.Equal(t, uint64(timestamp.UnixNano()), reader.Metadata().UnixNano())
+		record, err := reader.Record()
+		require.NoError(t, err)
+		assert.Equal(t, timestamp, record.Timestamp)
+	}
+
+	require.True(t, reader.Scan())
+	assert.Equal(t, domain.RecordFormatSeconds, reader.Metadata().Format())
+	assert.Equal(t, uint64(legacy.UnixNano()), reader.Metadata().UnixNano())
+	record, err := reader.Record()
+	require.NoError(t, err)
+	assert.Equal(t, legacy, record.Timestamp)
+	assert.Equal(t, []byte("seconds"), record.Message)
+}
@@ -0,0 +1,188 @@
+package datastor
+
+import (
+	"LogDb/internal/domain"
+	"LogDb/internal/domain/compression_types"
+	"LogDb/internal/internal_errors"
+	"LogDb/internal/ports"
+	"bytes"
+	"io"
+	"time"
+)
+
+// DataEndOffset returns the offset where the data pages of the data file end.
+// It is the footer offset for files with a data page directory and the file size otherwise.
+func DataEndOffset(df *domain.DataFile) (int64, error) {
+	if df.Header.HasDataPageDirectory() {
+		return int64(df.Header.FooterOffset), nil
+	}
+	stat, err := df.File.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return stat.Size(), nil
+}
+
+// ReadDataPageDirectory returns the data page directory of the data file.
+// The directory is read from the footer when the file has one, otherwise it is built by scanning every data page.
+func ReadDataPageDirectory(df *domain.DataFile, codec ports.Serializer) (*domain.DataPageDirectory, error) {
+	// the header kept in memory may be older than the one on disk
+	header := domain.NewEmptyDataFileHeader()
+	if _, err := codec.ReadFileHeader(header, io.NewSectionReader(df.File, 0, int64(domain.DataFileHeaderSize))); err != nil {
+		return nil, err
+	}
+	if !header.VerifyChecksum() {
+		return nil, internal_errors.DataFileHeaderChecksumMismatch
+	}
+	df.Header.Version = header.Version
+	df.Header.FooterOffset = header.FooterOffset
+	df.Header.FooterChecksum = header.FooterChecksum
+
+	if df.Header.HasDataPageDirectory() {
+		return readFooter(df, codec)
+	}
+	directory := domain.NewDataPageDirectory()
+	err := walkDataPages(df, codec, func(offset int64, pageHeader *domain.DataPageHeader, payload io.ReadSeeker) error {
+		directory.SetOffset(pageHeader.Number, uint64(offset))
+		if pageHeader.CompressionAlgorithm != compression_types.None {
+			reader, err := NewTmpDataPageReader(payload, pageHeader.CompressionAlgorithm, int64(pageHeader.CompressedPageSize), 30*time.Second)
+			if err != nil {
+				return err
+			}
+			payload = reader
+		}
+		reader := NewDataPageReader(pageHeader, payload, codec)
+		for reader.Scan() {
+			directory.AddRecord(pageHeader.Number, reader.Metadata().UnixNano())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return directory, nil
+}
+
+// WriteDataPageDirectory writes the directory right after the last data page and updates the data file header.
+// The offsets and the checksums of the data pages are taken from the data file itself.
+func WriteDataPageDirectory(df *domain.DataFile, codec ports.Serializer, directory *domain.DataPageDirectory) error {
+	end, err := DataEndOffset(df)
+	if err != nil {
+		return err
+	}
+	if err := df.File.Truncate(end); err != nil {
+		return err
+	}
+	err = walkDataPages(df, codec, func(offset int64, pageHeader *domain.DataPageHeader, payload io.ReadSeeker) error {
+		payloadChecksum, err := domain.ChecksumReader(payload)
+		if err != nil {
+			return err
+		}
+		directory.SetOffset(pageHeader.Number, uint64(offset))
+		directory.SetChecksum(pageHeader.Number, domain.DataPageChecksum{Header: pageHeader.Checksum(), Payload: payloadChecksum})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	footer := bytes.NewBuffer(make([]byte, 0, domain.DataPageDirectorySize+domain.DataPageChecksumsSize))
+	if _, err := codec.WriteDataPageDirectory(directory, footer); err != nil {
+		return err
+	}
+	if _, err := codec.WriteDataPageChecksums(directory, footer); err != nil {
+		return err
+	}
+	if _, err := df.File.WriteAt(footer.Bytes(), end); err != nil {
+		return err
+	}
+	df.Header.Version = domain.DataFileVersion
+	df.Header.FooterOffset = uint64(end)
+	df.Header.FooterChecksum = domain.Checksum(footer.Bytes())
+	if _, err := codec.WriteFileHeader(df.Header, io.NewOffsetWriter(df.File, 0)); err != nil {
+		return err
+	}
+	return df.File.Sync()
+}
+
+// readFooter reads the data page directory from the footer and verifies its checksum
+func readFooter(df *domain.DataFile, codec ports.Serializer) (*domain.DataPageDirectory, error) {
+	footer := make([]byte, df.Header.FooterSize())
+	if _, err := df.File.ReadAt(footer, int64(df.Header.FooterOffset)); err != nil {
+		return nil, err
+	}
+	if df.Header.HasDataPageChecksums() && domain.Checksum(footer) != df.Header.FooterChecksum {
+		return nil, internal_errors.DataFileFooterChecksumMismatch
+	}
+	directory := domain.NewDataPageDirectory()
+	reader := bytes.NewReader(footer)
+	if _, err := codec.ReadDataPageDirectory(directory, reader); err != nil {
+		return nil, err
+	}
+	if df.Header.HasDataPageChecksums() {
+		if _, err := codec.ReadDataPageChecksums(directory, reader); err != nil {
+			return nil, err
+		}
+	}
+	return directory, nil
+}
+
+// verifyDataPageHeader checks the data page header against the checksums of the directory
+func verifyDataPageHeader(directory *domain.DataPageDirectory, pageHeader *domain.DataPageHeader) error {
+	if pageHeader.Number >= domain.MaxDataPagesInDataFile || directory.Checksums[pageHeader.Number].Header != pageHeader.Checksum() {
+		return internal_errors.DataPageHeaderChecksumMismatch
+	}
+	return nil
+}
+
+// verifyDataPagePayload checks the payload of a data page with a verified header against the checksums of the
+// directory
+func verifyDataPagePayload(directory *domain.DataPageDirectory, pageHeader *domain.DataPageHeader, payload io.Reader) error {
+	payloadChecksum, err := domain.ChecksumReader(payload)
+	if err != nil {
+		return err
+	}
+	if directory.Checksums[pageHeader.Number].Payload != payloadChecksum {
+		return internal_errors.DataPagePayloadChecksumMismatch
+	}
+	return nil
+}
+
+// TruncateDataPageDirectory removes the footer so new data pages can be appended to the end of the data file.
+func TruncateDataPageDirectory(df *domain.DataFile) error {
+	if !df.Header.HasDataPageDirectory() {
+		return nil
+	}
+	if err := df.File.Truncate(int64(df.Header.FooterOffset)); err != nil {
+		return err
+	}
+	df.Header.FooterOffset = 0
+	return nil
+}
+
+// walkDataPages calls fn for every data page of the data file with the offset of its header and its payload.
+func walkDataPages(df *domain.DataFile, codec ports.Serializer, fn func(offset int64, pageHeader *domain.DataPageHeader, payload io.ReadSeeker) error) error {
+	end, err := DataEndOffset(df)
+	if err != nil {
+		return err
+	}
+	offset := int64(domain.DataFileHeaderSize)
+	for offset+int64(domain.DataPageHeaderSize) <= end {
+		pageHeader := domain.NewEmptyDataPageHeader()
+		if _, err := codec.ReadDataPageHeader(pageHeader, io.NewSectionReader(df.File, offset, int64(domain.DataPageHeaderSize))); err != nil {
+			return err
+		}
+		if pageHeader.Number >= domain.MaxDataPagesInDataFile {
+			return internal_errors.DataPageNumberOutOfRange
+		}
+		size := int64(pageHeader.PageSize)
+		if pageHeader.CompressionAlgorithm != compression_types.None {
+			size = int64(pageHeader.CompressedPageSize)
+		}
+		payload := io.NewSectionReader(df.File, offset+int64(domain.DataPageHeaderSize), size)
+		if err := fn(offset, pageHeader, payload); err != nil {
+			return err
+		}
+		offset += int64(domain.DataPageHeaderSize) + size
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package datastor
+
+import (
+	"LogDb/internal/adapters/serializer"
+	"LogDb/internal/domain"
+	"LogDb/internal/internal_errors"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+// writeTestDataFile writes one record per given minute and closes the writer
+func writeTestDataFile(t *testing.T, repo *DataFileRepository, minutes ...uint32) *domain.DataFileHeader {
+	dfw, err := NewDataFileWriterFactory(repo, logrus.NewEntry(logrus.StandardLogger())).Create(2024, 5, 1)
+	require.NoError(t, err)
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	for _, minute := range minutes {
+		require.NoError(t, dfw.AppendDataPage(domain.NewDataPageHeaderForMinute(minute)))
+		record := &domain.LogRecord{Timestamp: day.Add(time.Duration(minute) * time.Minute), SchemaVersion: 1, Message: []byte("message")}
+		require.NoError(t, dfw.AppendLogRecordToCurrentDataPage(record))
+	}
+	require.NoError(t, dfw.Close())
+	return dfw.Source().Header
+}
+
+// removeDataPageDirectory turns the data file into a data file written before the data page directory
+func removeDataPageDirectory(t *testing.T, repo *DataFileRepository, header *domain.DataFileHeader) {
+	df, err := repo.Open(header.String())
+	require.NoError(t, err)
+	defer df.Close()
+	require.NoError(t, TruncateDataPageDirectory(df))
+	df.Header.Version = domain.DataFileVersionPagesOnly
+	_, err = repo.Codec().WriteFileHeader(df.Header, io.NewOffsetWriter(df.File, 0))
+	require.NoError(t, err)
+}
+
+// TestDataFileWriter_Close_WritesDataPageDirectory tests that the footer points to every data page
+func TestDataFileWriter_Close_WritesDataPageDirectory(t *testing.T) {
+	repo := NewDataFileRepository(t.TempDir(), serializer.Default, "chunk")
+	header := writeTestDataFile(t, repo, 3, 10, 700)
+	assert.Equal(t, domain.DataFileVersion, header.Version)
+	assert.NotZero(t, header.FooterOffset)
+
+	df, err := repo.Open(header.String())
+	require.NoError(t, err)
+	defer df.Close()
+	assert.Equal(t, header.FooterOffset, df.Header.FooterOffset)
+
+	directory, err := ReadDataPageDirectory(df, repo.Codec())
+	require.NoError(t, err)
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	for _, minute := range []uint32{3, 10, 700} {
+		entry := directory.Entries[minute]
+		assert.True(t, entry.Exists())
+		assert.Equal(t, uint64(1), entry.RecordCount)
+		assert.Equal(t, uint64(day.Add(time.Duration(minute)*time.Minute).UnixNano()), entry.MinTimestamp)
+		assert.Equal(t, entry.MinTimestamp, entry.MaxTimestamp)
+	}
+	assert.False(t, directory.Entries[4].Exists())
+
+	reader := NewDataFileManagerFactory(repo).FromDataFile(df)
+	require.NoError(t, reader.SelectDataPage(700))
+	pageHeader, err := reader.GetCurrentDataPageHeader()
+	require.NoError(t, err)
+	assert.Equal(t, uint32(700), pageHeader.Number)
+	assert.ErrorIs(t, reader.SelectDataPage(4), internal_errors.DataPageNumberOutOfRange)
+
+	pageHeader, err = reader.SeekDataPage(11)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(700), pageHeader.Number)
+}
+
+// TestReadDataPageDirectory_WithoutFooter tests that the directory of older data files is built by scanning
+func TestReadDataPageDirectory_WithoutFooter(t *testing.T) {
+	repo := NewDataFileRepository(t.TempDir(), serializer.Default, "chunk")
+	header := writeTestDataFile(t, repo, 1, 2)
+
+	df, err := repo.Open(header.String())
+	require.NoError(t, err)
+	defer df.Close()
+	require.NoError(t, TruncateDataPageDirectory(df))
+	df.Header.Version = domain.DataFileVersionPagesOnly
+	_, err = repo.Codec().WriteFileHeader(df.Header, io.NewOffsetWriter(df.File, 0))
+	require.NoError(t, err)
+
+	directory, err := ReadDataPageDirectory(df, repo.Codec())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(domain.DataFileHeaderSize), directory.Entries[1].Offset)
+	assert.Equal(t, uint64(1), directory.Entries[2].RecordCount)
+
+	reader := NewDataFileManagerFactory(repo).FromDataFile(df)
+	require.NoError(t, reader.SelectDataPage(2))
+}
+
+// TestDataFileReader_SelectDataPage_ChecksumMismatch tests that corrupted data pages are reported with typed errors
+func TestDataFileReader_SelectDataPage_ChecksumMismatch(t *testing.T) {
+	repo := NewDataFileRepository(t.TempDir(), serializer.Default, "chunk")
+	header := writeTestDataFile(t, repo, 1, 2)
+
+	corrupt := func(offset int64) {
+		f, err := os.OpenFile(repo.GetDataFileFullPath(header.String()), os.O_RDWR, 0600)
+		require.NoError(t, err)
+		defer f.Close()
+		b := make([]byte, 1)
+		_, err = f.ReadAt(b, offset)
+		require.NoError(t, err)
+		b[0] ^= 0xff
+		_, err = f.WriteAt(b, offset)
+		require.NoError(t, err)
+	}
+	selectDataPage := func(number uint32) error {
+		df, err := repo.Open(header.String())
+		if err != nil {
+			return err
+		}
+		defer df.Close()
+		return NewDataFileManagerFactory(repo).FromDataFile(df).SelectDataPage(number)
+	}
+	require.NoError(t, selectDataPage(1))
+
+	// a byte of the record meta of the first page
+	corrupt(int64(domain.DataFileHeaderSize+domain.DataPageHeaderSize) + 1)
+	assert.ErrorIs(t, selectDataPage(1), internal_errors.DataPagePayloadChecksumMismatch)
+	assert.NoError(t, selectDataPage(2))
+
+	// the record count of the first page
+	corrupt(int64(domain.DataFileHeaderSize) + 12)
+	assert.ErrorIs(t, selectDataPage(1), internal_errors.DataPageHeaderChecksumMismatch)
+
+	// the record count of the file
+	corrupt(12)
+	assert.ErrorIs(t, selectDataPage(1), internal_errors.DataFileHeaderChecksumMismatch)
+}
+
+// TestDataFileReader_VerifyDataPage tests that listing data pages reads their headers only, the payload is
+// verified when the page is decoded
+func TestDataFileReader_VerifyDataPage(t *testing.T) {
+	repo := NewDataFileRepository(t.TempDir(), serializer.Default, "chunk")
+	header := writeTestDataFile(t, repo, 1, 2)
+
+	f, err := os.OpenFile(repo.GetDataFileFullPath(header.String()), os.O_RDWR, 0600)
+	require.NoError(t, err)
+	// a byte of the record meta of the first page
+	_, err = f.WriteAt([]byte{0xff}, int64(domain.DataFileHeaderSize+domain.DataPageHeaderSize)+1)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	df, err := repo.Open(header.String())
+	require.NoError(t, err)
+	defer df.Close()
+	reader := NewDataFileManagerFactory(repo).FromDataFile(df)
+
+	pageHeader, err := reader.SeekDataPage(0)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), pageHeader.Number)
+	assert.ErrorIs(t, reader.VerifyDataPage(), internal_errors.DataPagePayloadChecksumMismatch)
+
+	pageHeader, err = reader.NextDataPage()
+	require.NoError(t, err)
+	assert.Equal(t, uint32(2), pageHeader.Number)
+	assert.NoError(t, reader.VerifyDataPage())
+}
+
+// TestDataFileReader_DataPages tests that the data pages of a range are listed from the directory and by reading
+// their headers in data files without a footer alike
+func TestDataFileReader_DataPages(t *testing.T) {
+	repo := NewDataFileRepository(t.TempDir(), serializer.Default, "chunk")
+	header := writeTestDataFile(t, repo, 3, 10, 11, 700)
+
+	df, err := repo.Open(header.String())
+	require.NoError(t, err)
+	defer df.Close()
+	reader := NewDataFileManagerFactory(repo).FromDataFile(df)
+	pages, err := reader.DataPages(4, 700)
+	require.NoError(t, err)
+	require.Len(t, pages, 3)
+	assert.Equal(t, []uint32{10, 11, 700}, []uint32{pages[0].Number, pages[1].Number, pages[2].Number})
+	for _, page := range pages {
+		assert.Equal(t, uint64(1), page.RecordCount)
+		pageHeader, err := reader.SeekDataPageAt(page)
+		require.NoError(t, err)
+		assert.Equal(t, page.Number, pageHeader.Number)
+		assert.Equal(t, pageHeader.PageSize, page.Size)
+		require.NoError(t, reader.VerifyDataPage())
+	}
+	pages, err = reader.DataPages(701, domain.MaxDataPagesInDataFile-1)
+	require.NoError(t, err)
+	assert.Empty(t, pages)
+
+	listed, err := reader.DataPages(4, 700)
+	require.NoError(t, err)
+	removeDataPageDirectory(t, repo, header)
+	withoutFooter, err := repo.Open(header.String())
+	require.NoError(t, err)
+	defer withoutFooter.Close()
+	require.False(t, withoutFooter.Header.HasDataPageDirectory())
+
+	walked, err := NewDataFileManagerFactory(repo).FromDataFile(withoutFooter).DataPages(4, 700)
+	require.NoError(t, err)
+	assert.Equal(t, listed, walked)
+}
@@ -2,10 +2,15 @@ package datastor
 
 import (
 	"LogDb/internal/domain"
+	"LogDb/internal/domain/query_types"
 	"LogDb/internal/internal_errors"
 	"LogDb/internal/ports"
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"slices"
+	"sort"
 	"time"
 )
 
@@ -22,10 +27,14 @@ type PersistentStorage struct {
 
 	// Writer
 	memTable ports.MemTable
+	// Propagates the stored records to live subscribers
+	recordsPropagator ports.RecordsPropagator
 
 	// Reader
 	dataPageReaderFactory  ports.DataPageReaderFactory
 	dataFileManagerFactory ports.DataFileReaderFactory
+	scanOptions            ScanOptions
+	queryBudget            domain.QueryBudget
 }
 
 // NewPersistentStorage creates a new persistent storage
@@ -81,84 +90,430 @@ func (p *PersistentStorage) updateIndex(df *domain.DataFileHeader) error {
 	return nil
 }
 
+// BindRecordsPropagator sets the propagator notified about every stored record
+func (p *PersistentStorage) BindRecordsPropagator(propagator ports.RecordsPropagator) {
+	p.recordsPropagator = propagator
+}
+
+// SetScanOptions sets how many workers scan the data files of a query, a query can ask for fewer workers
+func (p *PersistentStorage) SetScanOptions(options ScanOptions) {
+	p.scanOptions = options
+}
+
 // StoreLogRecord stores the log record in the persistent storage
 func (p *PersistentStorage) StoreLogRecord(record *domain.LogRecord) error {
-	return p.memTable.Add(record)
+	_, err := p.StoreLogRecords([]*domain.LogRecord{record})
+	return err
 }
 
-// Query queries the log records in the persistent storage
-func (p *PersistentStorage) Query(query ports.PreparedQuery) (*domain.QueryResult, error) {
-	// Query the primary index
+// StoreLogRecords stores the log records in the persistent storage as one batch, it returns how many records were
+// acknowledged before an error. The acknowledged records are propagated.
+func (p *PersistentStorage) StoreLogRecords(records []*domain.LogRecord) (int, error) {
+	stored, err := p.memTable.AddBatch(records)
+	if p.recordsPropagator != nil && stored > 0 {
+		p.recordsPropagator.RecordsStored(records[:stored])
+	}
+	return stored, err
+}
+
+// Query queries the log records ordered by timestamp until the context is done or the timeout of the query expired.
+func (p *PersistentStorage) Query(ctx context.Context, query ports.PreparedQuery) (*domain.QueryResult, error) {
 	query.Begin()
 	defer query.End()
-	idxOperations, err := p.primaryIndex.GetDataFilesForRead(query)
+	if timeout := query.Timeout(); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	// The snapshot is taken before the data files are selected, a chunk flushed in between is in both
+	snapshot := p.memTable.Snapshot()
+
+	// Query the primary index
+	idxOperations, err := p.primaryIndex.GetDataFilesForRead(ctx, query)
 	if err != nil {
-		query.SetError(fmt.Errorf("failed to query primary index: %w", err))
-		return query.Result()
+		return p.queryStopped(query, fmt.Errorf("failed to query primary index: %w", err))
 	}
+	defer func() {
+		for _, idxOp := range idxOperations {
+			idxOp.Done()
+		}
+	}()
 	// Query the secondary indexes if any
 
-	// Iterate over the data files
+	if query.Explain() {
+		return p.explain(query, snapshot, idxOperations)
+	}
+	if err := checkCursor(query.After(), idxOperations); err != nil {
+		query.SetError(err)
+		return query.Result()
+	}
+
+	descending := query.Order() == query_types.Descending
+	sortDataFiles(idxOperations, descending)
+
+	// The records of the chunks that started flushing may be in the selected data files
+	flushing := snapshot.Flushing(p.memTable.FlushSequence())
+	stored := newStoredRecords(query, snapshot.Chunks[:flushing])
+
+	// The records of the MemTable are the newest, they come first in descending order and the records of the
+	// flushing chunks are skipped in data files
+	if descending {
+		if err := p.queryMemTable(ctx, query, snapshot, flushing, stored); err != nil {
+			return p.queryStopped(query, err)
+		}
+		stored.passed = true
+	}
+
+	// Iterate over the data files until the query has its records
+	if workers := p.scanWorkers(query); workers > 1 {
+		err = p.scanDataFiles(ctx, stored, idxOperations, workers)
+	} else {
+		err = p.queryDataFiles(ctx, stored, idxOperations)
+	}
+	if err != nil {
+		return p.queryStopped(query, err)
+	}
+	if !descending {
+		if err := p.queryMemTable(ctx, query, snapshot, flushing, stored); err != nil {
+			return p.queryStopped(query, err)
+		}
+	}
+	return p.queryStopped(query, nil)
+}
+
+// queryDataFiles reads the data files one after the other until the query has its records
+func (p *PersistentStorage) queryDataFiles(ctx context.Context, query ports.PreparedQuery, idxOperations []ports.IndexOperation) error {
 	for _, idxOp := range idxOperations {
-		dataFileManager, err := p.dataFileManagerFactory.NewDataFileManager(idxOp.GetDataFileHeader().String())
-		if err != nil {
-			query.SetError(fmt.Errorf("failed to get data file header: %w", err))
-			return query.Result()
-		}
-		defer dataFileManager.Close()
-		for {
-			dataPageHeader, err := dataFileManager.NextDataPage()
-			if err != nil {
-				if errors.Is(err, internal_errors.NoDataPagesLeft) {
-					break
-				}
-				query.SetError(fmt.Errorf("failed to get data page: %w", err))
-				return query.Result()
+		header := idxOp.GetDataFileHeader()
+		if isSatisfied(query, header.Time(), header.Time().AddDate(0, 0, 1)) {
+			break
+		}
+		if err := p.queryDataFile(ctx, query, header); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sortDataFiles sorts the data files day by day in the order of the query, they are read in this order
+func sortDataFiles(idxOperations []ports.IndexOperation, descending bool) {
+	slices.SortStableFunc(idxOperations, func(a, b ports.IndexOperation) int {
+		if descending {
+			a, b = b, a
+		}
+		return a.GetDataFileHeader().Time().Compare(b.GetDataFileHeader().Time())
+	})
+}
+
+// scanWorkers returns the number of workers scanning the data files of the query, the query can ask for fewer
+// workers than the storage has
+func (p *PersistentStorage) scanWorkers(query ports.PreparedQuery) int {
+	workers := p.scanOptions.Workers
+	if concurrency := query.Concurrency(); concurrency > 0 && concurrency < workers {
+		workers = concurrency
+	}
+	return workers
+}
+
+// queryStopped returns the result of the query sorted in its order, reaching the limit is not an error and a
+// cancelled query returns a truncated result
+func (p *PersistentStorage) queryStopped(query ports.PreparedQuery, err error) (*domain.QueryResult, error) {
+	truncated := errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+	if err != nil && !truncated && !errors.Is(err, internal_errors.RecordsLimitReached) {
+		query.SetError(err)
+	}
+	result, err := query.Result()
+	if result != nil {
+		result.Report.Truncated = truncated
+		result.Sort()
+	}
+	return result, err
+}
+
+// checkCursor fails when the data file holding the last record of the previous page is no longer read by the
+// query, a merge rewrote its records into other data files and their positions are lost. A compressed data file
+// keeps its name and the positions of its records.
+func checkCursor(cursor *domain.Cursor, idxOperations []ports.IndexOperation) error {
+	if cursor == nil || cursor.Position.DataFile == "" {
+		return nil
+	}
+	for _, idxOp := range idxOperations {
+		if idxOp.GetDataFileHeader().String() == cursor.Position.DataFile {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", internal_errors.CursorDataFileRewritten, cursor.Position.DataFile)
+}
+
+// isSatisfied reports whether the records from start until before end can no longer change the result of the query
+func isSatisfied(query ports.PreparedQuery, start, end time.Time) bool {
+	return query.IsSatisfied(uint64(start.UnixNano()), uint64(end.UnixNano())-1)
+}
+
+// queryMemTable processes the records of the MemTable snapshot in the order of the query, the records of the
+// flushing chunks already found in data files are skipped. The records sharing a timestamp are numbered, the
+// number does not depend on the time range. The records are copied to carry their position, the MemTable shares
+// them with other queries.
+func (p *PersistentStorage) queryMemTable(ctx context.Context, query ports.PreparedQuery, snapshot *domain.MemTableSnapshot, flushing int, stored *storedRecords) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	var records []*domain.LogRecord
+	usage := query.Usage()
+	for i, chunk := range snapshot.Chunks {
+		for _, record := range chunk {
+			usage.ScannedRecords++
+			usage.ScannedBytes += record.Size()
+			timestamp := uint64(record.Timestamp.UnixNano())
+			if query.IsBefore(timestamp) || query.IsAfter(timestamp) {
+				query.Skip()
+				continue
 			}
-			if dataPageHeader.RecordCount < 1 {
+			if i < flushing && stored.take(record) {
+				continue
+			}
+			records = append(records, record)
+		}
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Timestamp.Before(records[j].Timestamp)
+	})
+	positioned := make([]domain.LogRecord, len(records))
+	for i, record := range records {
+		positioned[i] = *record
+		position := &domain.RecordPosition{}
+		if i > 0 && record.Timestamp.Equal(records[i-1].Timestamp) {
+			position.Offset = positioned[i-1].Position.Offset + 1
+		}
+		positioned[i].Position = position
+	}
+	descending := query.Order() == query_types.Descending
+	for i := range positioned {
+		if descending {
+			i = len(positioned) - 1 - i
+		}
+		if err := query.Next(&positioned[i]); err != nil {
+			return fmt.Errorf("failed to process record: %w", err)
+		}
+		if err := p.checkBudget(query); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var _ ports.PageCounter = &storedRecords{}
+
+// storedRecords passes the records of the data files to the query and counts the records of flushing chunks among them
+type storedRecords struct {
+	ports.PreparedQuery
+	pending      map[domain.RecordFingerprint]int // records of flushing chunks not found in data files
+	found        map[domain.RecordFingerprint]int // records of flushing chunks found in data files
+	pendingPages map[int64]struct{}               // start of the data pages that may hold records of flushing chunks
+	passed       bool                             // the records of flushing chunks were passed from the MemTable
+}
+
+// newStoredRecords creates a query counting the records of the flushing chunks in the time range of the query
+func newStoredRecords(query ports.PreparedQuery, chunks [][]*domain.LogRecord) *storedRecords {
+	s := &storedRecords{PreparedQuery: query}
+	for _, chunk := range chunks {
+		for _, record := range chunk {
+			timestamp := uint64(record.Timestamp.UnixNano())
+			if query.IsBefore(timestamp) || query.IsAfter(timestamp) {
 				continue
 			}
-			// Initialize the data page reader
-			pageReader := p.dataPageReaderFactory.NewDataPageReader(dataPageHeader, dataFileManager.GetDataPageReader())
-
-			// Process each record in the page
-			for i := 0; i < int(dataPageHeader.RecordCount); i++ {
-				if !pageReader.Scan() {
-					break
-				}
-
-				meta := pageReader.Metadata()
-				labels, err := pageReader.Labels()
-				if err != nil {
-					fmt.Printf("Error reading labels: %v\n", err)
-					break
-				}
-				message, err := pageReader.Message()
-				if err != nil {
-					fmt.Printf("Error reading message: %v\n", err)
-					break
-				}
-				logRecord := &domain.LogRecord{
-					SchemaVersion: meta.SchemaVersion,
-					Labels:        labels,
-					Message:       message,
-					Timestamp:     time.Unix(int64(meta.Timestamp), 0),
-				}
-				err = query.Next(logRecord)
-				if err != nil {
-					query.SetError(fmt.Errorf("failed to process record: %w", err))
-					break
-				}
+			if s.pending == nil {
+				s.pending = make(map[domain.RecordFingerprint]int)
+				s.found = make(map[domain.RecordFingerprint]int)
+				s.pendingPages = make(map[int64]struct{})
 			}
+			s.pending[record.Fingerprint()]++
+			s.pendingPages[record.Timestamp.Truncate(domain.DataPageDuration).UnixNano()] = struct{}{}
 		}
+	}
+	return s
+}
+
+// Next counts the record when it belongs to a flushing chunk and passes it to the query, unless the record was
+// passed from the MemTable already
+func (s *storedRecords) Next(record *domain.LogRecord) error {
+	if s.pending != nil {
+		key := record.Fingerprint()
+		if s.pending[key] > 0 {
+			s.pending[key]--
+			if s.passed {
+				return nil
+			}
+			s.found[key]++
+		}
+	}
+	return s.PreparedQuery.Next(record)
+}
 
+// CountPage lets the query count the data page without reading it, unless the page may hold records of flushing
+// chunks, they must be found to be returned once
+func (s *storedRecords) CountPage(start time.Time, records uint64) bool {
+	counter, ok := s.PreparedQuery.(ports.PageCounter)
+	if !ok {
+		return false
 	}
-	for _, idxOp := range idxOperations {
-		idxOp.Done()
+	if _, ok := s.pendingPages[start.UnixNano()]; ok {
+		return false
 	}
-	return query.Result()
+	return counter.CountPage(start, records)
 }
 
-func (p *PersistentStorage) Close() error {
+// take reports whether the record of a flushing chunk was found in data files, every found record is taken once
+func (s *storedRecords) take(record *domain.LogRecord) bool {
+	if s.found == nil {
+		return false
+	}
+	key := record.Fingerprint()
+	if s.found[key] > 0 {
+		s.found[key]--
+		return true
+	}
+	return false
+}
+
+// queryDataFile reads the data pages of the data file overlapping the time range of the query in the order of
+// the query, until the query has its records
+func (p *PersistentStorage) queryDataFile(ctx context.Context, query ports.PreparedQuery, header *domain.DataFileHeader) error {
+	from := time.Unix(0, int64(query.FromDateTime())).UTC()
+	to := time.Unix(0, int64(query.ToDateTime())).UTC()
+	firstPage, lastPage, ok := header.DataPageRange(from, to)
+	if !ok {
+		return nil
+	}
+	if err := p.openDataFile(query); err != nil {
+		return err
+	}
+
+	dataFileManager, err := p.dataFileManagerFactory.NewDataFileManager(header.String())
+	if err != nil {
+		return fmt.Errorf("failed to get data file header: %w", err)
+	}
+	defer dataFileManager.Close()
+
+	// The pages of the range are listed from the data page directory, pages are minutes of the day
+	pages, err := dataFileManager.DataPages(firstPage, lastPage)
+	if err != nil {
+		return fmt.Errorf("failed to get data page: %w", err)
+	}
+	if query.Order() == query_types.Descending {
+		slices.Reverse(pages)
+	}
+	for _, page := range pages {
+		if done, err := p.visitDataPage(ctx, query, header, dataFileManager, page); done || err != nil {
+			return err
+		}
+	}
 	return nil
 }
+
+// visitDataPage counts or reads the records of the data page, done is true when the query has its records and
+// the following pages in the order of the query are not needed. A cancelled query stops before the page.
+func (p *PersistentStorage) visitDataPage(ctx context.Context, query ports.PreparedQuery, header *domain.DataFileHeader, dataFileManager ports.DataFileReader, page domain.DataPageLocation) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	start := header.DataPageStart(page.Number)
+	if isSatisfied(query, start, start.Add(domain.DataPageDuration)) {
+		return true, nil
+	}
+	// Counting queries take the number of records from the directory
+	if counter, ok := query.(ports.PageCounter); ok && counter.CountPage(start, page.RecordCount) {
+		return false, nil
+	}
+	dataPageHeader, err := dataFileManager.SeekDataPageAt(page)
+	if err == nil {
+		// Only the payload of a decoded page is verified, listing pages reads the directory only
+		err = dataFileManager.VerifyDataPage()
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get data page: %w", err)
+	}
+	return false, p.queryDataPage(query, header, dataPageHeader, dataFileManager.GetDataPageReader())
+}
+
+// queryDataPage processes the records of the data page in the order of the query
+func (p *PersistentStorage) queryDataPage(query ports.PreparedQuery, header *domain.DataFileHeader, dataPageHeader *domain.DataPageHeader, reader io.ReadSeeker) error {
+	page := p.readDataPage(query, header, dataPageHeader, reader)
+	return p.passDataPage(query, page)
+}
+
+// readDataPage reads the records of the data page in the time range of the query, records outside the time range
+// are skipped without reading their labels and message. In descending order the records are returned last first.
+// Only the time range and the order of the query are used, the workers of a scan read pages concurrently.
+func (p *PersistentStorage) readDataPage(query ports.PreparedQuery, header *domain.DataFileHeader, dataPageHeader *domain.DataPageHeader, reader io.ReadSeeker) *scannedPage {
+	dataFile := header.String()
+	page := &scannedPage{
+		start:   header.DataPageStart(dataPageHeader.Number),
+		size:    dataPageHeader.PageSize,
+		records: make([]*domain.LogRecord, 0, dataPageHeader.RecordCount),
+	}
+	// Initialize the data page reader
+	pageReader := p.dataPageReaderFactory.NewDataPageReader(dataPageHeader, reader)
+
+	// Read each record in the page
+	for i := 0; i < int(dataPageHeader.RecordCount); i++ {
+		if !pageReader.Scan() {
+			break
+		}
+
+		meta := pageReader.Metadata()
+		// Records written before the nanosecond format store seconds
+		timestamp := meta.UnixNano()
+		if query.IsBefore(timestamp) || query.IsAfter(timestamp) {
+			page.skipped++
+			continue
+		}
+		labels, err := pageReader.Labels()
+		if err != nil {
+			page.err = fmt.Errorf("failed to read labels: %w", err)
+			break
+		}
+		message, err := pageReader.Message()
+		if err != nil {
+			page.err = fmt.Errorf("failed to read message: %w", err)
+			break
+		}
+		// The page reader reuses its buffers for the next record
+		page.records = append(page.records, &domain.LogRecord{
+			SchemaVersion: meta.SchemaVersion,
+			Labels:        append([]domain.Label(nil), labels...),
+			Message:       append([]byte(nil), message...),
+			Timestamp:     meta.Time(),
+			Position:      &domain.RecordPosition{DataFile: dataFile, Page: dataPageHeader.Number, Offset: uint32(i)},
+		})
+	}
+	if query.Order() == query_types.Descending {
+		slices.Reverse(page.records)
+	}
+	return page
+}
+
+// passDataPage passes the records read from a data page to the query, the records read before an error are passed.
+// The page counts in the usage of the query, it fails once the query consumed more than its budget.
+func (p *PersistentStorage) passDataPage(query ports.PreparedQuery, page *scannedPage) error {
+	usage := query.Usage()
+	usage.ScannedBytes += page.size
+	usage.ScannedRecords += uint64(len(page.records) + page.skipped)
+	for i := 0; i < page.skipped; i++ {
+		query.Skip()
+	}
+	for _, record := range page.records {
+		if err := query.Next(record); err != nil {
+			return fmt.Errorf("failed to process record: %w", err)
+		}
+	}
+	if page.err != nil {
+		return page.err
+	}
+	return p.checkBudget(query)
+}
+
+// Close stops accepting records and flushes the records of the MemTable to data files
+func (p *PersistentStorage) Close() error {
+	return p.memTable.Close()
+}
@@ -0,0 +1,36 @@
+package datastor
+
+import (
+	"LogDb/internal/domain"
+	"LogDb/internal/internal_errors"
+	"LogDb/internal/ports"
+	"fmt"
+)
+
+// SetQueryBudget sets the limits of the resources of every query, a query can ask for lower limits
+func (p *PersistentStorage) SetQueryBudget(budget domain.QueryBudget) {
+	p.queryBudget = budget
+}
+
+// checkBudget fails when the query consumed more than the lower of the budgets of the storage and the query
+func (p *PersistentStorage) checkBudget(query ports.PreparedQuery) error {
+	budget := p.queryBudget.Lower(query.Budget())
+	usage := query.Usage()
+	switch {
+	case budget.DataFiles > 0 && usage.DataFiles > budget.DataFiles:
+		return fmt.Errorf("%w: %d data files opened, the budget is %d", internal_errors.QueryBudgetExceeded, usage.DataFiles, budget.DataFiles)
+	case budget.ScannedBytes > 0 && usage.ScannedBytes > budget.ScannedBytes:
+		return fmt.Errorf("%w: %d bytes scanned, the budget is %d", internal_errors.QueryBudgetExceeded, usage.ScannedBytes, budget.ScannedBytes)
+	case budget.ScannedRecords > 0 && usage.ScannedRecords > budget.ScannedRecords:
+		return fmt.Errorf("%w: %d records scanned, the budget is %d", internal_errors.QueryBudgetExceeded, usage.ScannedRecords, budget.ScannedRecords)
+	case budget.ResultBytes > 0 && usage.ResultBytes > budget.ResultBytes:
+		return fmt.Errorf("%w: %d result bytes, the budget is %d", internal_errors.QueryBudgetExceeded, usage.ResultBytes, budget.ResultBytes)
+	}
+	return nil
+}
+
+// openDataFile counts the data file opened by the query, it fails when the query opened too many data files
+func (p *PersistentStorage) openDataFile(query ports.PreparedQuery) error {
+	query.Usage().DataFiles++
+	return p.checkBudget(query)
+}
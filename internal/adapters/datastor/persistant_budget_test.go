@@ -0,0 +1,69 @@
+package datastor
+
+import (
+	"LogDb/internal/domain"
+	"LogDb/internal/domain/query_types"
+	"LogDb/internal/internal_errors"
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// TestPersistentStorage_Query_Budget tests that a query consuming more than a limit of its budget or of the budget of
+// the storage fails and reports what it consumed
+func TestPersistentStorage_Query_Budget(t *testing.T) {
+	tests := []struct {
+		name     string
+		budget   domain.QueryBudget
+		exceeded func(domain.QueryUsage, domain.QueryBudget) bool
+	}{
+		{"data files", domain.QueryBudget{DataFiles: 2}, func(u domain.QueryUsage, b domain.QueryBudget) bool {
+			return u.DataFiles > b.DataFiles
+		}},
+		{"scanned bytes", domain.QueryBudget{ScannedBytes: 4096}, func(u domain.QueryUsage, b domain.QueryBudget) bool {
+			return u.ScannedBytes > b.ScannedBytes
+		}},
+		{"scanned records", domain.QueryBudget{ScannedRecords: 100}, func(u domain.QueryUsage, b domain.QueryBudget) bool {
+			return u.ScannedRecords > b.ScannedRecords
+		}},
+		{"result bytes", domain.QueryBudget{ResultBytes: 1024}, func(u domain.QueryUsage, b domain.QueryBudget) bool {
+			return u.ResultBytes > b.ResultBytes
+		}},
+	}
+	storage := newTestStorage(t)
+	for _, test := range tests {
+		for _, options := range testScanOptions {
+			for _, ofStorage := range []bool{false, true} {
+				t.Run(fmt.Sprintf("%s %+v storage %t", test.name, options, ofStorage), func(t *testing.T) {
+					storage.SetScanOptions(options)
+					q := testQuery(query_types.Ascending, 0)
+					if ofStorage {
+						storage.SetQueryBudget(test.budget)
+						defer storage.SetQueryBudget(domain.QueryBudget{})
+					} else {
+						q.Budget = test.budget
+					}
+					prepared := storage.prepareQuery(t, q)
+					result := storage.query(t, context.Background(), prepared)
+					require.ErrorIs(t, prepared.Error(), internal_errors.QueryBudgetExceeded)
+					assert.True(t, test.exceeded(result.Report.Usage, test.budget), "usage %+v", result.Report.Usage)
+					assert.NotZero(t, result.Report.Usage.DataFiles)
+				})
+			}
+		}
+	}
+}
+
+// TestPersistentStorage_Query_WithinBudget tests that a query consuming less than its budget returns every record
+func TestPersistentStorage_Query_WithinBudget(t *testing.T) {
+	storage := newTestStorage(t)
+	storage.SetQueryBudget(domain.QueryBudget{DataFiles: len(testDays), ScannedRecords: testRecords})
+	prepared := storage.prepare(t, query_types.Ascending, 0)
+	result := storage.query(t, context.Background(), prepared)
+	require.NoError(t, prepared.Error())
+	assert.Len(t, result.Records, testRecords)
+	assert.Equal(t, len(testDays), result.Report.Usage.DataFiles)
+	assert.Equal(t, uint64(testRecords), result.Report.Usage.ScannedRecords)
+}
@@ -0,0 +1,82 @@
+package datastor
+
+import (
+	"LogDb/internal/domain"
+	"LogDb/internal/domain/query_types"
+	"LogDb/internal/ports"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// explain returns the plan of the query in its result without reading records
+func (p *PersistentStorage) explain(query ports.PreparedQuery, snapshot *domain.MemTableSnapshot, idxOperations []ports.IndexOperation) (*domain.QueryResult, error) {
+	plan := &domain.QueryPlan{
+		From:    time.Unix(0, int64(query.FromDateTime())).UTC(),
+		To:      time.Unix(0, int64(query.ToDateTime())).UTC(),
+		Order:   query.Order(),
+		Indexes: []string{p.primaryIndex.Name()},
+		Filters: query.Filters(),
+	}
+	descending := query.Order() == query_types.Descending
+	sortDataFiles(idxOperations, descending)
+	for _, idxOp := range idxOperations {
+		dataFile, err := p.explainDataFile(query, idxOp.GetDataFileHeader())
+		if err != nil {
+			return p.queryStopped(query, err)
+		}
+		plan.AddDataFile(dataFile)
+	}
+
+	var records uint64
+	for _, chunk := range snapshot.Chunks {
+		for _, record := range chunk {
+			timestamp := uint64(record.Timestamp.UnixNano())
+			if !query.IsBefore(timestamp) && !query.IsAfter(timestamp) {
+				records++
+			}
+		}
+	}
+	plan.AddMemTableRecords(records)
+
+	result, err := query.Result()
+	if result != nil {
+		result.Plan = plan
+	}
+	return result, err
+}
+
+// explainDataFile lists the pages of the data file with records in the time range of the query
+func (p *PersistentStorage) explainDataFile(query ports.PreparedQuery, header *domain.DataFileHeader) (*domain.DataFilePlan, error) {
+	plan := &domain.DataFilePlan{Name: header.String(), Pages: []*domain.PagePlan{}}
+	from := time.Unix(0, int64(query.FromDateTime())).UTC()
+	to := time.Unix(0, int64(query.ToDateTime())).UTC()
+	firstPage, lastPage, ok := header.DataPageRange(from, to)
+	if !ok {
+		return plan, nil
+	}
+
+	dataFileManager, err := p.dataFileManagerFactory.NewDataFileManager(header.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get data file header: %w", err)
+	}
+	defer dataFileManager.Close()
+
+	// The pages are listed from the data page directory, the headers of data files without it are read
+	pages, err := dataFileManager.DataPages(firstPage, lastPage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get data page: %w", err)
+	}
+	for _, page := range pages {
+		plan.Pages = append(plan.Pages, &domain.PagePlan{
+			Number:  page.Number,
+			Start:   header.DataPageStart(page.Number),
+			Records: page.RecordCount,
+			Bytes:   page.Size,
+		})
+	}
+	if query.Order() == query_types.Descending {
+		slices.Reverse(plan.Pages)
+	}
+	return plan, nil
+}
@@ -0,0 +1,108 @@
+package datastor
+
+import (
+	"LogDb/internal/domain"
+	"LogDb/internal/domain/query_types"
+	"LogDb/internal/internal_errors"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"testing"
+	"time"
+)
+
+// corruptPayloads flips a byte of the payload of every data page of the data file
+func corruptPayloads(t *testing.T, repo *DataFileRepository, header *domain.DataFileHeader) {
+	df, err := repo.Open(header.String())
+	require.NoError(t, err)
+	directory, err := ReadDataPageDirectory(df, repo.Codec())
+	require.NoError(t, err)
+	require.NoError(t, df.Close())
+
+	f, err := os.OpenFile(repo.GetDataFileFullPath(header.String()), os.O_RDWR, 0600)
+	require.NoError(t, err)
+	defer f.Close()
+	for number := range directory.Entries {
+		if entry := directory.Entries[number]; entry.Exists() {
+			_, err := f.WriteAt([]byte{0xff, 0xff}, int64(entry.Offset)+int64(domain.DataPageHeaderSize)+1)
+			require.NoError(t, err)
+		}
+	}
+}
+
+// TestPersistentStorage_Explain_ReadsNoPages tests that the plan of a query is built from the page directories, the
+// payloads of the data pages are neither read nor verified
+func TestPersistentStorage_Explain_ReadsNoPages(t *testing.T) {
+	storage := newTestStorage(t)
+	for _, header := range storage.headers {
+		corruptPayloads(t, storage.repo, header)
+	}
+
+	q := testQuery(query_types.Descending, 10)
+	q.Explain = true
+	prepared := storage.prepareQuery(t, q)
+	result := storage.query(t, context.Background(), prepared)
+	require.NoError(t, prepared.Error())
+	assert.Zero(t, storage.readers.decodedPages())
+	assert.Equal(t, domain.QueryUsage{}, result.Report.Usage)
+	assert.Empty(t, result.Records)
+
+	plan := result.Plan
+	require.NotNil(t, plan)
+	assert.Equal(t, uint64(testRecords), plan.EstimatedRecords)
+	require.Len(t, plan.DataFiles, len(testDays))
+	assert.Equal(t, storage.headers[len(storage.headers)-1].String(), plan.DataFiles[0].Name)
+	for _, dataFile := range plan.DataFiles {
+		require.Len(t, dataFile.Pages, testPages)
+		assert.Equal(t, uint32(testPages-1), dataFile.Pages[0].Number)
+		for _, page := range dataFile.Pages {
+			assert.Equal(t, uint64(testPageRecords), page.Records)
+			assert.Equal(t, dataFile.Pages[0].Bytes, page.Bytes)
+			assert.NotZero(t, page.Bytes)
+		}
+	}
+
+	// the query itself reads and verifies the payloads
+	prepared = storage.prepare(t, query_types.Descending, 10)
+	_, err := storage.Query(context.Background(), prepared)
+	require.NoError(t, err)
+	assert.ErrorIs(t, prepared.Error(), internal_errors.DataPagePayloadChecksumMismatch)
+	storage.requireReleased(t)
+}
+
+// TestPersistentStorage_Explain_WithoutFooter tests that the pages of data files without a page directory are listed
+// from their headers
+func TestPersistentStorage_Explain_WithoutFooter(t *testing.T) {
+	storage := newTestStorage(t)
+	explain := func() *domain.QueryPlan {
+		q := testQuery(query_types.Ascending, 0)
+		q.Explain = true
+		return storage.query(t, context.Background(), storage.prepareQuery(t, q)).Plan
+	}
+	withFooter := explain()
+
+	removeDataPageDirectory(t, storage.repo, storage.headers[0])
+
+	assert.Equal(t, withFooter.DataFiles, explain().DataFiles)
+	assert.Zero(t, storage.readers.decodedPages())
+}
+
+// TestPersistentStorage_Explain_NearEpoch tests that the time range of a query is read in nanoseconds, a bound near
+// the epoch prunes no data file
+func TestPersistentStorage_Explain_NearEpoch(t *testing.T) {
+	storage := newTestStorage(t)
+	q := testQuery(query_types.Ascending, 0)
+	q.From = time.Unix(0, 1).UTC()
+	q.Explain = true
+	plan := storage.query(t, context.Background(), storage.prepareQuery(t, q)).Plan
+	require.NotNil(t, plan)
+	assert.Equal(t, q.From, plan.From)
+	assert.Len(t, plan.DataFiles, len(testDays))
+	assert.Equal(t, uint64(testRecords), plan.EstimatedRecords)
+
+	q.Explain = false
+	result := storage.query(t, context.Background(), storage.prepareQuery(t, q))
+	assert.Equal(t, expectedMessages(query_types.Ascending), messages(result.Records))
+	storage.requireReleased(t)
+}
@@ -0,0 +1,246 @@
+package datastor
+
+import (
+	"LogDb/internal/domain"
+	"LogDb/internal/domain/query_types"
+	"LogDb/internal/ports"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ScanOptions configures the workers scanning the data files of a query
+type ScanOptions struct {
+	Workers        int  // data files read at the same time, 1 or less reads them one after the other
+	SplitDataFiles bool // the segments of a data file are read by different workers
+}
+
+// scanSegmentPages is the number of data pages of a segment of a split data file, an hour of records
+const scanSegmentPages = 60
+
+// scanBufferPages is the number of data pages a worker reads ahead of the merge stage
+const scanBufferPages = 4
+
+// scannedPage holds the records of a data page in the time range of the query, in the order of the query
+type scannedPage struct {
+	start   time.Time
+	size    uint64 // bytes of the data page
+	records []*domain.LogRecord
+	skipped int // records outside the time range
+	err     error
+}
+
+// scanTask is a data file, or a segment of it, read by a worker
+type scanTask struct {
+	header  *domain.DataFileHeader
+	pages   []domain.DataPageLocation
+	results chan *scannedPage
+	cancel  chan struct{} // closed when the query no longer needs the pages of the task
+}
+
+// dataFileScan reads the data files of a query with a pool of workers and merges their pages in the query order
+type dataFileScan struct {
+	ctx     context.Context
+	storage *PersistentStorage
+	query   ports.PreparedQuery
+	workers int
+	tasks   chan *scanTask
+	pending []*scanTask
+	stop    chan struct{}
+	wg      sync.WaitGroup
+	// satisfied is the data file whose following pages can no longer change the result
+	satisfied *domain.DataFileHeader
+}
+
+// scanDataFiles reads the data files with the workers until the query has its records or the context is done
+func (p *PersistentStorage) scanDataFiles(ctx context.Context, query ports.PreparedQuery, idxOperations []ports.IndexOperation, workers int) error {
+	scan := &dataFileScan{
+		ctx:     ctx,
+		storage: p,
+		query:   query,
+		workers: workers,
+		tasks:   make(chan *scanTask),
+		stop:    make(chan struct{}),
+	}
+	for i := 0; i < workers; i++ {
+		scan.wg.Add(1)
+		go scan.work()
+	}
+	defer scan.close()
+
+	for _, idxOp := range idxOperations {
+		header := idxOp.GetDataFileHeader()
+		if isSatisfied(query, header.Time(), header.Time().AddDate(0, 0, 1)) {
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := scan.plan(header); err != nil {
+			return err
+		}
+	}
+	for len(scan.pending) > 0 {
+		if err := scan.merge(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// plan submits the data pages of the data file the query needs in segments
+func (s *dataFileScan) plan(header *domain.DataFileHeader) error {
+	pages, err := s.dataPages(header)
+	if err != nil {
+		return err
+	}
+	if s.query.Order() == query_types.Descending {
+		slices.Reverse(pages)
+	}
+
+	task := &scanTask{header: header}
+	for _, page := range pages {
+		start := header.DataPageStart(page.Number)
+		if s.satisfied == header || isSatisfied(s.query, start, start.Add(domain.DataPageDuration)) {
+			break
+		}
+		// Counting queries take the number of records from the directory
+		if counter, ok := s.query.(ports.PageCounter); ok && counter.CountPage(start, page.RecordCount) {
+			continue
+		}
+		task.pages = append(task.pages, page)
+		if s.storage.scanOptions.SplitDataFiles && len(task.pages) == scanSegmentPages {
+			if err = s.submit(task); err != nil {
+				return err
+			}
+			task = &scanTask{header: header}
+		}
+	}
+	if len(task.pages) == 0 || s.satisfied == header {
+		return nil
+	}
+	return s.submit(task)
+}
+
+// dataPages returns the data pages with records in the time range of the query in file order
+func (s *dataFileScan) dataPages(header *domain.DataFileHeader) ([]domain.DataPageLocation, error) {
+	from := time.Unix(0, int64(s.query.FromDateTime())).UTC()
+	to := time.Unix(0, int64(s.query.ToDateTime())).UTC()
+	firstPage, lastPage, ok := header.DataPageRange(from, to)
+	if !ok {
+		return nil, nil
+	}
+	// The workers open the data file again, it counts once in the budget
+	if err := s.storage.openDataFile(s.query); err != nil {
+		return nil, err
+	}
+
+	dataFileManager, err := s.storage.dataFileManagerFactory.NewDataFileManager(header.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get data file header: %w", err)
+	}
+	defer dataFileManager.Close()
+
+	pages, err := dataFileManager.DataPages(firstPage, lastPage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get data page: %w", err)
+	}
+	return pages, nil
+}
+
+// submit passes the task to a free worker
+func (s *dataFileScan) submit(task *scanTask) error {
+	for len(s.pending) >= s.workers {
+		if err := s.merge(); err != nil {
+			return err
+		}
+	}
+	if s.satisfied == task.header {
+		return nil
+	}
+	task.results = make(chan *scannedPage, scanBufferPages)
+	task.cancel = make(chan struct{})
+	s.tasks <- task
+	s.pending = append(s.pending, task)
+	return nil
+}
+
+// merge passes the pages of the oldest pending task to the query
+func (s *dataFileScan) merge() error {
+	task := s.pending[0]
+	s.pending = s.pending[1:]
+	for page := range task.results {
+		if err := s.ctx.Err(); err != nil {
+			return err
+		}
+		if s.satisfied != task.header && (page.err != nil || !isSatisfied(s.query, page.start, page.start.Add(domain.DataPageDuration))) {
+			if err := s.storage.passDataPage(s.query, page); err != nil {
+				return err
+			}
+			continue
+		}
+		s.satisfied = task.header
+		close(task.cancel)
+		// the worker stops reading the task, the pages read in the meantime are dropped
+		for range task.results {
+		}
+	}
+	// the worker stops reading a cancelled query without passing the pages
+	return s.ctx.Err()
+}
+
+// work reads the pages of the submitted tasks until the scan is closed
+func (s *dataFileScan) work() {
+	defer s.wg.Done()
+	for task := range s.tasks {
+		s.read(task)
+	}
+}
+
+// read reads the pages of the task with a reader of its own
+func (s *dataFileScan) read(task *scanTask) {
+	defer close(task.results)
+	dataFileManager, err := s.storage.dataFileManagerFactory.NewDataFileManager(task.header.String())
+	if err != nil {
+		s.send(task, &scannedPage{err: fmt.Errorf("failed to get data file header: %w", err)})
+		return
+	}
+	// The data file is released as soon as the task is read
+	defer dataFileManager.Close()
+
+	for _, page := range task.pages {
+		dataPageHeader, err := dataFileManager.SeekDataPageAt(page)
+		if err == nil {
+			err = dataFileManager.VerifyDataPage()
+		}
+		if err != nil {
+			s.send(task, &scannedPage{err: fmt.Errorf("failed to get data page: %w", err)})
+			return
+		}
+		page := s.storage.readDataPage(s.query, task.header, dataPageHeader, dataFileManager.GetDataPageReader())
+		if !s.send(task, page) {
+			return
+		}
+	}
+}
+
+// send passes the page to the merge stage and reports whether the following pages are still needed
+func (s *dataFileScan) send(task *scanTask, page *scannedPage) bool {
+	select {
+	case task.results <- page:
+		return page.err == nil && s.ctx.Err() == nil
+	case <-task.cancel:
+	case <-s.stop:
+	case <-s.ctx.Done():
+	}
+	return false
+}
+
+// close stops the workers and waits until they released their data files
+func (s *dataFileScan) close() {
+	close(s.stop)
+	close(s.tasks)
+	s.wg.Wait()
+}
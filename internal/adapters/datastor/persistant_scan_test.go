@@ -0,0 +1,133 @@
+package datastor
+
+import (
+	"LogDb/internal/domain/query_types"
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// testScanOptions are the ways the data files of a query are read
+var testScanOptions = []ScanOptions{
+	{Workers: 1},
+	{Workers: 2},
+	{Workers: 4},
+	{Workers: 2, SplitDataFiles: true},
+	{Workers: 4, SplitDataFiles: true},
+}
+
+// TestPersistentStorage_Query_Workers tests that the workers of a scan return the records read one data file after
+// the other, in both orders
+func TestPersistentStorage_Query_Workers(t *testing.T) {
+	storage := newTestStorage(t)
+	for _, order := range []query_types.Order{query_types.Ascending, query_types.Descending} {
+		for _, options := range testScanOptions {
+			t.Run(fmt.Sprintf("%s %+v", order, options), func(t *testing.T) {
+				storage.SetScanOptions(options)
+				result := storage.query(t, context.Background(), storage.prepare(t, order, 0))
+				assert.Equal(t, expectedMessages(order), messages(result.Records))
+				assert.Equal(t, testRecords, result.Report.Hits)
+				assert.False(t, result.Report.Truncated)
+			})
+		}
+	}
+}
+
+// TestPersistentStorage_Query_Limit tests that a limit stops the scan once the following pages can no longer change
+// the result
+func TestPersistentStorage_Query_Limit(t *testing.T) {
+	storage := newTestStorage(t)
+	for _, order := range []query_types.Order{query_types.Ascending, query_types.Descending} {
+		for _, options := range testScanOptions {
+			t.Run(fmt.Sprintf("%s %+v", order, options), func(t *testing.T) {
+				storage.SetScanOptions(options)
+				decoded := storage.readers.decodedPages()
+				prepared := storage.prepare(t, order, 10)
+				result := storage.query(t, context.Background(), prepared)
+				require.NoError(t, prepared.Error())
+				assert.Equal(t, expectedMessages(order)[:10], messages(result.Records))
+				// the workers read a few pages ahead of the merge stage
+				assert.Less(t, result.Report.Usage.ScannedRecords, uint64(testRecords/3))
+				assert.Less(t, storage.readers.decodedPages()-decoded, testPages)
+			})
+		}
+	}
+}
+
+// TestPersistentStorage_Query_Cancel tests that a query cancelled while the data files are scanned stops, returns
+// the records found until then and releases the index and the data files
+func TestPersistentStorage_Query_Cancel(t *testing.T) {
+	storage := newTestStorage(t)
+	for _, options := range testScanOptions {
+		t.Run(fmt.Sprintf("%+v", options), func(t *testing.T) {
+			storage.SetScanOptions(options)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			prepared := &cancelAfter{PreparedQuery: storage.prepare(t, query_types.Ascending, 0), records: testPages, cancel: cancel}
+			result := storage.query(t, ctx, prepared)
+			require.NoError(t, prepared.Error())
+			assert.True(t, result.Report.Truncated)
+			assert.GreaterOrEqual(t, len(result.Records), testPages)
+			assert.Less(t, len(result.Records), testRecords)
+			assert.Equal(t, expectedMessages(query_types.Ascending)[:len(result.Records)], messages(result.Records))
+		})
+	}
+}
+
+// TestPersistentStorage_Query_Cancelled tests that a query whose context is done before it starts returns an empty
+// truncated result and acquires no index access
+func TestPersistentStorage_Query_Cancelled(t *testing.T) {
+	storage := newTestStorage(t)
+	for _, options := range testScanOptions {
+		t.Run(fmt.Sprintf("%+v", options), func(t *testing.T) {
+			storage.SetScanOptions(options)
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			prepared := storage.prepare(t, query_types.Ascending, 0)
+			result := storage.query(t, ctx, prepared)
+			require.ErrorIs(t, ctx.Err(), context.Canceled)
+			require.NoError(t, prepared.Error())
+			assert.True(t, result.Report.Truncated)
+			assert.Empty(t, result.Records)
+			assert.Zero(t, storage.readers.decodedPages())
+		})
+	}
+}
+
+// TestPersistentStorage_Query_Timeout tests that the timeout of a query stops the scan, returns the records found
+// until then and releases the index and the data files
+func TestPersistentStorage_Query_Timeout(t *testing.T) {
+	storage := newTestStorage(t)
+	for _, order := range []query_types.Order{query_types.Ascending, query_types.Descending} {
+		for _, options := range testScanOptions {
+			t.Run(fmt.Sprintf("%s %+v", order, options), func(t *testing.T) {
+				storage.SetScanOptions(options)
+				q := testQuery(order, 0)
+				q.Timeout = 200 * time.Millisecond
+				prepared := &sleepAfter{PreparedQuery: storage.prepareQuery(t, q), records: testPages, sleep: 2 * q.Timeout}
+				result := storage.query(t, context.Background(), prepared)
+				require.NoError(t, prepared.Error())
+				// on a slow machine the timeout may expire before the first record
+				assert.True(t, result.Report.Truncated)
+				assert.Less(t, len(result.Records), testRecords)
+				assert.Equal(t, expectedMessages(order)[:len(result.Records)], messages(result.Records))
+			})
+		}
+	}
+}
+
+// TestPersistentStorage_Query_Expired tests that a query whose timeout expires before the data files are selected
+// returns an empty truncated result
+func TestPersistentStorage_Query_Expired(t *testing.T) {
+	storage := newTestStorage(t)
+	q := testQuery(query_types.Ascending, 0)
+	q.Timeout = time.Nanosecond
+	prepared := storage.prepareQuery(t, q)
+	result := storage.query(t, context.Background(), prepared)
+	require.NoError(t, prepared.Error())
+	assert.True(t, result.Report.Truncated)
+	assert.Empty(t, result.Records)
+}
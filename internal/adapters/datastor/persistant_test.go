@@ -0,0 +1,297 @@
+package datastor
+
+import (
+	"LogDb/internal/adapters/filters"
+	"LogDb/internal/adapters/filters/label_conditions"
+	"LogDb/internal/adapters/query"
+	"LogDb/internal/adapters/schema"
+	"LogDb/internal/adapters/serializer"
+	"LogDb/internal/domain"
+	"LogDb/internal/domain/query_types"
+	"LogDb/internal/ports"
+	"context"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testDays are the days of the data files written by newTestStorage
+var testDays = []time.Time{
+	time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC),
+	time.Date(2024, 5, 3, 0, 0, 0, 0, time.UTC),
+}
+
+// testPages are the data pages of every test data file, more than a segment of a split data file
+const testPages = 150
+
+// testPageRecords are the records of every test data page, one every 20 seconds
+const testPageRecords = 3
+
+// testRecords is the number of records of the data files of the three test days
+const testRecords = 3 * testPages * testPageRecords
+
+// testStorage is a storage of real data files with an index and a MemTable counting their open handles
+type testStorage struct {
+	*PersistentStorage
+	repo    *DataFileRepository
+	index   *testIndex
+	readers *testReaderFactory
+	headers []*domain.DataFileHeader
+	schemas ports.SchemaStore
+}
+
+// newTestStorage writes a data file per test day with testPages pages of testPageRecords records
+func newTestStorage(t *testing.T) *testStorage {
+	dir := t.TempDir()
+	repo := NewDataFileRepository(dir, serializer.Default, "chunk")
+	schemas, err := schema.NewFileStore(dir + "/schemas.json")
+	require.NoError(t, err)
+	s := &testStorage{
+		repo:    repo,
+		index:   &testIndex{},
+		readers: &testReaderFactory{DataFileReaderFactory: NewDataFileManagerFactory(repo)},
+		schemas: schemas,
+	}
+	for _, day := range testDays {
+		s.headers = append(s.headers, writeTestDay(t, repo, day))
+	}
+	s.index.headers = s.headers
+	s.PersistentStorage = NewPersistentStorage(&testMemTable{}, s.readers, NewDataPageReaderFactory(repo.Codec(), domain.SmallChunks), s.index)
+	return s
+}
+
+// writeTestDay writes the data file of the day, the message of a record tells its day, minute and second
+func writeTestDay(t *testing.T, repo *DataFileRepository, day time.Time) *domain.DataFileHeader {
+	dfw, err := NewDataFileWriterFactory(repo, logrus.NewEntry(logrus.StandardLogger())).Create(uint64(day.Year()), uint64(day.Month()), uint64(day.Day()))
+	require.NoError(t, err)
+	for minute := uint32(0); minute < testPages; minute++ {
+		require.NoError(t, dfw.AppendDataPage(domain.NewDataPageHeaderForMinute(minute)))
+		for i := 0; i < testPageRecords; i++ {
+			timestamp := day.Add(time.Duration(minute)*time.Minute + time.Duration(i)*20*time.Second)
+			record := &domain.LogRecord{Timestamp: timestamp, Message: []byte(timestamp.Format("02 15:04:05"))}
+			require.NoError(t, dfw.AppendLogRecordToCurrentDataPage(record))
+		}
+	}
+	require.NoError(t, dfw.Close())
+	return dfw.Source().Header
+}
+
+// testQuery returns a query of every record of the test days in the order with the limit, 0 without limit
+func testQuery(order query_types.Order, limit int) *domain.Query {
+	q := &domain.Query{
+		QueryTimeRange: &domain.QueryTimeRange{From: testDays[0], To: testDays[len(testDays)-1].AddDate(0, 0, 1).Add(-time.Nanosecond)},
+		Operation:      query_types.Select,
+		Fields:         []string{"*"},
+		Order:          order,
+	}
+	if limit > 0 {
+		q.Limit = &limit
+	}
+	return q
+}
+
+// prepare prepares a query of every record of the test days in the order with the limit, 0 without limit
+func (s *testStorage) prepare(t *testing.T, order query_types.Order, limit int) ports.PreparedQuery {
+	return s.prepareQuery(t, testQuery(order, limit))
+}
+
+// prepareQuery prepares the query with the schemas of the storage
+func (s *testStorage) prepareQuery(t *testing.T, q *domain.Query) ports.PreparedQuery {
+	prepared, err := query.NewPreparer(filters.Factory, label_conditions.Factory, s.schemas).PrepareQuery(q)
+	require.NoError(t, err)
+	return prepared
+}
+
+// query runs the prepared query and checks that the index and the data files were released
+func (s *testStorage) query(t *testing.T, ctx context.Context, prepared ports.PreparedQuery) *domain.QueryResult {
+	result, err := s.Query(ctx, prepared)
+	require.NoError(t, err)
+	s.requireReleased(t)
+	return result
+}
+
+// requireReleased checks that every read access to the index and every data file reader was released
+func (s *testStorage) requireReleased(t *testing.T) {
+	require.Zero(t, s.index.open(), "index read access not released")
+	require.Zero(t, s.readers.open(), "data file readers not closed")
+}
+
+// messages returns the messages of the records in the order of the result
+func messages(records []*domain.LogRecord) []string {
+	result := make([]string, len(records))
+	for i, record := range records {
+		result[i] = string(record.Message)
+	}
+	return result
+}
+
+// expectedMessages returns the messages of every test record in the order
+func expectedMessages(order query_types.Order) []string {
+	var result []string
+	for _, day := range testDays {
+		for minute := 0; minute < testPages; minute++ {
+			for i := 0; i < testPageRecords; i++ {
+				result = append(result, day.Add(time.Duration(minute)*time.Minute+time.Duration(i)*20*time.Second).Format("02 15:04:05"))
+			}
+		}
+	}
+	if order == query_types.Descending {
+		for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+			result[i], result[j] = result[j], result[i]
+		}
+	}
+	return result
+}
+
+// cancelAfter cancels the context of the query once the query was passed the given number of records
+type cancelAfter struct {
+	ports.PreparedQuery
+	records int
+	cancel  context.CancelFunc
+}
+
+func (c *cancelAfter) Next(record *domain.LogRecord) error {
+	if c.records--; c.records == 0 {
+		c.cancel()
+	}
+	return c.PreparedQuery.Next(record)
+}
+
+var _ ports.MemTable = new(testMemTable)
+
+// testMemTable is an empty MemTable
+type testMemTable struct{}
+
+func (m *testMemTable) Add(*domain.LogRecord) error { return nil }
+func (m *testMemTable) AddBatch(records []*domain.LogRecord) (int, error) {
+	return len(records), nil
+}
+func (m *testMemTable) RotateChunk()          {}
+func (m *testMemTable) Flush()                {}
+func (m *testMemTable) IsFull() bool          { return false }
+func (m *testMemTable) Close() error          { return nil }
+func (m *testMemTable) FlushSequence() uint64 { return 0 }
+func (m *testMemTable) Snapshot() *domain.MemTableSnapshot {
+	return &domain.MemTableSnapshot{Chunks: [][]*domain.LogRecord{nil}}
+}
+
+var _ ports.Index = new(testIndex)
+
+// testIndex returns every data file with read access and counts the accesses not released
+type testIndex struct {
+	mu       sync.Mutex
+	headers  []*domain.DataFileHeader
+	acquired int
+}
+
+func (i *testIndex) Name() string                             { return "test" }
+func (i *testIndex) BindStorage(ports.DataStorage) error      { return nil }
+func (i *testIndex) AddDataFile(*domain.DataFileHeader) error { return nil }
+
+func (i *testIndex) GetDataFilesForRead(ctx context.Context, _ ports.PreparedQuery) ([]ports.IndexOperation, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	operations := make([]ports.IndexOperation, len(i.headers))
+	for n, header := range i.headers {
+		operations[n] = &testIndexOperation{index: i, header: header}
+		i.acquired++
+	}
+	return operations, nil
+}
+
+func (i *testIndex) open() int {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.acquired
+}
+
+// testIndexOperation is a read access to a data file of the test index
+type testIndexOperation struct {
+	index  *testIndex
+	header *domain.DataFileHeader
+}
+
+func (o *testIndexOperation) GetDataFileHeader() *domain.DataFileHeader { return o.header }
+
+func (o *testIndexOperation) GetDataFile(string) (*domain.DataFile, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
+func (o *testIndexOperation) Done() error {
+	o.index.mu.Lock()
+	defer o.index.mu.Unlock()
+	o.index.acquired--
+	return nil
+}
+
+// testReaderFactory counts the data file readers not closed and the data pages decoded
+type testReaderFactory struct {
+	ports.DataFileReaderFactory
+	mu      sync.Mutex
+	opened  int
+	decoded int
+}
+
+func (f *testReaderFactory) NewDataFileManager(fileName string) (ports.DataFileReader, error) {
+	reader, err := f.DataFileReaderFactory.NewDataFileManager(fileName)
+	if err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.opened++
+	return &testReader{DataFileReader: reader, factory: f}, nil
+}
+
+func (f *testReaderFactory) open() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.opened
+}
+
+func (f *testReaderFactory) decodedPages() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.decoded
+}
+
+// testReader is a data file reader of the test reader factory
+type testReader struct {
+	ports.DataFileReader
+	factory *testReaderFactory
+}
+
+func (r *testReader) VerifyDataPage() error {
+	r.factory.mu.Lock()
+	r.factory.decoded++
+	r.factory.mu.Unlock()
+	return r.DataFileReader.VerifyDataPage()
+}
+
+func (r *testReader) Close() error {
+	r.factory.mu.Lock()
+	r.factory.opened--
+	r.factory.mu.Unlock()
+	return r.DataFileReader.Close()
+}
+
+// sleepAfter sleeps once the query was passed the given number of records, longer than the timeout of the query
+type sleepAfter struct {
+	ports.PreparedQuery
+	records int
+	sleep   time.Duration
+}
+
+func (s *sleepAfter) Next(record *domain.LogRecord) error {
+	if s.records--; s.records == 0 {
+		time.Sleep(s.sleep)
+	}
+	return s.PreparedQuery.Next(record)
+}
@@ -52,14 +52,14 @@ func NewCursor() *Cursor {
 	}
 }
 
-// IsAfterDataPage checks if the cursor is after the given cursor
+// IsAfterDataPage checks if the time is after the minute of the cursor
 func (c *Cursor) IsAfterDataPage(t time.Time) bool {
-	return c.minuteEnd < t.Unix()
+	return c.minuteEnd <= t.Unix()
 }
 
-// IsAfterDataFile checks if the cursor is after the given cursor
+// IsAfterDataFile checks if the time is after the day of the cursor
 func (c *Cursor) IsAfterDataFile(t time.Time) bool {
-	return c.dayEnd < t.Unix()
+	return c.dayEnd <= t.Unix()
 }
 
 var _ ports.DataStorageWritable = &SequentialLogCollector{}
@@ -75,8 +75,11 @@ type SequentialLogCollector struct {
 // Close closes the data file writer
 func (s *SequentialLogCollector) Close() error {
 	if s.dfw != nil {
+		// the data file is propagated only when it is complete including the footer
+		if err := s.dfw.Close(); err != nil {
+			return err
+		}
 		s.propagator.DataFileCreated(s.dfw.Source().Header)
-		return s.dfw.Close()
 	}
 	return nil
 }
@@ -92,8 +95,12 @@ func NewSequentialLogCollector(dff ports.DataFileWriterFactory, dpf ports.DataPa
 
 // StoreLogRecord accepts a log record and writes it to the data file
 func (s *SequentialLogCollector) StoreLogRecord(record *domain.LogRecord) error {
-	//FIXME: Ensure Timestamp is UTC
-	record.Timestamp = record.Timestamp.UTC()
+	if record.Timestamp.Location() != time.UTC {
+		// the record is shared with queries reading the MemTable, it is not modified
+		utc := *record
+		utc.Timestamp = record.Timestamp.UTC()
+		record = &utc
+	}
 	if s.cursor.IsAfterDataFile(record.Timestamp) {
 		if s.dfw != nil {
 			if err := s.dfw.Close(); err != nil {
@@ -0,0 +1,120 @@
+package datastor
+
+import (
+	"LogDb/internal/adapters/serializer"
+	"LogDb/internal/domain"
+	"LogDb/internal/internal_errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// TestCursor_IsAfterDataPage tests that a record on the next minute or the next day starts a new data page or data file
+func TestCursor_IsAfterDataPage(t *testing.T) {
+	minute := time.Date(2024, 5, 1, 23, 59, 0, 0, time.UTC)
+	cursor := NewCursor().New(minute.Add(30 * time.Second))
+	tests := []struct {
+		t                   time.Time
+		afterPage, afterDay bool
+	}{
+		{minute, false, false},
+		{minute.Add(time.Minute - time.Nanosecond), false, false},
+		{minute.Add(time.Minute), true, true},
+		{minute.Add(time.Minute + time.Nanosecond), true, true},
+		{minute.Add(2 * time.Minute), true, true},
+	}
+	for _, test := range tests {
+		t.Run(test.t.Format(time.RFC3339Nano), func(t *testing.T) {
+			assert.Equal(t, test.afterPage, cursor.IsAfterDataPage(test.t), "data page")
+			assert.Equal(t, test.afterDay, cursor.IsAfterDataFile(test.t), "data file")
+		})
+	}
+
+	cursor = NewCursor().New(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC))
+	assert.False(t, cursor.IsAfterDataPage(time.Date(2024, 5, 1, 10, 0, 59, 999999999, time.UTC)))
+	assert.True(t, cursor.IsAfterDataPage(time.Date(2024, 5, 1, 10, 1, 0, 0, time.UTC)))
+	assert.False(t, cursor.IsAfterDataFile(time.Date(2024, 5, 1, 23, 59, 59, 999999999, time.UTC)))
+}
+
+// collectedDataFiles collects the data files created by a collector
+type collectedDataFiles struct {
+	headers []*domain.DataFileHeader
+}
+
+func (c *collectedDataFiles) DataFileCreated(header *domain.DataFileHeader) {
+	c.headers = append(c.headers, header)
+}
+
+func (c *collectedDataFiles) DataFileDeleted(*domain.DataFileHeader) {}
+
+// TestSequentialLogCollector_Midnight tests that records around midnight are written to the data pages of their minute
+// in the data file of their day, and that their pages are found on the minute with and without a page directory
+func TestSequentialLogCollector_Midnight(t *testing.T) {
+	repo := NewDataFileRepository(t.TempDir(), serializer.Default, "chunk")
+	created := &collectedDataFiles{}
+	collector := NewSequentialLogCollector(NewDataFileWriterFactory(repo, logrus.NewEntry(logrus.StandardLogger())), NewDataPageHeaderFactory(), created)
+	midnight := time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)
+	for _, d := range []time.Duration{
+		-61 * time.Second,                // 23:58:59
+		-time.Minute,                     // 23:59:00
+		-time.Nanosecond,                 // 23:59:59.999999999
+		0,                                // 00:00:00
+		500 * time.Millisecond,           // 00:00:00.5
+		time.Minute,                      // 00:01:00
+		time.Minute + 59*time.Second + 1, // 00:01:59.000000001
+	} {
+		require.NoError(t, collector.StoreLogRecord(&domain.LogRecord{Timestamp: midnight.Add(d), SchemaVersion: 1, Message: []byte(midnight.Add(d).Format(time.RFC3339Nano))}))
+	}
+	require.NoError(t, collector.Close())
+	require.Len(t, created.headers, 2)
+	before, after := created.headers[0], created.headers[1]
+	assert.Equal(t, midnight.AddDate(0, 0, -1), before.Time())
+	assert.Equal(t, midnight, after.Time())
+	assert.Equal(t, []uint32{1438, 1439}, []uint32{before.FirstDataPageNumber, before.LastDataPageNumber})
+	assert.Equal(t, []uint32{0, 1}, []uint32{after.FirstDataPageNumber, after.LastDataPageNumber})
+
+	// the range from 23:59 to 00:00 inclusive holds the last page of the first day and the first page of the next one
+	first, last, ok := before.DataPageRange(midnight.Add(-time.Minute), midnight)
+	require.True(t, ok)
+	assert.Equal(t, []uint32{1439, 1439}, []uint32{first, last})
+	first, last, ok = after.DataPageRange(midnight.Add(-time.Minute), midnight)
+	require.True(t, ok)
+	assert.Equal(t, []uint32{0, 0}, []uint32{first, last})
+
+	seek := func(t *testing.T, footer bool, header *domain.DataFileHeader, pages map[uint32]uint32, records map[uint32]uint64) {
+		df, err := repo.Open(header.String())
+		require.NoError(t, err)
+		defer df.Close()
+		require.Equal(t, footer, df.Header.HasDataPageDirectory())
+		reader := NewDataFileManagerFactory(repo).FromDataFile(df)
+		// the pages are sought in both directions, a page without records seeks the next one
+		for _, requested := range []uint32{header.LastDataPageNumber, 0, header.FirstDataPageNumber, header.LastDataPageNumber} {
+			pageHeader, err := reader.SeekDataPage(requested)
+			require.NoError(t, err)
+			assert.Equal(t, pages[requested], pageHeader.Number, "page %d", requested)
+		}
+		_, err = reader.SeekDataPage(header.LastDataPageNumber + 1)
+		assert.ErrorIs(t, err, internal_errors.NoDataPagesLeft)
+
+		listed, err := reader.DataPages(0, domain.MaxDataPagesInDataFile-1)
+		require.NoError(t, err)
+		counts := make(map[uint32]uint64, len(listed))
+		for _, page := range listed {
+			counts[page.Number] = page.RecordCount
+		}
+		assert.Equal(t, records, counts)
+	}
+	for _, footer := range []bool{true, false} {
+		t.Run(fmt.Sprintf("footer %t", footer), func(t *testing.T) {
+			if !footer {
+				removeDataPageDirectory(t, repo, before)
+				removeDataPageDirectory(t, repo, after)
+			}
+			seek(t, footer, before, map[uint32]uint32{0: 1438, 1438: 1438, 1439: 1439}, map[uint32]uint64{1438: 1, 1439: 2})
+			seek(t, footer, after, map[uint32]uint32{0: 0, 1: 1}, map[uint32]uint64{0: 2, 1: 2})
+		})
+	}
+}
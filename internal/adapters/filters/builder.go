@@ -37,13 +37,12 @@ func (g *GenericFilterBuilder) Not(filter ports.Filter) ports.FilterBuilder {
 		g.filter = NewNot(filter)
 		return g
 	}
-	g.filter = NewNot(filter)
+	g.filter = NewAnd(g.filter, NewNot(filter))
 	return g
 }
 
 func (g *GenericFilterBuilder) WithLabelCondition(idx int, schema uint64, condition ports.LabelCondition) ports.FilterBuilder {
-	g.filter = NewAnd(g.filter, NewLabel(idx, schema, condition))
-	return g
+	return g.And(NewLabel(idx, schema, condition))
 }
 
 func (g *GenericFilterBuilder) Contains(bytes []byte) ports.FilterBuilder {
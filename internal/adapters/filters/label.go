@@ -11,7 +11,7 @@ type LabelFilter struct {
 	condition  ports.LabelCondition
 }
 
-// IsMatch returns true if the LabelFilter's value is contained in the record's label.
+// IsMatch returns true if the record has the label and the label fits the condition.
 func (c *LabelFilter) IsMatch(record *domain.LogRecord) bool {
 	if c.schema != record.SchemaVersion {
 		return false
@@ -76,6 +76,18 @@ func (s *SingleLabelConditionBuilder) Lte(l *domain.Label) ports.LabelConditionB
 	return s
 }
 
+// Exists sets a condition where the label must be present.
+func (s *SingleLabelConditionBuilder) Exists() ports.LabelConditionBuilder {
+	s.condition = NewExists()
+	return s
+}
+
+// NotEmpty sets a condition where the label must be present and have a value.
+func (s *SingleLabelConditionBuilder) NotEmpty() ports.LabelConditionBuilder {
+	s.condition = NewNotEmpty()
+	return s
+}
+
 // Build finalizes the condition-building process and returns the constructed condition.
 // If no conditions have been added, an error is returned.
 func (s *SingleLabelConditionBuilder) Build() (ports.LabelCondition, error) {
@@ -0,0 +1,71 @@
+package label_conditions
+
+import (
+	"LogDb/internal/domain"
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strconv"
+)
+
+// compare compares the actual label with the expected one and returns -1, 0 or 1.
+// Numeric labels are compared by value even if one is an int and the other a float,
+// string labels are compared as numbers when the other side is numeric and the string parses as a number.
+// The second value is false when the labels cannot be compared.
+func compare(actual, expected *domain.Label) (int, bool) {
+	if actual.Type == domain.StringLabelType && expected.Type == domain.StringLabelType {
+		return bytes.Compare(actual.Value, expected.Value), true
+	}
+	if actual.Type == domain.IntLabelType && expected.Type == domain.IntLabelType {
+		a, ok1 := toInt(actual.Value)
+		e, ok2 := toInt(expected.Value)
+		if !ok1 || !ok2 {
+			return 0, false
+		}
+		switch {
+		case a < e:
+			return -1, true
+		case a > e:
+			return 1, true
+		}
+		return 0, true
+	}
+	a, ok1 := toFloat(actual)
+	e, ok2 := toFloat(expected)
+	if !ok1 || !ok2 {
+		return 0, false
+	}
+	switch {
+	case a < e:
+		return -1, true
+	case a > e:
+		return 1, true
+	}
+	return 0, true
+}
+
+// toInt decodes a little endian int64 label value
+func toInt(value []byte) (int64, bool) {
+	if len(value) < 8 {
+		return 0, false
+	}
+	return int64(binary.LittleEndian.Uint64(value)), true
+}
+
+// toFloat decodes a label value of any type as a float64
+func toFloat(l *domain.Label) (float64, bool) {
+	switch l.Type {
+	case domain.IntLabelType:
+		v, ok := toInt(l.Value)
+		return float64(v), ok
+	case domain.FloatLabelType:
+		if len(l.Value) < 8 {
+			return 0, false
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(l.Value)), true
+	case domain.StringLabelType:
+		v, err := strconv.ParseFloat(string(l.Value), 64)
+		return v, err == nil
+	}
+	return 0, false
+}
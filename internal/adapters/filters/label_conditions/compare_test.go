@@ -0,0 +1,101 @@
+package label_conditions
+
+import (
+	"LogDb/internal/adapters/transformers"
+	"LogDb/internal/domain"
+	"LogDb/internal/ports"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math"
+	"testing"
+)
+
+func intLabel(value int64) *domain.Label {
+	label := transformers.Int64ToLabel(value)
+	return &label
+}
+
+func floatLabel(value float64) *domain.Label {
+	label := transformers.FloatToLabel(value)
+	return &label
+}
+
+func stringLabel(value string) *domain.Label {
+	label := transformers.StringToLabel(value)
+	return &label
+}
+
+func build(t *testing.T, builder ports.LabelConditionBuilder) ports.LabelCondition {
+	condition, err := builder.Build()
+	require.NoError(t, err)
+	return condition
+}
+
+// TestCompare tests the ordering of int, float and string labels and of labels of different types
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name             string
+		actual, expected *domain.Label
+		result           int
+		ok               bool
+	}{
+		{"int less", intLabel(3), intLabel(5), -1, true},
+		{"int equal", intLabel(7), intLabel(7), 0, true},
+		{"negative int less than positive", intLabel(-5), intLabel(3), -1, true},
+		{"negative ints", intLabel(-1), intLabel(-10), 1, true},
+		{"int extremes", intLabel(math.MinInt64), intLabel(math.MaxInt64), -1, true},
+		{"large ints beyond float precision", intLabel(1<<53 + 1), intLabel(1 << 53), 1, true},
+		{"float less", floatLabel(2.5), floatLabel(10.25), -1, true},
+		{"negative float less than positive", floatLabel(-0.5), floatLabel(0.25), -1, true},
+		{"negative floats", floatLabel(-2.5), floatLabel(-10), 1, true},
+		{"negative zero", floatLabel(math.Copysign(0, -1)), floatLabel(0), 0, true},
+		{"int greater than float", intLabel(3), floatLabel(2.5), 1, true},
+		{"float equal to int", floatLabel(3), intLabel(3), 0, true},
+		{"negative int less than negative float", intLabel(-3), floatLabel(-2.5), -1, true},
+		{"string less", stringLabel("abc"), stringLabel("abd"), -1, true},
+		{"strings compared by bytes", stringLabel("10"), stringLabel("9"), -1, true},
+		{"empty string", stringLabel(""), stringLabel("a"), -1, true},
+		{"numeric string and int", stringLabel("10"), intLabel(9), 1, true},
+		{"numeric string and float", stringLabel("-1.5"), floatLabel(-1), -1, true},
+		{"int and numeric string", intLabel(-2), stringLabel("-2"), 0, true},
+		{"text and int", stringLabel("abc"), intLabel(1), 0, false},
+		{"truncated int", &domain.Label{Type: domain.IntLabelType, Value: []byte{1}, Size: 1}, intLabel(1), 0, false},
+		{"truncated float", &domain.Label{Type: domain.FloatLabelType, Value: []byte{1}, Size: 1}, floatLabel(1), 0, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, ok := compare(test.actual, test.expected)
+			assert.Equal(t, test.ok, ok)
+			assert.Equal(t, test.result, result)
+
+			lt, eq, gt := ok && result < 0, ok && result == 0, ok && result > 0
+			assert.Equal(t, eq, NewEq(test.expected).IsFit(test.actual), "=")
+			assert.Equal(t, lt, NewLt(test.expected).IsFit(test.actual), "<")
+			assert.Equal(t, gt, NewGt(test.expected).IsFit(test.actual), ">")
+			assert.Equal(t, !eq, build(t, Factory.CreateConditionBuilder(0, test.expected).Neq(test.expected)).IsFit(test.actual), "!=")
+			assert.Equal(t, lt || eq, build(t, Factory.CreateConditionBuilder(0, test.expected).Lte(test.expected)).IsFit(test.actual), "<=")
+			assert.Equal(t, gt || eq, build(t, Factory.CreateConditionBuilder(0, test.expected).Gte(test.expected)).IsFit(test.actual), ">=")
+		})
+	}
+}
+
+// TestPresenceConditions tests that exists fits any label and not empty only labels with a value
+func TestPresenceConditions(t *testing.T) {
+	tests := []struct {
+		label    *domain.Label
+		notEmpty bool
+	}{
+		{stringLabel("api"), true},
+		{stringLabel(""), false},
+		{intLabel(0), true},
+		{floatLabel(0), true},
+		{&domain.Label{Type: domain.IntLabelType}, false},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%d %q", test.label.Type, test.label.Value), func(t *testing.T) {
+			assert.True(t, NewExists().IsFit(test.label))
+			assert.Equal(t, test.notEmpty, NewNotEmpty().IsFit(test.label))
+		})
+	}
+}
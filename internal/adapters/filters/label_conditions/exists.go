@@ -0,0 +1,21 @@
+package label_conditions
+
+import (
+	"LogDb/internal/domain"
+	"LogDb/internal/ports"
+)
+
+var _ ports.LabelCondition = new(Exists)
+
+// Exists fits any label, the presence of the label is checked by the label filter, its absence (not exists) by the
+// negated label filter
+type Exists struct{}
+
+func (e *Exists) IsFit(_ *domain.Label) bool {
+	return true
+}
+
+// NewExists creates a new Exists label condition
+func NewExists() *Exists {
+	return &Exists{}
+}
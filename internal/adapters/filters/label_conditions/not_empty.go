@@ -0,0 +1,20 @@
+package label_conditions
+
+import (
+	"LogDb/internal/domain"
+	"LogDb/internal/ports"
+)
+
+var _ ports.LabelCondition = new(NotEmpty)
+
+// NotEmpty fits labels with a value
+type NotEmpty struct{}
+
+func (n *NotEmpty) IsFit(l *domain.Label) bool {
+	return len(l.Value) > 0
+}
+
+// NewNotEmpty creates a new NotEmpty label condition
+func NewNotEmpty() *NotEmpty {
+	return &NotEmpty{}
+}
@@ -0,0 +1,68 @@
+package filters
+
+import (
+	"LogDb/internal/domain"
+	"LogDb/internal/ports"
+)
+
+var _ ports.Filter = new(TextFilter)
+var _ ports.LabelCondition = new(TextCondition)
+
+// TextScope is the part of a record checked by a text filter
+type TextScope uint8
+
+const (
+	MessageOrLabels TextScope = iota // the message or any label value, as ContainsFilter
+	MessageOnly
+	LabelsOnly
+)
+
+// TextFilter matches the message and/or the label values of a record with a text matcher
+type TextFilter struct {
+	matcher ports.TextMatcher
+	scope   TextScope
+}
+
+// IsMatch returns true if the message or a label value in the scope of the filter matches.
+func (t *TextFilter) IsMatch(record *domain.LogRecord) bool {
+	if t.scope != LabelsOnly && t.matcher.Match(record.Message) {
+		return true
+	}
+	if t.scope == MessageOnly {
+		return false
+	}
+	for i := range record.Labels {
+		if t.matcher.Match(labelText(&record.Labels[i])) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewText creates a new TextFilter matching the given scope of a record.
+func NewText(matcher ports.TextMatcher, scope TextScope) *TextFilter {
+	return &TextFilter{matcher: matcher, scope: scope}
+}
+
+// TextCondition matches the value of a label with a text matcher
+type TextCondition struct {
+	matcher ports.TextMatcher
+}
+
+// IsFit returns true if the label value matches.
+func (t *TextCondition) IsFit(l *domain.Label) bool {
+	return t.matcher.Match(labelText(l))
+}
+
+// NewTextCondition creates a new TextCondition with the given matcher.
+func NewTextCondition(matcher ports.TextMatcher) *TextCondition {
+	return &TextCondition{matcher: matcher}
+}
+
+// labelText returns the value of a string label, numeric labels are matched as they are printed
+func labelText(l *domain.Label) []byte {
+	if l.Type == domain.StringLabelType {
+		return l.Value
+	}
+	return []byte(l.String())
+}
@@ -0,0 +1,97 @@
+package filters
+
+import (
+	"LogDb/internal/ports"
+	"bytes"
+	"regexp"
+	"unicode/utf8"
+)
+
+var _ ports.TextMatcher = new(ContainsMatcher)
+var _ ports.TextMatcher = new(RegexpMatcher)
+var _ ports.TextMatcher = new(ContainsFoldMatcher)
+var _ ports.TextMatcher = new(PrefixMatcher)
+var _ ports.TextMatcher = new(SuffixMatcher)
+
+// ContainsMatcher matches text containing a value
+type ContainsMatcher struct {
+	value []byte
+}
+
+// Match returns true if the text contains the value.
+func (m *ContainsMatcher) Match(text []byte) bool {
+	return bytes.Contains(text, m.value)
+}
+
+// NewContainsMatcher creates a new ContainsMatcher with the given value.
+func NewContainsMatcher(value []byte) *ContainsMatcher {
+	return &ContainsMatcher{value: value}
+}
+
+// RegexpMatcher matches text with a compiled regular expression
+type RegexpMatcher struct {
+	re *regexp.Regexp
+}
+
+// Match returns true if the regular expression matches any part of the text.
+func (m *RegexpMatcher) Match(text []byte) bool {
+	return m.re.Match(text)
+}
+
+// NewRegexpMatcher creates a new RegexpMatcher, the expression is compiled once by the caller.
+func NewRegexpMatcher(re *regexp.Regexp) *RegexpMatcher {
+	return &RegexpMatcher{re: re}
+}
+
+// ContainsFoldMatcher matches text containing a value under Unicode case folding
+type ContainsFoldMatcher struct {
+	value []byte
+}
+
+// Match returns true if the text contains the value ignoring case. Letters whose cases have a different UTF-8
+// length (e.g. the Kelvin sign) are not folded.
+func (m *ContainsFoldMatcher) Match(text []byte) bool {
+	for len(text) >= len(m.value) {
+		if bytes.EqualFold(text[:len(m.value)], m.value) {
+			return true
+		}
+		_, size := utf8.DecodeRune(text)
+		text = text[size:]
+	}
+	return false
+}
+
+// NewContainsFoldMatcher creates a new ContainsFoldMatcher with the given value.
+func NewContainsFoldMatcher(value []byte) *ContainsFoldMatcher {
+	return &ContainsFoldMatcher{value: value}
+}
+
+// PrefixMatcher matches text starting with a value
+type PrefixMatcher struct {
+	value []byte
+}
+
+// Match returns true if the text starts with the value.
+func (m *PrefixMatcher) Match(text []byte) bool {
+	return bytes.HasPrefix(text, m.value)
+}
+
+// NewPrefixMatcher creates a new PrefixMatcher with the given value.
+func NewPrefixMatcher(value []byte) *PrefixMatcher {
+	return &PrefixMatcher{value: value}
+}
+
+// SuffixMatcher matches text ending with a value
+type SuffixMatcher struct {
+	value []byte
+}
+
+// Match returns true if the text ends with the value.
+func (m *SuffixMatcher) Match(text []byte) bool {
+	return bytes.HasSuffix(text, m.value)
+}
+
+// NewSuffixMatcher creates a new SuffixMatcher with the given value.
+func NewSuffixMatcher(value []byte) *SuffixMatcher {
+	return &SuffixMatcher{value: value}
+}
@@ -0,0 +1,57 @@
+package filters
+
+import (
+	"LogDb/internal/domain"
+	"encoding/binary"
+	"github.com/stretchr/testify/require"
+	"regexp"
+	"testing"
+)
+
+func newTextRecord(message string, labels ...string) *domain.LogRecord {
+	record := domain.NewEmptyLogRecord()
+	record.Message = []byte(message)
+	for _, label := range labels {
+		record.Labels = append(record.Labels, domain.Label{Type: domain.StringLabelType, Size: uint64(len(label)), Value: []byte(label)})
+	}
+	return record
+}
+
+func TestTextMatchers(t *testing.T) {
+	require.True(t, NewRegexpMatcher(regexp.MustCompile(`^GET /api/v\d+`)).Match([]byte("GET /api/v2/users")))
+	require.False(t, NewRegexpMatcher(regexp.MustCompile(`^GET /api/v\d+`)).Match([]byte("POST /api/v2/users")))
+
+	require.True(t, NewContainsFoldMatcher([]byte("TimeOut")).Match([]byte("read timeout after 5s")))
+	require.True(t, NewContainsFoldMatcher([]byte("ÉTÉ")).Match([]byte("un été chaud")))
+	require.False(t, NewContainsFoldMatcher([]byte("timeouts")).Match([]byte("read timeout")))
+	require.True(t, NewContainsFoldMatcher(nil).Match(nil))
+
+	require.True(t, NewPrefixMatcher([]byte("/v2")).Match([]byte("/v2/users")))
+	require.False(t, NewPrefixMatcher([]byte("/v2")).Match([]byte("/api/v2")))
+	require.True(t, NewSuffixMatcher([]byte(".json")).Match([]byte("users.json")))
+	require.False(t, NewSuffixMatcher([]byte(".json")).Match([]byte("users.json.gz")))
+}
+
+// TestTextFilter_Scope tests that a text filter checks only the message, only the label values or both
+func TestTextFilter_Scope(t *testing.T) {
+	matcher := NewContainsMatcher([]byte("eu-west"))
+	inMessage := newTextRecord("moved to eu-west", "us-east")
+	inLabel := newTextRecord("moved", "eu-west")
+
+	require.True(t, NewText(matcher, MessageOrLabels).IsMatch(inMessage))
+	require.True(t, NewText(matcher, MessageOrLabels).IsMatch(inLabel))
+	require.True(t, NewText(matcher, MessageOnly).IsMatch(inMessage))
+	require.False(t, NewText(matcher, MessageOnly).IsMatch(inLabel))
+	require.False(t, NewText(matcher, LabelsOnly).IsMatch(inMessage))
+	require.True(t, NewText(matcher, LabelsOnly).IsMatch(inLabel))
+}
+
+// TestTextCondition_NumericLabel tests that numeric labels are matched as they are printed
+func TestTextCondition_NumericLabel(t *testing.T) {
+	value := make([]byte, 8)
+	binary.LittleEndian.PutUint64(value, 503)
+	label := &domain.Label{Type: domain.IntLabelType, Size: 8, Value: value}
+
+	require.True(t, NewTextCondition(NewPrefixMatcher([]byte("5"))).IsFit(label))
+	require.False(t, NewTextCondition(NewPrefixMatcher([]byte("2"))).IsFit(label))
+}
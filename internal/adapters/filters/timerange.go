@@ -5,6 +5,7 @@ import (
 )
 
 // TimeRangeFilter represents a filter that filters log records by time range.
+// The timestamps are UNIX nanoseconds.
 type TimeRangeFilter struct {
 	startTime uint64
 	endTime   uint64
@@ -31,18 +32,18 @@ func NewTimeRangeFilter(startTime uint64, endTime uint64) *TimeRangeFilter {
 
 // NewDateRangeFilter creates a new TimeRangeFilter with the given start and end times.
 func NewDateRangeFilter(startTime time.Time, endTime time.Time) *TimeRangeFilter {
-	return &TimeRangeFilter{startTime: uint64(startTime.Unix()), endTime: uint64(endTime.Unix())}
+	return &TimeRangeFilter{startTime: uint64(startTime.UnixNano()), endTime: uint64(endTime.UnixNano())}
 }
 
-// allTimeFilter is a filter that always returns true.
+// allTimeFilter is a filter without time bounds, no timestamp is before or after it.
 type allTimeFilter struct{}
 
 func (a allTimeFilter) IsBefore(timestamp uint64) bool {
-	return true
+	return false
 }
 
 func (a allTimeFilter) IsAfter(timestamp uint64) bool {
-	return true
+	return false
 }
 
 var AllTimeRange = &allTimeFilter{}
@@ -3,6 +3,7 @@ package index
 import (
 	"LogDb/internal/domain"
 	"LogDb/internal/ports"
+	"context"
 	"fmt"
 	log "github.com/sirupsen/logrus"
 	"sort"
@@ -27,35 +28,33 @@ type Timestamp struct {
 	dataCompressor ports.DataCompressor
 }
 
-func (t *Timestamp) GetDataFilesForRead(q ports.PreparedQuery) ([]ports.IndexOperation, error) {
+// GetDataFilesForRead returns the data files overlapping the time range of the query with read access, a query
+// cancelled meanwhile gets none of them
+func (t *Timestamp) GetDataFilesForRead(ctx context.Context, q ports.PreparedQuery) ([]ports.IndexOperation, error) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
-	fromDateTime := time.Unix(int64(q.FromDateTime()), 0)
-	toDateTime := time.Unix(int64(q.ToDateTime()), 0)
+	fromDateTime := time.Unix(0, int64(q.FromDateTime())).UTC()
+	toDateTime := time.Unix(0, int64(q.ToDateTime())).UTC()
 
 	var items []ports.IndexOperation
 	for _, idxItems := range t.index {
-		// TODO: optimise search for the date range
+		if err := ctx.Err(); err != nil {
+			for _, item := range items {
+				_ = item.Done()
+			}
+			return nil, err
+		}
 		for _, idxItem := range idxItems {
-			// if the data file is not in the range of the query, skip it
+			// if the data pages (minutes) of the data file are not in the range of the query, skip it
 			dfHeader := idxItem.GetHeader()
-			if dfHeader.Time().Before(fromDateTime) || dfHeader.Time().After(toDateTime) {
+			if _, _, ok := dfHeader.DataPageRange(fromDateTime, toDateTime); !ok {
 				continue
 			}
-			// This need to be check on exact datapage
-			// Check last data page number - aka minute number
-			//if dfHeader.LastDataPageNumber < uint32(q.From.Hour()*60+q.From.Minute()) {
-			//	continue
-			//}
-			// Check first data page number - aka minute number
-			// TODO: implement FirstDataPageNumber as not not in protocol
-			//if dfHeader.FirstDataPageNumber > uint32(q.To.Hour()*60+q.To.Minute()) {
-			//	continue
-			//}
 			// Request read access to the data file
 			op, err := idxItem.RequestReadAccess()
 			if err != nil {
 				log.WithError(err).Errorf("Failed to request read access to data file %s", dfHeader)
+				continue
 			}
 			items = append(items, op)
 		}
@@ -73,6 +72,11 @@ func NewTimestamp(repo ports.DataFileRepository, merger ports.Merger, dataCompre
 	}
 }
 
+// Name returns the name of the index shown in query plans
+func (t *Timestamp) Name() string {
+	return "timestamp"
+}
+
 // BindStorage binds the index to a data storage.
 func (t *Timestamp) BindStorage(storage ports.DataStorage) error {
 	t.storage = storage
@@ -175,38 +179,40 @@ func (t *Timestamp) mergeSequentially(target, source ports.IndexItem) (ports.Ind
 		return nil, err
 	}
 	// Add a new data file to the index
-	// Remove the source data file from the index
-	// Remove the target data file from the index
-	err = t.deleteDataFile(targetDataFile.Header)
-	if err != nil {
-		return nil, err
-	}
-	err = t.deleteDataFile(sourceDataFile.Header)
-	if err != nil {
-		return nil, err
+	// Remove the source and the target data files from the index
+	// The merged data file may be the target itself when the source was appended to it
+	for _, header := range []*domain.DataFileHeader{targetDataFile.Header, sourceDataFile.Header} {
+		if header.Id == mergedDataFile.Header.Id {
+			t.removeDataFile(header)
+			continue
+		}
+		if err := t.deleteDataFile(header); err != nil {
+			return nil, err
+		}
 	}
 	return t.addDataFile(mergedDataFile.Header)
 }
 
-// deleteDataFile - deletes a DataFileHeader from the index
+// deleteDataFile - deletes a DataFileHeader from the index and the data file from the repository
 func (t *Timestamp) deleteDataFile(df *domain.DataFileHeader) error {
-	if _, ok := t.index[df.Time().Format("2006-01-02")]; !ok {
+	if !t.removeDataFile(df) {
 		return nil
 	}
+	return t.repo.DeleteByHeader(df)
+}
+
+// removeDataFile - removes a DataFileHeader from the index, returns false when it is not indexed
+func (t *Timestamp) removeDataFile(df *domain.DataFileHeader) bool {
+	if _, ok := t.index[df.Time().Format("2006-01-02")]; !ok {
+		return false
+	}
 	for i, idxItem := range t.index[df.Time().Format("2006-01-02")] {
 		if idxItem.GetHeader().Id == df.Id {
-			//op, err := idxItem.AwaitWriteAccess()
-			//defer op.Done()
-			//if err != nil {
-			//	return err
-			//}
-			//t.mu.Lock()
 			t.index[df.Time().Format("2006-01-02")] = append(t.index[df.Time().Format("2006-01-02")][:i], t.index[df.Time().Format("2006-01-02")][i+1:]...)
-			//t.mu.Unlock()
-			return t.repo.DeleteByHeader(df)
+			return true
 		}
 	}
-	return nil
+	return false
 }
 
 // Compress compresses the data files in the index.
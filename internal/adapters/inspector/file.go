@@ -4,6 +4,7 @@ import (
 	"LogDb/internal/adapters/compression"
 	"LogDb/internal/domain"
 	"LogDb/internal/domain/compression_types"
+	"LogDb/internal/internal_errors"
 	"LogDb/internal/ports"
 	"bytes"
 	"encoding/hex"
@@ -16,9 +17,10 @@ import (
 )
 
 type FileConsistencyInspector struct {
-	fh     *os.File
-	codec  ports.Serializer
-	report *InspectionReport
+	fh          *os.File
+	codec       ports.Serializer
+	report      *InspectionReport
+	pageOffsets []uint64
 }
 
 func NewFileConsistencyInspector(fh *os.File, codec ports.Serializer) *FileConsistencyInspector {
@@ -45,6 +47,11 @@ func (f *FileConsistencyInspector) Inspect() error {
 		return err
 	}
 
+	err = f.InspectDataPageDirectory()
+	if err != nil {
+		return err
+	}
+
 	fmt.Println("Inspection completed successfully.")
 	return nil
 }
@@ -65,12 +72,18 @@ func (f *FileConsistencyInspector) Report() (*InspectionReport, error) {
 		return nil, err
 	}
 
+	err = f.ReportDataPageDirectory()
+	if err != nil {
+		return nil, err
+	}
+
 	// Enhanced console output in a table format
 	fmt.Println("\n=========== File Header ===========")
 	fmt.Printf(" %-20s: %d\n", "Version", f.report.Header.Version)
 	fmt.Printf(" %-20s: %d\n", "Id", f.report.Header.Id)
 	fmt.Printf(" %-20s: %d\n", "Record ScannedItems", f.report.Header.RecordCount)
 	fmt.Printf(" %-20s: %d\n", "Data Pages ScannedItems", len(f.report.DataPages))
+	fmt.Printf(" %-20s: %d\n", "Footer Offset", f.report.Header.FooterOffset)
 	fmt.Printf(" %-20s: %d\n", "Checksum", f.report.Header.Checksum)
 	fmt.Printf(" %-20s: %s\n", "Date", f.report.Header.Time().Format(time.RFC3339))
 	fmt.Println("============================================")
@@ -99,6 +112,20 @@ func (f *FileConsistencyInspector) Report() (*InspectionReport, error) {
 	// Flush the tabwriter buffer to output the table
 	writer.Flush()
 
+	if f.report.Directory != nil {
+		fmt.Println("\n=========== Data Page Directory ===========")
+		writer = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "Page Number\tOffset\tRecord Count\tMin Timestamp\tMax Timestamp\tHeader CRC32C\tPayload CRC32C")
+		for number, entry := range f.report.Directory.Entries {
+			if !entry.Exists() {
+				continue
+			}
+			checksum := f.report.Directory.Checksums[number]
+			fmt.Fprintf(writer, "%d\t%d\t%d\t%d\t%d\t%08x\t%08x\t\n", number, entry.Offset, entry.RecordCount, entry.MinTimestamp, entry.MaxTimestamp, checksum.Header, checksum.Payload)
+		}
+		writer.Flush()
+	}
+
 	fmt.Println("============================================")
 	return f.report, nil
 }
@@ -121,6 +148,9 @@ func (f *FileConsistencyInspector) InspectHeader() (*domain.DataFileHeader, erro
 		return nil, err
 	}
 	printHexdumpWithTitle("Data File Header", headerBytes, header)
+	if !header.VerifyChecksum() {
+		return nil, internal_errors.DataFileHeaderChecksumMismatch
+	}
 
 	f.report.Header = header // Save header for reporting
 	return header, nil
@@ -153,6 +183,12 @@ func (f *FileConsistencyInspector) InspectDataPages() error {
 	fmt.Println("\n========= Inspecting Data Pages and Validating Sizes =========")
 	pageNumber := 0
 	for {
+		if end, err := f.isDataEnd(); err != nil {
+			return err
+		} else if end {
+			fmt.Println("Data page directory reached.")
+			break
+		}
 		fmt.Printf("\n-- Data Page %d --\n", pageNumber)
 		err := f.InspectDataPage()
 		if err == io.EOF {
@@ -171,6 +207,12 @@ func (f *FileConsistencyInspector) InspectDataPages() error {
 func (f *FileConsistencyInspector) ReportDataPages() error {
 	pageNumber := 0
 	for {
+		if end, err := f.isDataEnd(); err != nil {
+			return err
+		} else if end {
+			fmt.Println("Data page directory reached.")
+			break
+		}
 		err := f.ReportDataPage()
 		if err == io.EOF {
 			fmt.Println("End of file reached.")
@@ -241,11 +283,114 @@ func (f *FileConsistencyInspector) ReportDataPage() error {
 	return nil
 }
 
+// InspectDataPageDirectory checks that the data page directory matches the data pages of the file
+func (f *FileConsistencyInspector) InspectDataPageDirectory() error {
+	if err := f.ReportDataPageDirectory(); err != nil {
+		return err
+	}
+	if f.report.Directory == nil {
+		fmt.Println("\nNo data page directory, the file was written before version 2.")
+		return nil
+	}
+	fmt.Println("\n========= Inspecting Data Page Directory =========")
+	var pages int
+	for _, entry := range f.report.Directory.Entries {
+		if entry.Exists() {
+			pages++
+		}
+	}
+	if pages != len(f.report.DataPages) {
+		return fmt.Errorf("data page directory mismatch: expected %d data pages, got %d", len(f.report.DataPages), pages)
+	}
+	for i, page := range f.report.DataPages {
+		entry := f.report.Directory.Entries[page.Number]
+		if entry.Offset != f.pageOffsets[i] {
+			return fmt.Errorf("data page %d offset mismatch: expected %d, got %d", page.Number, f.pageOffsets[i], entry.Offset)
+		}
+		if entry.RecordCount != page.RecordCount {
+			return fmt.Errorf("data page %d record count mismatch: expected %d, got %d", page.Number, page.RecordCount, entry.RecordCount)
+		}
+		if entry.MinTimestamp > entry.MaxTimestamp {
+			return fmt.Errorf("data page %d timestamp range is invalid: %d > %d", page.Number, entry.MinTimestamp, entry.MaxTimestamp)
+		}
+		if err := f.inspectDataPageChecksum(page, f.pageOffsets[i]); err != nil {
+			return fmt.Errorf("data page %d: %w", page.Number, err)
+		}
+	}
+	fmt.Printf("Data page directory is consistent with %d data pages.\n", pages)
+	return nil
+}
+
+// ReportDataPageDirectory reads the data page directory from the footer if the file has it
+func (f *FileConsistencyInspector) ReportDataPageDirectory() error {
+	if !f.report.Header.HasDataPageDirectory() {
+		return nil
+	}
+	footer := make([]byte, f.report.Header.FooterSize())
+	if _, err := f.fh.ReadAt(footer, int64(f.report.Header.FooterOffset)); err != nil {
+		return err
+	}
+	if f.report.Header.HasDataPageChecksums() && domain.Checksum(footer) != f.report.Header.FooterChecksum {
+		return internal_errors.DataFileFooterChecksumMismatch
+	}
+	directory := domain.NewDataPageDirectory()
+	reader := bytes.NewReader(footer)
+	if _, err := f.codec.ReadDataPageDirectory(directory, reader); err != nil {
+		return err
+	}
+	if f.report.Header.HasDataPageChecksums() {
+		if _, err := f.codec.ReadDataPageChecksums(directory, reader); err != nil {
+			return err
+		}
+	}
+	f.report.Directory = directory
+	return nil
+}
+
+// inspectDataPageChecksum compares the data page header and payload with the checksums of the directory
+func (f *FileConsistencyInspector) inspectDataPageChecksum(page *domain.DataPageHeader, offset uint64) error {
+	if !f.report.Header.HasDataPageChecksums() {
+		return nil
+	}
+	checksum := f.report.Directory.Checksums[page.Number]
+	if checksum.Header != page.Checksum() {
+		return internal_errors.DataPageHeaderChecksumMismatch
+	}
+	size := int64(page.PageSize)
+	if page.CompressionAlgorithm != compression_types.None {
+		size = int64(page.CompressedPageSize)
+	}
+	payloadChecksum, err := domain.ChecksumReader(io.NewSectionReader(f.fh, int64(offset)+int64(domain.DataPageHeaderSize), size))
+	if err != nil {
+		return err
+	}
+	if checksum.Payload != payloadChecksum {
+		return internal_errors.DataPagePayloadChecksumMismatch
+	}
+	return nil
+}
+
+// isDataEnd returns true when the data pages are read and only the footer is left
+func (f *FileConsistencyInspector) isDataEnd() (bool, error) {
+	if !f.report.Header.HasDataPageDirectory() {
+		return false, nil
+	}
+	position, err := f.fh.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return false, err
+	}
+	return uint64(position) >= f.report.Header.FooterOffset, nil
+}
+
 // InspectDataPageHeader reads and returns a data page header
 func (f *FileConsistencyInspector) InspectDataPageHeader(silent bool) (*domain.DataPageHeader, error) {
+	offset, err := f.fh.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
 	headerBytes := make([]byte, domain.DataPageHeaderSize)
 	header := &domain.DataPageHeader{}
-	_, err := f.fh.Read(headerBytes)
+	_, err = f.fh.Read(headerBytes)
 	if err != nil {
 		return nil, err
 	}
@@ -257,6 +402,7 @@ func (f *FileConsistencyInspector) InspectDataPageHeader(silent bool) (*domain.D
 	if !silent {
 		printHexdumpWithTitle("Data Page Header", headerBytes, header)
 	}
+	f.pageOffsets = append(f.pageOffsets, uint64(offset))
 	return header, nil
 }
 
@@ -314,6 +460,7 @@ func (f *FileConsistencyInspector) InspectRecord(reader io.Reader) (int64, error
 type InspectionReport struct {
 	Header    *domain.DataFileHeader
 	DataPages []*domain.DataPageHeader
+	Directory *domain.DataPageDirectory
 }
 
 // Helper function to print a titled hexdump of the data with object values
@@ -1,7 +1,9 @@
 package memtable
 
 import (
+	"LogDb/internal/internal_errors"
 	"LogDb/internal/ports"
+	"errors"
 	"sync"
 )
 
@@ -10,33 +12,46 @@ var _ ports.Flushable = &Flusher{}
 type Flusher struct {
 	wStorageFactory ports.DataStorageWritableFactory
 	wg              sync.WaitGroup
+	mu              sync.RWMutex
+	closed          bool
 }
 
 // FlushChunk Flush a chunk of log records to persistent storage.
-func (f *Flusher) FlushChunk(chunk ports.HeapChunk) error {
+// The records are persisted once FlushChunk returns without an error, the data files are closed by then.
+func (f *Flusher) FlushChunk(chunk ports.HeapChunk) (err error) {
 	// Add to f.wg to wait for all FlushChunk calls to finish before closing the flusher
+	f.mu.RLock()
+	if f.closed {
+		f.mu.RUnlock()
+		return internal_errors.FlusherClosed
+	}
 	f.wg.Add(1)
+	f.mu.RUnlock()
 	defer f.wg.Done()
 	wStorage, err := f.wStorageFactory.NewDataStorageWritable()
 	if err != nil {
 		return err
 	}
-	defer wStorage.Close()
+	defer func() {
+		err = errors.Join(err, wStorage.Close())
+	}()
 	for {
-		record, err := chunk.Pop()
-		if err != nil {
+		record, popErr := chunk.Pop()
+		if popErr != nil {
 			break
 		}
-		err = wStorage.StoreLogRecord(record)
-		if err != nil {
+		if err := wStorage.StoreLogRecord(record); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-// Close closes the data file writer
+// Close waits until the running flushes closed their data files, no chunk is flushed afterward.
 func (f *Flusher) Close() error {
+	f.mu.Lock()
+	f.closed = true
+	f.mu.Unlock()
 	f.wg.Wait()
 	return nil
 }
@@ -52,6 +52,11 @@ func (hc *HeapChunkImpl) IsImmutable() bool {
 	return hc.immutable
 }
 
+// Records returns a copy of the records in heap order, the heap is not modified.
+func (hc *HeapChunkImpl) Records() []*domain.LogRecord {
+	return append([]*domain.LogRecord(nil), hc.logs...)
+}
+
 // calculateRecordSize estimates the size of a LogRecord in bytes.
 func (hc *HeapChunkImpl) calculateRecordSize(record *domain.LogRecord) int {
 	size := 24 // Base size for Timestamp and SchemaVersion
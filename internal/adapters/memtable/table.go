@@ -2,7 +2,10 @@ package memtable
 
 import (
 	"LogDb/internal/domain"
+	"LogDb/internal/internal_errors"
 	"LogDb/internal/ports"
+	"context"
+	"errors"
 	log "github.com/sirupsen/logrus"
 	"sync"
 	"time"
@@ -17,8 +20,13 @@ type Generic struct {
 	flushable        ports.Flushable
 	lastFlushTime    time.Time
 	rwMu             sync.RWMutex
-	flushMu          sync.Mutex
+	flushMu          sync.Mutex // chunks are flushed one at a time in the order of rotation
+	queueMu          sync.Mutex
 	flushQueue       []ports.HeapChunk
+	flushing         []*domain.LogRecord // records of the chunk being flushed, visible to queries until it is flushed
+	isFlushing       bool
+	flushSequence    uint64 // number of chunks that started flushing
+	closed           bool
 }
 
 func (mt *Generic) Flush() {
@@ -27,8 +35,8 @@ func (mt *Generic) Flush() {
 
 var _ ports.MemTable = &Generic{}
 
-// NewMemTable creates a new MemTable with auto-flush routine.
-func NewMemTable(maxSize, maxRecords int, newChunk func(maxSize int, maxRecords int) ports.HeapChunk, flushable ports.Flushable, maxFlushInterval time.Duration) *Generic {
+// NewMemTable creates a new MemTable with auto-flush routine, the routine stops when the context is done.
+func NewMemTable(ctx context.Context, maxSize, maxRecords int, newChunk func(maxSize int, maxRecords int) ports.HeapChunk, flushable ports.Flushable, maxFlushInterval time.Duration) *Generic {
 	memTable := &Generic{
 		activeChunk:      newChunk(maxSize, maxRecords),
 		newChunk:         newChunk,
@@ -40,15 +48,21 @@ func NewMemTable(maxSize, maxRecords int, newChunk func(maxSize int, maxRecords
 		maxFlushInterval: maxFlushInterval,
 	}
 
-	go memTable.autoFlush()
+	go memTable.autoFlush(ctx)
 
 	return memTable
 }
 
 // autoFlush monitors the MemTable and triggers flush if no writes occur for 5 seconds.
-func (mt *Generic) autoFlush() {
+func (mt *Generic) autoFlush(ctx context.Context) {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
 	for {
-		time.Sleep(5 * time.Second)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
 		mt.rwMu.RLock()
 		if time.Since(mt.lastFlushTime) > mt.maxFlushInterval && mt.activeChunk.Size() > 0 {
 			mt.rwMu.RUnlock()
@@ -61,50 +75,86 @@ func (mt *Generic) autoFlush() {
 
 // Add inserts a LogRecord into the active chunk.
 func (mt *Generic) Add(record *domain.LogRecord) error {
+	_, err := mt.AddBatch([]*domain.LogRecord{record})
+	return err
+}
+
+// AddBatch inserts the LogRecords into the active chunk, full chunks are rotated on the way. It returns how many
+// records were inserted before an error.
+func (mt *Generic) AddBatch(records []*domain.LogRecord) (int, error) {
 	mt.rwMu.Lock()
 	defer mt.rwMu.Unlock()
 
-	if mt.activeChunk.IsFull() {
-		mt.RotateChunk()
+	if mt.closed {
+		return 0, internal_errors.MemTableClosed
 	}
-
-	return mt.activeChunk.Add(record)
+	for i, record := range records {
+		if mt.activeChunk.IsFull() {
+			mt.rotateChunk()
+		}
+		if err := mt.activeChunk.Add(record); err != nil {
+			return i, err
+		}
+	}
+	return len(records), nil
 }
 
 // RotateChunk moves the current active chunk to the flush queue and creates a new active chunk.
 func (mt *Generic) RotateChunk() {
 	mt.rwMu.Lock()
+	defer mt.rwMu.Unlock()
+	if mt.closed {
+		return
+	}
+	mt.rotateChunk()
+}
+
+// rotateChunk moves the current active chunk to the flush queue, the caller holds the write lock.
+func (mt *Generic) rotateChunk() {
 	oldChunk := mt.activeChunk
 	mt.activeChunk = mt.newChunk(mt.maxSize, mt.maxRecords)
 	mt.lastFlushTime = time.Now()
-	mt.rwMu.Unlock()
 
 	// Make the old chunk immutable and add it to the flush queue
 	oldChunk.MakeImmutable()
-	mt.flushMu.Lock()
+	mt.queueMu.Lock()
 	mt.flushQueue = append(mt.flushQueue, oldChunk)
-	mt.flushMu.Unlock()
+	mt.queueMu.Unlock()
 
 	// Trigger asynchronous flushing
 	go mt.flushChunks()
 }
 
-// flushChunks processes the flush queue asynchronously.
-func (mt *Generic) flushChunks() {
+// flushChunks processes the flush queue asynchronously and returns the errors of the chunks that failed.
+func (mt *Generic) flushChunks() error {
 	mt.flushMu.Lock()
 	defer mt.flushMu.Unlock()
-	// Update the last write time
-	mt.lastFlushTime = time.Now()
 
-	for len(mt.flushQueue) > 0 {
+	var errs []error
+	for {
+		mt.queueMu.Lock()
+		if len(mt.flushQueue) == 0 {
+			mt.queueMu.Unlock()
+			return errors.Join(errs...)
+		}
 		chunk := mt.flushQueue[0]
 		mt.flushQueue = mt.flushQueue[1:]
+		// the flushable pops the records, queries read the copy
+		mt.flushing = chunk.Records()
+		mt.isFlushing = true
+		mt.flushSequence++
+		mt.queueMu.Unlock()
 
 		if err := mt.flushable.FlushChunk(chunk); err != nil {
 			log.WithError(err).Error("Failed to flush chunk")
+			errs = append(errs, err)
 		} else {
 			log.Debug("Successfully flushed chunk")
 		}
+		mt.queueMu.Lock()
+		mt.flushing = nil
+		mt.isFlushing = false
+		mt.queueMu.Unlock()
 	}
 }
 
@@ -115,3 +165,55 @@ func (mt *Generic) IsFull() bool {
 
 	return mt.activeChunk.IsFull()
 }
+
+// Snapshot returns the records of the chunk being flushed, of the flush queue and of the active chunk.
+func (mt *Generic) Snapshot() *domain.MemTableSnapshot {
+	mt.rwMu.RLock()
+	defer mt.rwMu.RUnlock()
+	mt.queueMu.Lock()
+	defer mt.queueMu.Unlock()
+
+	snapshot := &domain.MemTableSnapshot{Sequence: mt.flushSequence}
+	if mt.isFlushing {
+		snapshot.Sequence--
+		snapshot.Chunks = append(snapshot.Chunks, mt.flushing)
+	}
+	for _, chunk := range mt.flushQueue {
+		snapshot.Chunks = append(snapshot.Chunks, chunk.Records())
+	}
+	// the active chunk of a closed MemTable is in the flush queue
+	if !mt.closed {
+		snapshot.Chunks = append(snapshot.Chunks, mt.activeChunk.Records())
+	}
+	return snapshot
+}
+
+// FlushSequence returns the number of chunks that started flushing.
+func (mt *Generic) FlushSequence() uint64 {
+	mt.queueMu.Lock()
+	defer mt.queueMu.Unlock()
+	return mt.flushSequence
+}
+
+// Close stops accepting records, flushes the active chunk and waits until every chunk is flushed
+// before the flushable is closed.
+func (mt *Generic) Close() error {
+	mt.rwMu.Lock()
+	if mt.closed {
+		mt.rwMu.Unlock()
+		return nil
+	}
+	mt.closed = true
+	oldChunk := mt.activeChunk
+	oldChunk.MakeImmutable()
+	if oldChunk.Size() > 0 {
+		mt.queueMu.Lock()
+		mt.flushQueue = append(mt.flushQueue, oldChunk)
+		mt.queueMu.Unlock()
+	}
+	mt.rwMu.Unlock()
+
+	// chunks taken by a running flush are done once the flush lock is acquired
+	err := mt.flushChunks()
+	return errors.Join(err, mt.flushable.Close())
+}
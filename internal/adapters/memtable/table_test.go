@@ -0,0 +1,108 @@
+package memtable
+
+import (
+	"LogDb/internal/domain"
+	"LogDb/internal/internal_errors"
+	"LogDb/internal/ports"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countFlusher counts the flushed records
+type countFlusher struct {
+	mu      sync.Mutex
+	records int
+	closed  bool
+}
+
+func (f *countFlusher) FlushChunk(chunk ports.HeapChunk) error {
+	// a slow flush still running when the MemTable is closed
+	time.Sleep(10 * time.Millisecond)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.records += chunk.Size()
+	return nil
+}
+
+func (f *countFlusher) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+	return nil
+}
+
+// TestGeneric_Close tests that closing the MemTable flushes every chunk before the flushable is closed
+func TestGeneric_Close(t *testing.T) {
+	flusher := &countFlusher{}
+	memTable := NewMemTable(context.Background(), 1<<20, 4, func(maxSize, maxRecords int) ports.HeapChunk {
+		return NewHeapChunk(maxSize, maxRecords)
+	}, flusher, time.Hour)
+
+	records := make([]*domain.LogRecord, 10)
+	for i := range records {
+		records[i] = &domain.LogRecord{Timestamp: time.Unix(int64(i), 0), Message: []byte("message")}
+	}
+	added, err := memTable.AddBatch(records)
+	require.NoError(t, err)
+	require.Equal(t, len(records), added)
+	require.NoError(t, memTable.Close())
+
+	assert.Equal(t, 10, flusher.records)
+	assert.True(t, flusher.closed)
+	assert.ErrorIs(t, memTable.Add(records[0]), internal_errors.MemTableClosed)
+	assert.NoError(t, memTable.Close())
+}
+
+// blockingFlusher blocks every flush until it is released
+type blockingFlusher struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (f *blockingFlusher) FlushChunk(chunk ports.HeapChunk) error {
+	f.started <- struct{}{}
+	<-f.release
+	return nil
+}
+
+func (f *blockingFlusher) Close() error {
+	return nil
+}
+
+// TestGeneric_Snapshot tests that the snapshot holds the records of the flushing, queued and active chunks
+func TestGeneric_Snapshot(t *testing.T) {
+	flusher := &blockingFlusher{started: make(chan struct{}), release: make(chan struct{})}
+	memTable := NewMemTable(context.Background(), 1<<20, 2, func(maxSize, maxRecords int) ports.HeapChunk {
+		return NewHeapChunk(maxSize, maxRecords)
+	}, flusher, time.Hour)
+
+	records := make([]*domain.LogRecord, 5)
+	for i := range records {
+		records[i] = &domain.LogRecord{Timestamp: time.Unix(int64(i), 0), Message: []byte("message")}
+	}
+	added, err := memTable.AddBatch(records)
+	require.NoError(t, err)
+	require.Equal(t, len(records), added)
+	// the first chunk is flushing, the second one waits in the queue
+	<-flusher.started
+
+	snapshot := memTable.Snapshot()
+	assert.Equal(t, uint64(0), snapshot.Sequence)
+	require.Len(t, snapshot.Chunks, 3)
+	assert.ElementsMatch(t, records[0:2], snapshot.Chunks[0])
+	assert.ElementsMatch(t, records[2:4], snapshot.Chunks[1])
+	assert.ElementsMatch(t, records[4:], snapshot.Chunks[2])
+	assert.Equal(t, 1, snapshot.Flushing(memTable.FlushSequence()))
+
+	flusher.release <- struct{}{}
+	<-flusher.started
+	assert.Equal(t, 2, snapshot.Flushing(memTable.FlushSequence()))
+	snapshot = memTable.Snapshot()
+	assert.Equal(t, uint64(1), snapshot.Sequence)
+	assert.Len(t, snapshot.Chunks, 2)
+	flusher.release <- struct{}{}
+}
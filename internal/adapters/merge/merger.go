@@ -75,26 +75,44 @@ func (m *Merger) MergeDataFiles(df1, df2 *domain.DataFile) (*domain.DataFile, er
 	if df1.Header.Time() != df2.Header.Time() {
 		return nil, internal_errors.DataFileNumberMismatch
 	}
+	// The statistics of the merged data pages are combined from both directories, offsets and checksums are taken after merging
+	directory, err := m.mergeDataPageDirectories(df1, df2)
+	if err != nil {
+		return nil, err
+	}
 	if df1.Header.FirstDataPageNumber > df2.Header.LastDataPageNumber {
 		log.Debugf("Merging by appending %s to %s", df2.Header, df1.Header)
-		return m.safeAppendDataFile(df2, df1)
+		return m.safeAppendDataFile(df2, df1, directory)
 	} else if df2.Header.FirstDataPageNumber > df1.Header.LastDataPageNumber {
 		log.Debugf("Merging by appending %s to %s", df1.Header, df2.Header)
-		return m.safeAppendDataFile(df1, df2)
+		return m.safeAppendDataFile(df1, df2, directory)
 	} else if df1.Header.FirstDataPageNumber == df2.Header.LastDataPageNumber {
 		log.Debugf("Merging by appending with last page merged %s to %s", df2.Header, df1.Header)
-		return m.safeAppendDataFileWithLastPageMerged(df2, df1)
+		return m.safeAppendDataFileWithLastPageMerged(df2, df1, directory)
 	} else if df2.Header.FirstDataPageNumber == df1.Header.LastDataPageNumber {
 		log.Debugf("Merging by appending with last page merged %s to %s", df1.Header, df2.Header)
-		return m.safeAppendDataFileWithLastPageMerged(df1, df2)
+		return m.safeAppendDataFileWithLastPageMerged(df1, df2, directory)
 	} else {
 		log.Debugf("Merging by creating a new data file %s and %s", df1.Header, df2.Header)
-		return m.mergeToANewDataFile(df1, df2)
+		return m.mergeToANewDataFile(df1, df2, directory)
 	}
 }
 
+// mergeDataPageDirectories combines record counts and timestamp ranges of the data pages of both data files.
+func (m *Merger) mergeDataPageDirectories(df1, df2 *domain.DataFile) (*domain.DataPageDirectory, error) {
+	directory := domain.NewDataPageDirectory()
+	for _, df := range []*domain.DataFile{df1, df2} {
+		dfDirectory, err := datastor.ReadDataPageDirectory(df, m.codec)
+		if err != nil {
+			return nil, err
+		}
+		directory.Merge(dfDirectory)
+	}
+	return directory, nil
+}
+
 // safeAppendDataFileWithLastPageMerged appends the data file to the target file.
-func (m *Merger) safeAppendDataFileWithLastPageMerged(target, source *domain.DataFile) (*domain.DataFile, error) {
+func (m *Merger) safeAppendDataFileWithLastPageMerged(target, source *domain.DataFile, directory *domain.DataPageDirectory) (*domain.DataFile, error) {
 	// Read the last data page from target and first data page from source
 	targetReader := m.dfReaderFactory.FromDataFile(target)
 	sourceReader := m.dfReaderFactory.FromDataFile(source)
@@ -135,24 +153,27 @@ func (m *Merger) safeAppendDataFileWithLastPageMerged(target, source *domain.Dat
 	if _, err := m.codec.WriteFileHeader(newHeader, target); err != nil {
 		return nil, err
 	}
-	// Seek to the last data page start of the last file
-	if _, err := target.File.Seek(-int64(dph2.PageSize+uint64(domain.DataPageHeaderSize)), io.SeekEnd); err != nil {
+	// Seek to the last data page start of the target file
+	if err := datastor.TruncateDataPageDirectory(target); err != nil {
+		return nil, err
+	}
+	if _, err := target.File.Seek(-int64(dph1.PageSize+uint64(domain.DataPageHeaderSize)), io.SeekEnd); err != nil {
 		return nil, err
 	}
 	// Write the merged data page
 	if _, err := io.Copy(target.File, mergedPage); err != nil {
 		return nil, err
 	}
-	// Seek df1 to the end of the first data page
+	// Seek source to the end of the first data page
 	if _, err := source.File.Seek(int64(domain.DataFileHeaderSize+domain.DataPageHeaderSize+int(dph2.PageSize)), io.SeekStart); err != nil {
 		return nil, err
 	}
-	return m.unsafeAppendDataFile(target, source)
+	return m.unsafeAppendDataFile(target, source, directory)
 }
 
 // safeAppendDataFile appends the data file to the target file.
 func (m *Merger) safeAppendDataFile(target,
-	source *domain.DataFile) (*domain.DataFile, error) {
+	source *domain.DataFile, directory *domain.DataPageDirectory) (*domain.DataFile, error) {
 
 	// seek to header end of source file
 	if _, err := source.File.Seek(int64(domain.DataFileHeaderSize), io.SeekStart); err != nil {
@@ -168,21 +189,36 @@ func (m *Merger) safeAppendDataFile(target,
 	if _, err := m.codec.WriteFileHeader(newHeader, target); err != nil {
 		return nil, err
 	}
-	// seek end of target file
+	// seek end of target data pages
+	if err := datastor.TruncateDataPageDirectory(target); err != nil {
+		return nil, err
+	}
 	if _, err := target.File.Seek(0, io.SeekEnd); err != nil {
 		return nil, err
 	}
-	return m.unsafeAppendDataFile(target, source)
+	return m.unsafeAppendDataFile(target, source, directory)
 }
 
 // unsafeAppendDataFile appends the data file to the target file.
 func (m *Merger) unsafeAppendDataFile(target,
-	source *domain.DataFile) (*domain.DataFile, error) {
+	source *domain.DataFile, directory *domain.DataPageDirectory) (*domain.DataFile, error) {
 	target.Header.LastDataPageNumber = source.Header.LastDataPageNumber
 	target.Header.RecordCount += source.Header.RecordCount
 	target.Header.UpdateChecksum()
 
-	if _, err := io.Copy(target.File, source); err != nil {
+	// the footer of the source is not copied
+	position, err := source.File.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	end, err := datastor.DataEndOffset(source)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(target.File, io.LimitReader(source, end-position)); err != nil {
+		return nil, err
+	}
+	if err := datastor.WriteDataPageDirectory(target, m.codec, directory); err != nil {
 		return nil, err
 	}
 	return target, nil
@@ -205,7 +241,7 @@ func (m *Merger) mergeDataFileHeaders(df1, df2 *domain.DataFile, id uint32) *dom
 }
 
 // mergeToANewDataFile merges two data files into a new data file.
-func (m *Merger) mergeToANewDataFile(df1, df2 *domain.DataFile) (*domain.DataFile, error) {
+func (m *Merger) mergeToANewDataFile(df1, df2 *domain.DataFile, directory *domain.DataPageDirectory) (*domain.DataFile, error) {
 	// Create a new data file header that combines df1 and df2
 	newHeader := m.mergeDataFileHeaders(df1, df2, uuid.New().ID())
 
@@ -244,6 +280,12 @@ func (m *Merger) mergeToANewDataFile(df1, df2 *domain.DataFile) (*domain.DataFil
 		}
 
 		if dfReader1CurrentDataPageHeader.Number == dfReader2CurrentDataPageHeader.Number {
+			if err := dfReader1.VerifyDataPage(); err != nil {
+				return nil, err
+			}
+			if err := dfReader2.VerifyDataPage(); err != nil {
+				return nil, err
+			}
 			dp1 := domain.NewReadOnlyDataPage(dfReader1CurrentDataPageHeader, dfReader1.GetDataPageReader())
 			dp2 := domain.NewReadOnlyDataPage(dfReader2CurrentDataPageHeader, dfReader2.GetDataPageReader())
 
@@ -296,6 +338,9 @@ func (m *Merger) mergeToANewDataFile(df1, df2 *domain.DataFile) (*domain.DataFil
 			return nil, err
 		}
 
+		if err := selectedDataFileReader.VerifyDataPage(); err != nil {
+			return nil, err
+		}
 		if _, err := io.Copy(mergedDataFile, selectedDataFileReader.GetDataPageReader()); err != nil {
 			return nil, err
 		}
@@ -307,6 +352,9 @@ func (m *Merger) mergeToANewDataFile(df1, df2 *domain.DataFile) (*domain.DataFil
 		}
 	}
 
+	if err := datastor.WriteDataPageDirectory(mergedDataFile, m.codec, directory); err != nil {
+		return nil, err
+	}
 	return mergedDataFile, nil
 }
 
@@ -0,0 +1,201 @@
+package presenters
+
+import (
+	"LogDb/internal/adapters/schema"
+	"LogDb/internal/domain"
+	"LogDb/internal/ports"
+	"time"
+)
+
+// resultDocument is a query result in the json and yaml formats, it has the shape of the search result of the web API
+type resultDocument struct {
+	Records     []*recordDocument    `json:"records" yaml:"records"`
+	Report      *reportDocument      `json:"report" yaml:"report"`
+	Aggregation *aggregationDocument `json:"aggregation,omitempty" yaml:"aggregation,omitempty"`
+	Plan        *planDocument        `json:"plan,omitempty" yaml:"plan,omitempty"`
+}
+
+// eventDocument is a line of the ndjson format, a record, a bucket, the plan or the report closing the result
+type eventDocument struct {
+	Record *recordDocument `json:"record,omitempty"`
+	Bucket *bucketDocument `json:"bucket,omitempty"`
+	Plan   *planDocument   `json:"plan,omitempty"`
+	Report *reportDocument `json:"report,omitempty"`
+}
+
+// recordDocument is a log record with the labels keyed by name
+type recordDocument struct {
+	Timestamp     time.Time         `json:"timestamp" yaml:"timestamp"`
+	TimestampNano int64             `json:"timestamp_ns" yaml:"timestamp_ns"`
+	Message       string            `json:"message" yaml:"message"`
+	StringLabels  map[string]string `json:"string_labels" yaml:"string_labels"`
+}
+
+// reportDocument is the report of the query
+type reportDocument struct {
+	TotalRecords   int            `json:"total_records" yaml:"total_records"`
+	ScannedRecords int            `json:"scanned_records" yaml:"scanned_records"`
+	TimeTaken      float64        `json:"time_taken" yaml:"time_taken"`
+	Truncated      bool           `json:"truncated,omitempty" yaml:"truncated,omitempty"`
+	Usage          *usageDocument `json:"usage" yaml:"usage"`
+}
+
+// usageDocument is what the query consumed of its budget
+type usageDocument struct {
+	ScannedBytes   uint64 `json:"scanned_bytes" yaml:"scanned_bytes"`
+	ScannedRecords uint64 `json:"scanned_records" yaml:"scanned_records"`
+	ResultBytes    uint64 `json:"result_bytes" yaml:"result_bytes"`
+	DataFiles      int    `json:"data_files" yaml:"data_files"`
+}
+
+// aggregationDocument is the aggregation of the query, the buckets are ordered by start and label values
+type aggregationDocument struct {
+	Dimension string            `json:"dimension" yaml:"dimension"`
+	GroupBy   []string          `json:"group_by,omitempty" yaml:"group_by,omitempty"`
+	Metrics   []string          `json:"metrics,omitempty" yaml:"metrics,omitempty"`
+	Total     uint64            `json:"total" yaml:"total"`
+	Buckets   []*bucketDocument `json:"buckets" yaml:"buckets"`
+}
+
+// bucketDocument is a bucket of the aggregation, a metric without values is null
+type bucketDocument struct {
+	Start   time.Time           `json:"start" yaml:"start"`
+	Labels  map[string]string   `json:"labels,omitempty" yaml:"labels,omitempty"`
+	Count   uint64              `json:"count" yaml:"count"`
+	Metrics map[string]*float64 `json:"metrics,omitempty" yaml:"metrics,omitempty"`
+}
+
+// planDocument is the plan of an explained query
+type planDocument struct {
+	From             time.Time               `json:"from" yaml:"from"`
+	To               time.Time               `json:"to" yaml:"to"`
+	Order            string                  `json:"order" yaml:"order"`
+	Indexes          []string                `json:"indexes" yaml:"indexes"`
+	Filters          []string                `json:"filters" yaml:"filters"`
+	DataFiles        []*dataFilePlanDocument `json:"data_files" yaml:"data_files"`
+	MemTableRecords  uint64                  `json:"memtable_records" yaml:"memtable_records"`
+	EstimatedRecords uint64                  `json:"estimated_records" yaml:"estimated_records"`
+}
+
+// dataFilePlanDocument is a data file of the plan and the pages the query would visit
+type dataFilePlanDocument struct {
+	Name             string              `json:"name" yaml:"name"`
+	Pages            []*pagePlanDocument `json:"pages" yaml:"pages"`
+	EstimatedRecords uint64              `json:"estimated_records" yaml:"estimated_records"`
+}
+
+// pagePlanDocument is a data page the query would visit
+type pagePlanDocument struct {
+	Number  uint32    `json:"number" yaml:"number"`
+	Start   time.Time `json:"start" yaml:"start"`
+	Records uint64    `json:"records" yaml:"records"`
+	Bytes   uint64    `json:"bytes" yaml:"bytes"`
+}
+
+// newResultDocument converts the query result, label names are resolved with the schema of every record
+func newResultDocument(schemas ports.SchemaStore, result *domain.QueryResult) *resultDocument {
+	document := &resultDocument{
+		Records: make([]*recordDocument, 0, len(result.Records)),
+		Report:  newReportDocument(result.Report),
+	}
+	for _, record := range result.Records {
+		document.Records = append(document.Records, newRecordDocument(schemas, record))
+	}
+	if result.Aggregation != nil {
+		document.Aggregation = newAggregationDocument(result.Aggregation)
+	}
+	if result.Plan != nil {
+		document.Plan = newPlanDocument(result.Plan)
+	}
+	return document
+}
+
+// newPlanDocument converts the plan of the query
+func newPlanDocument(plan *domain.QueryPlan) *planDocument {
+	document := &planDocument{
+		From:             plan.From,
+		To:               plan.To,
+		Order:            string(plan.Order),
+		Indexes:          plan.Indexes,
+		Filters:          plan.Filters,
+		DataFiles:        make([]*dataFilePlanDocument, 0, len(plan.DataFiles)),
+		MemTableRecords:  plan.MemTableRecords,
+		EstimatedRecords: plan.EstimatedRecords,
+	}
+	for _, dataFile := range plan.DataFiles {
+		pages := make([]*pagePlanDocument, 0, len(dataFile.Pages))
+		for _, page := range dataFile.Pages {
+			pages = append(pages, &pagePlanDocument{Number: page.Number, Start: page.Start, Records: page.Records, Bytes: page.Bytes})
+		}
+		document.DataFiles = append(document.DataFiles, &dataFilePlanDocument{
+			Name:             dataFile.Name,
+			Pages:            pages,
+			EstimatedRecords: dataFile.EstimatedRecords,
+		})
+	}
+	return document
+}
+
+// newReportDocument converts the report of the query
+func newReportDocument(report *domain.QueryReport) *reportDocument {
+	return &reportDocument{
+		TotalRecords:   report.Hits,
+		ScannedRecords: report.ScannedItems,
+		TimeTaken:      report.ElapsedTime.Seconds(),
+		Truncated:      report.Truncated,
+		Usage: &usageDocument{
+			ScannedBytes:   report.Usage.ScannedBytes,
+			ScannedRecords: report.Usage.ScannedRecords,
+			ResultBytes:    report.Usage.ResultBytes,
+			DataFiles:      report.Usage.DataFiles,
+		},
+	}
+}
+
+// newRecordDocument converts the record, label names are resolved with the schema of the record
+func newRecordDocument(schemas ports.SchemaStore, record *domain.LogRecord) *recordDocument {
+	labels := make(map[string]string, len(record.Labels))
+	for i, name := range schema.LabelNames(schemas, record) {
+		labels[name] = record.Labels[i].String()
+	}
+	return &recordDocument{
+		Timestamp:     record.Timestamp.UTC(),
+		TimestampNano: record.Timestamp.UnixNano(),
+		Message:       string(record.Message),
+		StringLabels:  labels,
+	}
+}
+
+// newAggregationDocument converts the aggregation, the label values are keyed by label and the metric values by metric
+func newAggregationDocument(aggregation *domain.Aggregation) *aggregationDocument {
+	document := &aggregationDocument{
+		Dimension: string(aggregation.Dimension),
+		GroupBy:   aggregation.GroupBy,
+		Total:     aggregation.Total,
+		Buckets:   make([]*bucketDocument, 0),
+	}
+	for _, metric := range aggregation.Metrics {
+		document.Metrics = append(document.Metrics, metric.String())
+	}
+	for _, bucket := range aggregation.Buckets() {
+		bucketDoc := &bucketDocument{Start: bucket.Start, Count: bucket.Count}
+		if len(aggregation.GroupBy) > 0 {
+			bucketDoc.Labels = make(map[string]string, len(aggregation.GroupBy))
+			for i, field := range aggregation.GroupBy {
+				bucketDoc.Labels[field] = bucket.Labels[i]
+			}
+		}
+		if len(bucket.Metrics) > 0 {
+			bucketDoc.Metrics = make(map[string]*float64, len(bucket.Metrics))
+			for _, metric := range bucket.Metrics {
+				if value, ok := metric.Value(); ok {
+					bucketDoc.Metrics[metric.Metric.String()] = &value
+				} else {
+					bucketDoc.Metrics[metric.Metric.String()] = nil
+				}
+			}
+		}
+		document.Buckets = append(document.Buckets, bucketDoc)
+	}
+	return document
+}
@@ -0,0 +1,39 @@
+package presenters
+
+import (
+	"LogDb/internal/domain/query_types"
+	"LogDb/internal/internal_errors"
+	"LogDb/internal/ports"
+	"fmt"
+)
+
+var _ ports.QueryResultPresenterFactory = new(QueryResultPresenters)
+
+// QueryResultPresenters provides the presenter of every result format
+type QueryResultPresenters struct {
+	presenters map[query_types.Format]ports.QueryResultPresenter
+}
+
+// NewQueryResultPresenters creates the presenters of all formats, labels are named with the schema store and
+// binary records are written with the serializer
+func NewQueryResultPresenters(schemas ports.SchemaStore, codec ports.Serializer) *QueryResultPresenters {
+	return &QueryResultPresenters{
+		presenters: map[query_types.Format]ports.QueryResultPresenter{
+			query_types.Text:   NewQueryResultPresenter(NewLogRecordRawStringPresenter(schemas)),
+			query_types.CSV:    NewCSVPresenter(schemas),
+			query_types.JSON:   NewJSONPresenter(schemas),
+			query_types.NDJSON: NewNDJSONPresenter(schemas),
+			query_types.YAML:   NewYAMLPresenter(schemas),
+			query_types.Binary: NewBinaryPresenter(codec),
+		},
+	}
+}
+
+// Presenter returns the presenter of the format
+func (f *QueryResultPresenters) Presenter(format query_types.Format) (ports.QueryResultPresenter, error) {
+	presenter, ok := f.presenters[format]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", internal_errors.UnsupportedFormat, format)
+	}
+	return presenter, nil
+}
@@ -1,38 +1,45 @@
 package presenters
 
 import (
+	"LogDb/internal/adapters/schema"
 	"LogDb/internal/domain"
+	"LogDb/internal/ports"
 	"encoding/binary"
 	"fmt"
 	"math"
 )
 
 type LogRecordRawStringPresenter struct {
+	schemas ports.SchemaStore
 }
 
 func (p *LogRecordRawStringPresenter) Present(record *domain.LogRecord) string {
-	ts := record.Timestamp.Format("2006-01-02 15:04:05")
+	ts := record.Timestamp.Format("2006-01-02 15:04:05.000000000")
 	labels := ""
+	names := schema.LabelNames(p.schemas, record)
 	for i, label := range record.Labels {
+		if i > 0 {
+			labels += ", "
+		}
 		switch label.Type {
 		case domain.StringLabelType: // String
 			labelValue := string(label.Value) // Convert bytes back to string
-			labels += fmt.Sprintf("%dl: %s", i, labelValue)
+			labels += fmt.Sprintf("%s: %s", names[i], labelValue)
 		case domain.IntLabelType: // Integer
 			labelValue := binary.LittleEndian.Uint64(label.Value) // Convert bytes back to int64
-			labels += fmt.Sprintf("%dl: %d", i, labelValue)
+			labels += fmt.Sprintf("%s: %d", names[i], int64(labelValue))
 		case domain.FloatLabelType: // Float
 			labelValue := math.Float64frombits(binary.LittleEndian.Uint64(label.Value)) // Convert bytes back to float64
-			labels += fmt.Sprintf("%dl: %f", i, labelValue)
+			labels += fmt.Sprintf("%s: %f", names[i], labelValue)
 		default:
-			labels += fmt.Sprintf("%dl: Unknown label type %+v ", i, label.Value)
+			labels += fmt.Sprintf("%s: Unknown label type %+v", names[i], label.Value)
 		}
 	}
 	message := string(record.Message)
 	return fmt.Sprintf("%s - [%s] %s\n", ts, labels, message)
 }
 
-// NewLogRecordRawStringPresenter creates a new LogRecordRawStringPresenter
-func NewLogRecordRawStringPresenter() *LogRecordRawStringPresenter {
-	return &LogRecordRawStringPresenter{}
+// NewLogRecordRawStringPresenter creates a new LogRecordRawStringPresenter resolving label names with the schema store
+func NewLogRecordRawStringPresenter(schemas ports.SchemaStore) *LogRecordRawStringPresenter {
+	return &LogRecordRawStringPresenter{schemas: schemas}
 }
@@ -2,8 +2,10 @@ package presenters
 
 import (
 	"LogDb/internal/domain"
+	"LogDb/internal/domain/query_types"
 	"LogDb/internal/ports"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -24,8 +26,13 @@ func NewQueryResultPresenter(logRecordPresenter ports.LogRecordPresenter) *Query
 
 //TODO: split part to QueryPresenter and re use it here
 
+// ContentType returns the media type of the text report
+func (p *QueryResultPresenter) ContentType() string {
+	return "text/plain; charset=utf-8"
+}
+
 // Present generates a string report for the QueryResult
-func (p *QueryResultPresenter) Present(result *domain.QueryResult) string {
+func (p *QueryResultPresenter) Present(result *domain.QueryResult) (string, error) {
 	var builder strings.Builder
 
 	// Write the Query Info
@@ -42,8 +49,8 @@ func (p *QueryResultPresenter) Present(result *domain.QueryResult) string {
 	// Write time range if present
 	if result.Query.QueryTimeRange != nil {
 		builder.WriteString(fmt.Sprintf("Time Range  : %s - %s\n",
-			result.Query.QueryTimeRange.From.Format(time.RFC3339),
-			result.Query.QueryTimeRange.To.Format(time.RFC3339)))
+			result.Query.QueryTimeRange.From.Format(time.RFC3339Nano),
+			result.Query.QueryTimeRange.To.Format(time.RFC3339Nano)))
 	}
 
 	// Write fields
@@ -57,6 +64,10 @@ func (p *QueryResultPresenter) Present(result *domain.QueryResult) string {
 		}
 	}
 
+	if result.Query.Order == query_types.Descending {
+		builder.WriteString(fmt.Sprintf("Order       : %s\n", result.Query.Order))
+	}
+
 	// Write limit if present
 	if result.Query.Limit != nil {
 		builder.WriteString(fmt.Sprintf("Limit       : %d\n", *result.Query.Limit))
@@ -65,9 +76,14 @@ func (p *QueryResultPresenter) Present(result *domain.QueryResult) string {
 	// Write aggregation if present
 	if result.Query.AggregatedBy != nil {
 		builder.WriteString(fmt.Sprintf("Aggregated By: %s\n", *result.Query.AggregatedBy))
+		if len(result.Query.GroupBy) > 0 {
+			builder.WriteString(fmt.Sprintf("Group By    : %s\n", strings.Join(result.Query.GroupBy, ", ")))
+		}
 	}
 
-	builder.WriteString(fmt.Sprintf("Format      : %s\n", result.Query.Format))
+	if result.Query.Format != query_types.DefaultFormat {
+		builder.WriteString(fmt.Sprintf("Format      : %s\n", result.Query.Format))
+	}
 	builder.WriteString("===================================\n\n")
 
 	// Write the QueryReport Info
@@ -78,8 +94,23 @@ func (p *QueryResultPresenter) Present(result *domain.QueryResult) string {
 	builder.WriteString(fmt.Sprintf("Miss        : %d\n", result.Report.Miss))
 	builder.WriteString(fmt.Sprintf("Result Records : %d\n", len(result.Records)))
 	builder.WriteString(fmt.Sprintf("Elapsed Time: %s\n", result.Report.ElapsedTime))
+	usage := result.Report.Usage
+	builder.WriteString(fmt.Sprintf("Usage       : %d data files, %d bytes and %d records scanned, %d result bytes\n",
+		usage.DataFiles, usage.ScannedBytes, usage.ScannedRecords, usage.ResultBytes))
+	if result.Report.Truncated {
+		builder.WriteString("Truncated   : the query was cancelled or timed out\n")
+	}
 	builder.WriteString("====================================\n\n")
 
+	if result.Aggregation != nil {
+		p.presentAggregation(&builder, result.Aggregation)
+		return builder.String(), nil
+	}
+	if result.Plan != nil {
+		p.presentPlan(&builder, result.Plan)
+		return builder.String(), nil
+	}
+
 	// Write the records
 	builder.WriteString("=========== Query Records ===========\n")
 	if len(result.Records) == 0 {
@@ -92,5 +123,59 @@ func (p *QueryResultPresenter) Present(result *domain.QueryResult) string {
 	}
 	builder.WriteString("=====================================\n")
 
-	return builder.String()
+	return builder.String(), nil
+}
+
+// presentPlan writes the indexes, the filters and a line per data file with its pages in the order of the query
+func (p *QueryResultPresenter) presentPlan(builder *strings.Builder, plan *domain.QueryPlan) {
+	builder.WriteString("=========== Query Plan ===========\n")
+	builder.WriteString(fmt.Sprintf("Time Range  : %s - %s, %s\n", plan.From.Format(time.RFC3339Nano), plan.To.Format(time.RFC3339Nano), plan.Order))
+	builder.WriteString(fmt.Sprintf("Indexes     : %s\n", strings.Join(plan.Indexes, ", ")))
+	if len(plan.Filters) > 0 {
+		builder.WriteString("Filters     :\n")
+		for _, filter := range plan.Filters {
+			builder.WriteString(fmt.Sprintf("  - %s\n", filter))
+		}
+	}
+	builder.WriteString(fmt.Sprintf("Data Files  : %d\n", len(plan.DataFiles)))
+	for _, dataFile := range plan.DataFiles {
+		builder.WriteString(fmt.Sprintf("  - %s: %d pages, %d records\n", dataFile.Name, len(dataFile.Pages), dataFile.EstimatedRecords))
+		for _, page := range dataFile.Pages {
+			builder.WriteString(fmt.Sprintf("      page %d %s: %d records, %d bytes\n", page.Number, page.Start.Format(time.RFC3339), page.Records, page.Bytes))
+		}
+	}
+	builder.WriteString(fmt.Sprintf("MemTable    : %d records\n", plan.MemTableRecords))
+	builder.WriteString(fmt.Sprintf("Estimated   : %d records\n", plan.EstimatedRecords))
+	builder.WriteString("==================================\n")
+}
+
+// presentAggregation writes a line per bucket with its start, the values of the grouping labels, the count and
+// the metrics, a metric without values is written as -
+func (p *QueryResultPresenter) presentAggregation(builder *strings.Builder, aggregation *domain.Aggregation) {
+	builder.WriteString("=========== Query Aggregation ===========\n")
+	buckets := aggregation.Buckets()
+	if len(buckets) == 0 {
+		builder.WriteString("No records found.\n")
+	}
+	for _, bucket := range buckets {
+		builder.WriteString(bucket.Start.Format(time.RFC3339))
+		if len(aggregation.GroupBy) > 0 {
+			groups := make([]string, len(aggregation.GroupBy))
+			for i, field := range aggregation.GroupBy {
+				groups[i] = fmt.Sprintf("%s: %s", field, bucket.Labels[i])
+			}
+			builder.WriteString(fmt.Sprintf(" [%s]", strings.Join(groups, ", ")))
+		}
+		builder.WriteString(fmt.Sprintf(" %d", bucket.Count))
+		for _, metric := range bucket.Metrics {
+			if value, ok := metric.Value(); ok {
+				builder.WriteString(fmt.Sprintf(" %s=%s", metric.Metric, strconv.FormatFloat(value, 'g', -1, 64)))
+			} else {
+				builder.WriteString(fmt.Sprintf(" %s=-", metric.Metric))
+			}
+		}
+		builder.WriteString("\n")
+	}
+	builder.WriteString(fmt.Sprintf("Total: %d\n", aggregation.Total))
+	builder.WriteString("=========================================\n")
 }
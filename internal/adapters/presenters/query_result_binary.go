@@ -0,0 +1,45 @@
+package presenters
+
+import (
+	"LogDb/internal/domain"
+	"LogDb/internal/domain/query_types"
+	"LogDb/internal/internal_errors"
+	"LogDb/internal/ports"
+	"fmt"
+	"strings"
+)
+
+var _ ports.QueryResultPresenter = new(BinaryPresenter)
+
+// BinaryPresenter presents the records of a query result in the record framing of the data files,
+// so the records can be read by the serializer without converting them
+type BinaryPresenter struct {
+	codec ports.Serializer
+}
+
+// NewBinaryPresenter creates a new BinaryPresenter writing the records with the serializer
+func NewBinaryPresenter(codec ports.Serializer) *BinaryPresenter {
+	return &BinaryPresenter{codec: codec}
+}
+
+// ContentType returns the media type of the binary records
+func (p *BinaryPresenter) ContentType() string {
+	return "application/octet-stream"
+}
+
+// Present writes the records one after the other, an aggregation or a plan has no records and can not be presented
+func (p *BinaryPresenter) Present(result *domain.QueryResult) (string, error) {
+	if result.Aggregation != nil {
+		return "", fmt.Errorf("%w: aggregation in %s", internal_errors.UnsupportedFormat, query_types.Binary)
+	}
+	if result.Plan != nil {
+		return "", fmt.Errorf("%w: plan in %s", internal_errors.UnsupportedFormat, query_types.Binary)
+	}
+	var builder strings.Builder
+	for _, record := range result.Records {
+		if _, err := p.codec.WriteLogRecord(record, &builder); err != nil {
+			return "", err
+		}
+	}
+	return builder.String(), nil
+}
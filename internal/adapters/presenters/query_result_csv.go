@@ -0,0 +1,110 @@
+package presenters
+
+import (
+	"LogDb/internal/adapters/schema"
+	"LogDb/internal/domain"
+	"LogDb/internal/domain/query_types"
+	"LogDb/internal/internal_errors"
+	"LogDb/internal/ports"
+	"encoding/csv"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var _ ports.QueryResultPresenter = new(CSVPresenter)
+
+// CSVPresenter presents the records of a query result as CSV rows, or the buckets when the result is aggregated
+type CSVPresenter struct {
+	schemas ports.SchemaStore
+}
+
+// NewCSVPresenter creates a new CSVPresenter resolving label names with the schema store
+func NewCSVPresenter(schemas ports.SchemaStore) *CSVPresenter {
+	return &CSVPresenter{schemas: schemas}
+}
+
+// ContentType returns the media type of CSV
+func (p *CSVPresenter) ContentType() string {
+	return "text/csv; charset=utf-8"
+}
+
+// Present generates the CSV of the QueryResult, the header is timestamp, message and the names of all labels
+// of the records in alphabetical order, a record without a label has an empty cell. A plan has no rows.
+func (p *CSVPresenter) Present(result *domain.QueryResult) (string, error) {
+	if result.Plan != nil {
+		return "", fmt.Errorf("%w: plan in %s", internal_errors.UnsupportedFormat, query_types.CSV)
+	}
+	var rows [][]string
+	if result.Aggregation != nil {
+		rows = p.aggregationRows(result.Aggregation)
+	} else {
+		rows = p.recordRows(result.Records)
+	}
+
+	var builder strings.Builder
+	writer := csv.NewWriter(&builder)
+	if err := writer.WriteAll(rows); err != nil {
+		return "", err
+	}
+	return builder.String(), nil
+}
+
+// recordRows creates the header and a row per record
+func (p *CSVPresenter) recordRows(records []*domain.LogRecord) [][]string {
+	recordLabels := make([]map[string]string, len(records))
+	var names []string
+	for i, record := range records {
+		recordLabels[i] = make(map[string]string, len(record.Labels))
+		for j, name := range schema.LabelNames(p.schemas, record) {
+			recordLabels[i][name] = record.Labels[j].String()
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	slices.Sort(names)
+
+	rows := make([][]string, 0, len(records)+1)
+	rows = append(rows, append([]string{"timestamp", "message"}, names...))
+	for i, record := range records {
+		row := make([]string, 0, len(names)+2)
+		row = append(row, record.Timestamp.UTC().Format(time.RFC3339Nano), string(record.Message))
+		for _, name := range names {
+			row = append(row, recordLabels[i][name])
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// aggregationRows creates the header and a row per bucket with its start, the values of the grouping labels,
+// the count and the metrics, a metric without values has an empty cell
+func (p *CSVPresenter) aggregationRows(aggregation *domain.Aggregation) [][]string {
+	header := append([]string{"start"}, aggregation.GroupBy...)
+	header = append(header, "count")
+	for _, metric := range aggregation.Metrics {
+		header = append(header, metric.String())
+	}
+
+	buckets := aggregation.Buckets()
+	rows := make([][]string, 0, len(buckets)+1)
+	rows = append(rows, header)
+	for _, bucket := range buckets {
+		row := make([]string, 0, len(header))
+		row = append(row, bucket.Start.Format(time.RFC3339))
+		row = append(row, bucket.Labels...)
+		row = append(row, strconv.FormatUint(bucket.Count, 10))
+		for _, metric := range bucket.Metrics {
+			if value, ok := metric.Value(); ok {
+				row = append(row, strconv.FormatFloat(value, 'g', -1, 64))
+			} else {
+				row = append(row, "")
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
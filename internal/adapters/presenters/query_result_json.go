@@ -0,0 +1,33 @@
+package presenters
+
+import (
+	"LogDb/internal/domain"
+	"LogDb/internal/ports"
+	"encoding/json"
+)
+
+var _ ports.QueryResultPresenter = new(JSONPresenter)
+
+// JSONPresenter presents a query result as a JSON document
+type JSONPresenter struct {
+	schemas ports.SchemaStore
+}
+
+// NewJSONPresenter creates a new JSONPresenter resolving label names with the schema store
+func NewJSONPresenter(schemas ports.SchemaStore) *JSONPresenter {
+	return &JSONPresenter{schemas: schemas}
+}
+
+// ContentType returns the media type of JSON
+func (p *JSONPresenter) ContentType() string {
+	return "application/json; charset=utf-8"
+}
+
+// Present generates the JSON document of the QueryResult
+func (p *JSONPresenter) Present(result *domain.QueryResult) (string, error) {
+	data, err := json.MarshalIndent(newResultDocument(p.schemas, result), "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
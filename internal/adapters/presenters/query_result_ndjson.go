@@ -0,0 +1,53 @@
+package presenters
+
+import (
+	"LogDb/internal/domain"
+	"LogDb/internal/ports"
+	"encoding/json"
+	"strings"
+)
+
+var _ ports.QueryResultPresenter = new(NDJSONPresenter)
+
+// NDJSONPresenter presents a query result as a JSON document per line, a line per record or bucket followed by
+// a line with the report
+type NDJSONPresenter struct {
+	schemas ports.SchemaStore
+}
+
+// NewNDJSONPresenter creates a new NDJSONPresenter resolving label names with the schema store
+func NewNDJSONPresenter(schemas ports.SchemaStore) *NDJSONPresenter {
+	return &NDJSONPresenter{schemas: schemas}
+}
+
+// ContentType returns the media type of newline delimited JSON
+func (p *NDJSONPresenter) ContentType() string {
+	return "application/x-ndjson"
+}
+
+// Present generates the lines of the QueryResult
+func (p *NDJSONPresenter) Present(result *domain.QueryResult) (string, error) {
+	var builder strings.Builder
+	encoder := json.NewEncoder(&builder)
+	for _, record := range result.Records {
+		if err := encoder.Encode(eventDocument{Record: newRecordDocument(p.schemas, record)}); err != nil {
+			return "", err
+		}
+	}
+	if result.Aggregation != nil {
+		for _, bucket := range newAggregationDocument(result.Aggregation).Buckets {
+			if err := encoder.Encode(eventDocument{Bucket: bucket}); err != nil {
+				return "", err
+			}
+		}
+	}
+	if result.Plan != nil {
+		if err := encoder.Encode(eventDocument{Plan: newPlanDocument(result.Plan)}); err != nil {
+			return "", err
+		}
+	}
+	if err := encoder.Encode(eventDocument{Report: newReportDocument(result.Report)}); err != nil {
+		return "", err
+	}
+	return builder.String(), nil
+}
@@ -0,0 +1,182 @@
+package presenters
+
+import (
+	"LogDb/internal/adapters/schema"
+	"LogDb/internal/adapters/serializer"
+	"LogDb/internal/domain"
+	"LogDb/internal/domain/query_types"
+	"LogDb/internal/internal_errors"
+	"LogDb/internal/ports"
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestResult(t *testing.T) (*schema.FileStore, *domain.QueryResult) {
+	schemas, err := schema.NewFileStore(filepath.Join(t.TempDir(), "schemas.json"))
+	require.NoError(t, err)
+	first, err := schemas.ResolveSchema([]ports.SchemaField{schema.NewField("service", domain.StringLabelType)})
+	require.NoError(t, err)
+	second, err := schemas.ResolveSchema([]ports.SchemaField{
+		schema.NewField("host", domain.StringLabelType),
+		schema.NewField("service", domain.StringLabelType),
+	})
+	require.NoError(t, err)
+
+	result := domain.NewQueryResult(&domain.Query{})
+	result.Records = []*domain.LogRecord{
+		{
+			Timestamp:     time.Date(2024, 5, 1, 10, 0, 0, 5, time.UTC),
+			SchemaVersion: first.ID(),
+			Labels:        []domain.Label{{Type: domain.StringLabelType, Value: []byte("api")}},
+			Message:       []byte("started, ready"),
+		},
+		{
+			Timestamp:     time.Date(2024, 5, 1, 10, 1, 0, 0, time.UTC),
+			SchemaVersion: second.ID(),
+			Labels: []domain.Label{
+				{Type: domain.StringLabelType, Value: []byte("node-1")},
+				{Type: domain.StringLabelType, Value: []byte("db")},
+			},
+			Message: []byte("stopped"),
+		},
+	}
+	result.Report.Hits = 2
+	return schemas, result
+}
+
+// TestCSVPresenter_Records tests that the labels of all records are columns in alphabetical order
+func TestCSVPresenter_Records(t *testing.T) {
+	schemas, result := newTestResult(t)
+
+	output, err := NewCSVPresenter(schemas).Present(result)
+
+	require.NoError(t, err)
+	assert.Equal(t, "timestamp,message,host,service\n"+
+		"2024-05-01T10:00:00.000000005Z,\"started, ready\",,api\n"+
+		"2024-05-01T10:01:00Z,stopped,node-1,db\n", output)
+}
+
+// TestCSVPresenter_Aggregation tests that a bucket is a row and a metric without values is an empty cell
+func TestCSVPresenter_Aggregation(t *testing.T) {
+	schemas, _ := newTestResult(t)
+	maxSize := query_types.Metric{Function: query_types.Max, Field: "label.size"}
+	aggregation := domain.NewAggregation(query_types.Hour, []string{"label.service"}, []query_types.Metric{maxSize})
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	aggregation.AddRecord(start.Add(time.Minute), []string{"api"}, []*domain.Label{{Type: domain.StringLabelType, Value: []byte("12")}})
+	aggregation.AddRecord(start.Add(2*time.Minute), []string{"db"}, []*domain.Label{nil})
+	result := domain.NewQueryResult(&domain.Query{})
+	result.Aggregation = aggregation
+
+	output, err := NewCSVPresenter(schemas).Present(result)
+
+	require.NoError(t, err)
+	assert.Equal(t, "start,label.service,count,max(label.size)\n"+
+		"2024-05-01T10:00:00Z,api,1,12\n"+
+		"2024-05-01T10:00:00Z,db,1,\n", output)
+}
+
+// TestJSONPresenter tests that the JSON and the YAML documents hold the same records
+func TestJSONPresenter(t *testing.T) {
+	schemas, result := newTestResult(t)
+
+	jsonOutput, err := NewJSONPresenter(schemas).Present(result)
+	require.NoError(t, err)
+	yamlOutput, err := NewYAMLPresenter(schemas).Present(result)
+	require.NoError(t, err)
+
+	var fromJSON, fromYAML resultDocument
+	require.NoError(t, json.Unmarshal([]byte(jsonOutput), &fromJSON))
+	require.NoError(t, yaml.Unmarshal([]byte(yamlOutput), &fromYAML))
+	assert.Equal(t, fromJSON, fromYAML)
+	require.Len(t, fromJSON.Records, 2)
+	assert.Equal(t, map[string]string{"host": "node-1", "service": "db"}, fromJSON.Records[1].StringLabels)
+	assert.Equal(t, 2, fromJSON.Report.TotalRecords)
+	assert.Nil(t, fromJSON.Aggregation)
+}
+
+// TestJSONPresenter_Plan tests that the plan of an explained query is presented instead of the records, the formats
+// without a document can not present it
+func TestJSONPresenter_Plan(t *testing.T) {
+	schemas, err := schema.NewFileStore(filepath.Join(t.TempDir(), "schemas.json"))
+	require.NoError(t, err)
+	result := domain.NewQueryResult(&domain.Query{Explain: true})
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	result.Plan = &domain.QueryPlan{Order: query_types.Ascending, Indexes: []string{"timestamp"}, Filters: []string{`message contains "error"`}}
+	result.Plan.AddDataFile(&domain.DataFilePlan{Name: "2024-05-01.1", Pages: []*domain.PagePlan{
+		{Number: 600, Start: start, Records: 3, Bytes: 120},
+		{Number: 601, Start: start.Add(time.Minute), Records: 2, Bytes: 80},
+	}})
+	result.Plan.AddMemTableRecords(4)
+
+	jsonOutput, err := NewJSONPresenter(schemas).Present(result)
+	require.NoError(t, err)
+	yamlOutput, err := NewYAMLPresenter(schemas).Present(result)
+	require.NoError(t, err)
+
+	var fromJSON, fromYAML resultDocument
+	require.NoError(t, json.Unmarshal([]byte(jsonOutput), &fromJSON))
+	require.NoError(t, yaml.Unmarshal([]byte(yamlOutput), &fromYAML))
+	assert.Equal(t, fromJSON, fromYAML)
+	require.NotNil(t, fromJSON.Plan)
+	assert.Equal(t, uint64(9), fromJSON.Plan.EstimatedRecords)
+	require.Len(t, fromJSON.Plan.DataFiles, 1)
+	assert.Equal(t, uint64(5), fromJSON.Plan.DataFiles[0].EstimatedRecords)
+	assert.Equal(t, uint32(601), fromJSON.Plan.DataFiles[0].Pages[1].Number)
+	assert.Empty(t, fromJSON.Records)
+
+	_, err = NewCSVPresenter(schemas).Present(result)
+	assert.ErrorIs(t, err, internal_errors.UnsupportedFormat)
+}
+
+// TestBinaryPresenter tests that the records are written in the framing of the serializer
+func TestBinaryPresenter(t *testing.T) {
+	_, result := newTestResult(t)
+	codec := serializer.Default
+
+	output, err := NewBinaryPresenter(codec).Present(result)
+	require.NoError(t, err)
+
+	reader := bytes.NewReader([]byte(output))
+	for _, expected := range result.Records {
+		meta := &domain.RecordMeta{}
+		_, err := codec.ReadLogRecordMeta(meta, reader)
+		require.NoError(t, err)
+		assert.Equal(t, expected.SchemaVersion, meta.SchemaVersion)
+		assert.Equal(t, expected.Timestamp, meta.Time())
+		for i := uint64(0); i < meta.LabelsCount; i++ {
+			label := domain.Label{}
+			_, err := codec.ReadLogLabel(&label, reader)
+			require.NoError(t, err)
+			assert.Equal(t, expected.Labels[i].Value, label.Value)
+		}
+		message := make([]byte, meta.MessageSize)
+		_, err = codec.ReadLogRecordMessage(message, reader)
+		require.NoError(t, err)
+		assert.Equal(t, expected.Message, message)
+	}
+	assert.Zero(t, reader.Len())
+
+	result.Aggregation = domain.NewAggregation(query_types.Hour, nil, nil)
+	_, err = NewBinaryPresenter(codec).Present(result)
+	assert.ErrorIs(t, err, internal_errors.UnsupportedFormat)
+}
+
+// TestQueryResultPresenters tests that every format has a presenter
+func TestQueryResultPresenters(t *testing.T) {
+	schemas, _ := newTestResult(t)
+	factory := NewQueryResultPresenters(schemas, serializer.Default)
+
+	for _, format := range query_types.Formats {
+		presenter, err := factory.Presenter(format)
+		require.NoError(t, err, format)
+		assert.NotEmpty(t, presenter.ContentType())
+	}
+	_, err := factory.Presenter("xml")
+	assert.ErrorIs(t, err, internal_errors.UnsupportedFormat)
+}
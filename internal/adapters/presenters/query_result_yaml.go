@@ -0,0 +1,33 @@
+package presenters
+
+import (
+	"LogDb/internal/domain"
+	"LogDb/internal/ports"
+	"gopkg.in/yaml.v3"
+)
+
+var _ ports.QueryResultPresenter = new(YAMLPresenter)
+
+// YAMLPresenter presents a query result as a YAML document with the structure of the JSON document
+type YAMLPresenter struct {
+	schemas ports.SchemaStore
+}
+
+// NewYAMLPresenter creates a new YAMLPresenter resolving label names with the schema store
+func NewYAMLPresenter(schemas ports.SchemaStore) *YAMLPresenter {
+	return &YAMLPresenter{schemas: schemas}
+}
+
+// ContentType returns the media type of YAML
+func (p *YAMLPresenter) ContentType() string {
+	return "application/yaml; charset=utf-8"
+}
+
+// Present generates the YAML document of the QueryResult
+func (p *YAMLPresenter) Present(result *domain.QueryResult) (string, error) {
+	data, err := yaml.Marshal(newResultDocument(p.schemas, result))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
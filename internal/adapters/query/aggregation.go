@@ -0,0 +1,106 @@
+package query
+
+import (
+	"LogDb/internal/domain"
+	"LogDb/internal/domain/query_types"
+	"LogDb/internal/internal_errors"
+	"LogDb/internal/ports"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// labelResolver finds labels of records by name, the label positions are resolved once per schema version
+type labelResolver struct {
+	schemas   ports.SchemaStore
+	names     []string
+	positions map[uint64][]int
+}
+
+// newLabelResolver creates a resolver of the label names, the fields must refer to labels
+func newLabelResolver(schemas ports.SchemaStore, fields []string, notLabel error) (*labelResolver, error) {
+	r := &labelResolver{schemas: schemas, positions: make(map[uint64][]int)}
+	for _, field := range fields {
+		if !strings.HasPrefix(field, LabelFieldPrefix) {
+			return nil, fmt.Errorf("%w: %s", notLabel, field)
+		}
+		r.names = append(r.names, strings.TrimPrefix(field, LabelFieldPrefix))
+	}
+	return r, nil
+}
+
+// labels returns the labels of the record in order of the names, nil when the record does not have the label
+func (r *labelResolver) labels(record *domain.LogRecord) []*domain.Label {
+	if len(r.names) == 0 {
+		return nil
+	}
+	positions, ok := r.positions[record.SchemaVersion]
+	if !ok {
+		positions = r.resolve(record.SchemaVersion)
+		r.positions[record.SchemaVersion] = positions
+	}
+	labels := make([]*domain.Label, len(positions))
+	for i, position := range positions {
+		if position >= 0 && position < len(record.Labels) {
+			labels[i] = &record.Labels[position]
+		}
+	}
+	return labels
+}
+
+// values returns the values of the labels of the record, a missing label has an empty value
+func (r *labelResolver) values(record *domain.LogRecord) []string {
+	labels := r.labels(record)
+	if labels == nil {
+		return nil
+	}
+	values := make([]string, len(labels))
+	for i, label := range labels {
+		if label != nil {
+			values[i] = label.String()
+		}
+	}
+	return values
+}
+
+// resolve finds the positions of the labels in the schema, records without a schema
+// address their labels by position
+func (r *labelResolver) resolve(version uint64) []int {
+	positions := make([]int, len(r.names))
+	var schema ports.Schema
+	if version >= domain.FirstSchemaVersion {
+		schema, _ = r.schemas.GetSchema(version)
+	}
+	for i, name := range r.names {
+		positions[i] = -1
+		if schema != nil {
+			if idx, ok := schema.FieldIndex(name); ok {
+				positions[i] = idx
+			}
+		} else if version < domain.FirstSchemaVersion {
+			if idx, ok := positionalLabelIndex(name); ok {
+				positions[i] = idx
+			}
+		}
+	}
+	return positions
+}
+
+// newMetricLabels creates the resolver of the labels of the metrics, count of all records has no label
+func newMetricLabels(schemas ports.SchemaStore, metrics []query_types.Metric) (*labelResolver, error) {
+	fields := make([]string, len(metrics))
+	for i, metric := range metrics {
+		switch {
+		case !slices.Contains(query_types.MetricFunctions, metric.Function):
+			return nil, fmt.Errorf("%w: %s", internal_errors.UnsupportedMetric, metric)
+		case metric.Function == query_types.Count && metric.Field == query_types.AllRecords:
+			// no label, the placeholder resolves to no position
+			fields[i] = LabelFieldPrefix
+		case metric.Function == query_types.Percentile && (metric.Percentile < 0 || metric.Percentile > 100):
+			return nil, fmt.Errorf("%w: %s", internal_errors.UnsupportedMetric, metric)
+		default:
+			fields[i] = metric.Field
+		}
+	}
+	return newLabelResolver(schemas, fields, internal_errors.UnsupportedMetric)
+}
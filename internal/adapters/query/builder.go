@@ -21,8 +21,9 @@ func NewQueryBuilder(operation query_types.Operation, database, table string) *B
 			Operation: operation,
 			Database:  database,
 			Table:     table,
-			Fields:    []string{"*"},    // default to all fields
-			Format:    query_types.JSON, // default format
+			Fields:    []string{"*"},             // default to all fields
+			Order:     query_types.Ascending,     // oldest first
+			Format:    query_types.DefaultFormat, // chosen by the client
 			QueryTimeRange: &domain.QueryTimeRange{
 				From: time.Now().UTC().Add(-24 * time.Hour),
 				To:   time.Now().UTC(),
@@ -37,6 +38,12 @@ func (qb *Builder) SelectFields(fields ...string) ports.QueryBuilder {
 	return qb
 }
 
+// SelectMetrics sets the metrics computed per aggregation bucket (optional)
+func (qb *Builder) SelectMetrics(metrics ...query_types.Metric) ports.QueryBuilder {
+	qb.query.Metrics = metrics
+	return qb
+}
+
 // SetPartition sets the partition (optional)
 func (qb *Builder) SetPartition(partition string) ports.QueryBuilder {
 	qb.query.Partition = &partition
@@ -59,18 +66,60 @@ func (qb *Builder) Limit(limit int) ports.QueryBuilder {
 	return qb
 }
 
+// OrderBy sets the order of the records by timestamp (optional)
+func (qb *Builder) OrderBy(order query_types.Order) ports.QueryBuilder {
+	qb.query.Order = order
+	return qb
+}
+
+// After continues the query after the last record of the previous page of results (optional)
+func (qb *Builder) After(cursor *domain.Cursor) ports.QueryBuilder {
+	qb.query.After = cursor
+	return qb
+}
+
+// Concurrency sets the number of workers scanning the data files, at most the workers of the storage (optional)
+func (qb *Builder) Concurrency(workers int) ports.QueryBuilder {
+	qb.query.Concurrency = workers
+	return qb
+}
+
+// Timeout sets the time limit of the query, the records found until then are returned (optional)
+func (qb *Builder) Timeout(timeout time.Duration) ports.QueryBuilder {
+	qb.query.Timeout = timeout
+	return qb
+}
+
+// Budget sets the limits of the resources of the query, at most the budget of the storage (optional)
+func (qb *Builder) Budget(budget domain.QueryBudget) ports.QueryBuilder {
+	qb.query.Budget = budget
+	return qb
+}
+
 // AggregateBy sets the aggregation dimension (optional)
 func (qb *Builder) AggregateBy(dimension query_types.Dimension) ports.QueryBuilder {
 	qb.query.AggregatedBy = &dimension
 	return qb
 }
 
+// GroupBy sets the labels grouping the aggregation (optional)
+func (qb *Builder) GroupBy(fields ...string) ports.QueryBuilder {
+	qb.query.GroupBy = fields
+	return qb
+}
+
 // SetFormat sets the output format (json, csv, etc.)
 func (qb *Builder) SetFormat(format query_types.Format) ports.QueryBuilder {
 	qb.query.Format = format
 	return qb
 }
 
+// Explain asks for the plan of the query instead of its records (optional)
+func (qb *Builder) Explain() ports.QueryBuilder {
+	qb.query.Explain = true
+	return qb
+}
+
 // SetTimeRange sets the time range for the query
 func (qb *Builder) SetTimeRange(startTime, endTime time.Time) ports.QueryBuilder {
 	qb.query.QueryTimeRange = &domain.QueryTimeRange{
@@ -0,0 +1,44 @@
+package query
+
+import (
+	"LogDb/internal/domain"
+	"LogDb/internal/domain/query_types"
+	"fmt"
+	"strings"
+)
+
+// describeConditions describes the filters compiled from the conditions of the query, the conditions were compiled
+// successfully. A label condition is checked at the position of the label in every schema having it.
+func (p *Preparer) describeConditions(q *domain.Query) []string {
+	descriptions := make([]string, 0, len(q.Conditions))
+	for _, cond := range q.Conditions {
+		description := fmt.Sprintf("%s %s", cond.Field, cond.Operator)
+		if hasValue(cond.Operator) {
+			description += fmt.Sprintf(" %q", fmt.Sprint(cond.Value))
+		}
+		switch {
+		case cond.Field == MessageField && (cond.Operator == query_types.Contains || cond.Operator == query_types.NotContains):
+			description += ": message or label values"
+		case cond.Field == MessageField:
+			description += ": message only"
+		case cond.Field == LabelsField:
+			description += ": label values only"
+		case strings.HasPrefix(cond.Field, LabelFieldPrefix):
+			description += ": " + describeLabelPositions(p.labelPositions(strings.TrimPrefix(cond.Field, LabelFieldPrefix)))
+		}
+		descriptions = append(descriptions, description)
+	}
+	return descriptions
+}
+
+// describeLabelPositions lists the schemas having the label and its position in them
+func describeLabelPositions(positions []labelPosition) string {
+	if len(positions) == 0 {
+		return "no schema has the label"
+	}
+	parts := make([]string, len(positions))
+	for i, position := range positions {
+		parts[i] = fmt.Sprintf("schema %d label %d", position.schema, position.index)
+	}
+	return strings.Join(parts, ", ")
+}
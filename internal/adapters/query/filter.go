@@ -0,0 +1,69 @@
+package query
+
+import (
+	"LogDb/internal/domain"
+	"LogDb/internal/ports"
+	"sync"
+	"sync/atomic"
+)
+
+var _ ports.Filter = &schemaFilter{}
+
+// schemaFilter is a filter of query conditions for records stored after it was prepared.
+// Label conditions are resolved with the schemas known when they are compiled, a record with a newer schema
+// compiles the conditions again.
+type schemaFilter struct {
+	preparer *Preparer
+	query    *domain.Query
+	matchers []ports.TextMatcher // compiled once, a new schema only resolves the labels again
+	mu       sync.Mutex
+	compiled atomic.Pointer[compiledFilter]
+}
+
+// compiledFilter is the filter of the conditions and the latest schema version it knows
+type compiledFilter struct {
+	filter     ports.Filter
+	lastSchema uint64
+}
+
+// newSchemaFilter compiles the conditions of the query with the current schemas
+func newSchemaFilter(p *Preparer, q *domain.Query) (*schemaFilter, error) {
+	matchers, err := compileTextMatchers(q)
+	if err != nil {
+		return nil, err
+	}
+	f := &schemaFilter{preparer: p, query: q, matchers: matchers}
+	if err := f.compile(0); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// compile builds the filter again unless it already knows the schema version
+func (f *schemaFilter) compile(schemaVersion uint64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if current := f.compiled.Load(); current != nil && current.lastSchema >= schemaVersion {
+		return nil
+	}
+	// the schemas are read first, a schema created while building is compiled on the next record using it
+	lastSchema := schemaVersion
+	for _, schema := range f.preparer.schemas.Schemas() {
+		lastSchema = max(lastSchema, schema.ID())
+	}
+	filter, err := f.preparer.buildFilterSet(f.query, f.matchers, nil)
+	if err != nil {
+		return err
+	}
+	f.compiled.Store(&compiledFilter{filter: filter, lastSchema: lastSchema})
+	return nil
+}
+
+// IsMatch checks the record with the filter compiled for its schema
+func (f *schemaFilter) IsMatch(record *domain.LogRecord) bool {
+	if record.SchemaVersion > f.compiled.Load().lastSchema {
+		// the conditions were valid when prepared, a failed build keeps the previous filter
+		_ = f.compile(record.SchemaVersion)
+	}
+	return f.compiled.Load().filter.IsMatch(record)
+}
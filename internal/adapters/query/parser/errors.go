@@ -0,0 +1,20 @@
+package parser
+
+import "fmt"
+
+// SyntaxError describes a query that cannot be parsed and where the problem is
+type SyntaxError struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+// Error returns the error message with the position of the problem
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at line %d, column %d: %s", e.Line, e.Column, e.Message)
+}
+
+// newSyntaxError creates a new SyntaxError at the position of the given token
+func newSyntaxError(t Token, format string, args ...interface{}) *SyntaxError {
+	return &SyntaxError{Line: t.Line, Column: t.Column, Message: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,197 @@
+package parser
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Lexer splits a query text into tokens keeping track of line and column numbers
+type Lexer struct {
+	input  []rune
+	pos    int
+	line   int
+	column int
+}
+
+// NewLexer creates a new Lexer for the given query text
+func NewLexer(input string) *Lexer {
+	return &Lexer{
+		input:  []rune(input),
+		line:   1,
+		column: 1,
+	}
+}
+
+// peek returns the rune at the given offset from the current position or 0 at the end of input
+func (l *Lexer) peek(offset int) rune {
+	if l.pos+offset >= len(l.input) {
+		return 0
+	}
+	return l.input[l.pos+offset]
+}
+
+// advance moves to the next rune updating the position
+func (l *Lexer) advance() rune {
+	r := l.input[l.pos]
+	l.pos++
+	if r == '\n' {
+		l.line++
+		l.column = 1
+	} else {
+		l.column++
+	}
+	return r
+}
+
+// skipWhitespace skips spaces, new lines and `--` comments
+func (l *Lexer) skipWhitespace() {
+	for l.pos < len(l.input) {
+		r := l.peek(0)
+		if unicode.IsSpace(r) {
+			l.advance()
+			continue
+		}
+		if r == '-' && l.peek(1) == '-' {
+			for l.pos < len(l.input) && l.peek(0) != '\n' {
+				l.advance()
+			}
+			continue
+		}
+		return
+	}
+}
+
+// Next returns the next token from the input
+func (l *Lexer) Next() (Token, error) {
+	l.skipWhitespace()
+	start := Token{Line: l.line, Column: l.column}
+	if l.pos >= len(l.input) {
+		start.Kind = EOF
+		return start, nil
+	}
+
+	r := l.peek(0)
+	switch {
+	case isIdentStart(r):
+		return l.scanIdent(start), nil
+	case unicode.IsDigit(r) || (r == '-' && unicode.IsDigit(l.peek(1))):
+		return l.scanNumber(start), nil
+	case r == '"' || r == '\'':
+		return l.scanString(start)
+	}
+
+	l.advance()
+	switch r {
+	case '*':
+		return l.token(start, Star, "*"), nil
+	case ',':
+		return l.token(start, Comma, ","), nil
+	case '.':
+		return l.token(start, Dot, "."), nil
+	case ';':
+		return l.token(start, Semicolon, ";"), nil
+	case '(':
+		return l.token(start, LParen, "("), nil
+	case ')':
+		return l.token(start, RParen, ")"), nil
+	case '=':
+		if l.peek(0) == '~' {
+			l.advance()
+			return l.token(start, Operator, "=~"), nil
+		}
+		return l.token(start, Operator, "="), nil
+	case '<', '>':
+		if l.peek(0) == '=' {
+			l.advance()
+			return l.token(start, Operator, string(r)+"="), nil
+		}
+		return l.token(start, Operator, string(r)), nil
+	case '!':
+		if l.peek(0) == '=' {
+			l.advance()
+			return l.token(start, Operator, "!="), nil
+		}
+		if l.peek(0) == '~' {
+			l.advance()
+			return l.token(start, Operator, "!~"), nil
+		}
+	}
+	return start, newSyntaxError(start, "unexpected character %q", r)
+}
+
+// token fills the kind and text of a token started at the given position
+func (l *Lexer) token(start Token, kind TokenKind, text string) Token {
+	start.Kind = kind
+	start.Text = text
+	return start
+}
+
+// scanIdent reads an identifier or a keyword
+func (l *Lexer) scanIdent(start Token) Token {
+	var sb strings.Builder
+	for l.pos < len(l.input) && isIdentPart(l.peek(0)) {
+		sb.WriteRune(l.advance())
+	}
+	return l.token(start, Ident, sb.String())
+}
+
+// scanNumber reads an integer or a floating point number
+func (l *Lexer) scanNumber(start Token) Token {
+	var sb strings.Builder
+	if l.peek(0) == '-' {
+		sb.WriteRune(l.advance())
+	}
+	for l.pos < len(l.input) && unicode.IsDigit(l.peek(0)) {
+		sb.WriteRune(l.advance())
+	}
+	if l.peek(0) == '.' && unicode.IsDigit(l.peek(1)) {
+		sb.WriteRune(l.advance())
+		for l.pos < len(l.input) && unicode.IsDigit(l.peek(0)) {
+			sb.WriteRune(l.advance())
+		}
+	}
+	return l.token(start, Number, sb.String())
+}
+
+// scanString reads a single or double-quoted string literal with backslash escapes
+func (l *Lexer) scanString(start Token) (Token, error) {
+	quote := l.advance()
+	var sb strings.Builder
+	for {
+		if l.pos >= len(l.input) {
+			return start, newSyntaxError(start, "unterminated string literal")
+		}
+		r := l.advance()
+		if r == quote {
+			return l.token(start, String, sb.String()), nil
+		}
+		if r != '\\' {
+			sb.WriteRune(r)
+			continue
+		}
+		if l.pos >= len(l.input) {
+			return start, newSyntaxError(start, "unterminated string literal")
+		}
+		escaped := l.advance()
+		switch escaped {
+		case 'n':
+			sb.WriteRune('\n')
+		case 't':
+			sb.WriteRune('\t')
+		case 'r':
+			sb.WriteRune('\r')
+		default:
+			sb.WriteRune(escaped)
+		}
+	}
+}
+
+// isIdentStart reports whether the rune can start an identifier
+func isIdentStart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
+
+// isIdentPart reports whether the rune can be part of an identifier
+func isIdentPart(r rune) bool {
+	return r == '_' || r == '-' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
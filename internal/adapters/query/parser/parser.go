@@ -0,0 +1,657 @@
+package parser
+
+import (
+	"LogDb/internal/adapters/query"
+	"LogDb/internal/domain"
+	"LogDb/internal/domain/query_types"
+	"LogDb/internal/ports"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var _ ports.QueryParser = (*Parser)(nil)
+
+// TimestampField is the field name used to restrict the time range of a query
+const TimestampField = "timestamp"
+
+// unboundedFrom and unboundedTo are the open side of a time range restricted on one side only, the range holds every
+// timestamp of the records, UNIX nanoseconds
+var (
+	unboundedFrom = time.Unix(0, 0).UTC()
+	unboundedTo   = time.Unix(0, math.MaxInt64).UTC()
+)
+
+// Parser turns the textual query language described in docs/query.md into a domain.Query
+//
+//	select [{fields and metrics}] from {database}.{table} [partition {name}]
+//	  [where {field} {operator} [{value}] [and ...]] [order by timestamp [asc|desc]] [limit {n}]
+//	  [aggregated by {dimension} [group by {label}, ...]] [format {format}] [;]
+//	scan [from] {database}.{table} ...
+//	explain {select or scan statement}
+type Parser struct{}
+
+// NewParser creates a new Parser
+func NewParser() *Parser {
+	return &Parser{}
+}
+
+// Parse parses a single query statement
+func (p *Parser) Parse(text string) (*domain.Query, error) {
+	s := &state{lexer: NewLexer(text)}
+	if err := s.next(); err != nil {
+		return nil, err
+	}
+	return s.parseStatement()
+}
+
+// state holds the parsing progress of a single statement
+type state struct {
+	lexer   *Lexer
+	current Token
+	from    *time.Time
+	to      *time.Time
+	metric  *Token // first metric of the selected fields
+}
+
+// next moves to the next token
+func (s *state) next() error {
+	t, err := s.lexer.Next()
+	if err != nil {
+		return err
+	}
+	s.current = t
+	return nil
+}
+
+// isKeyword reports whether the current token is the given keyword (case-insensitive)
+func (s *state) isKeyword(keyword string) bool {
+	return s.current.Kind == Ident && strings.EqualFold(s.current.Text, keyword)
+}
+
+// expectKeyword consumes the given keyword or fails
+func (s *state) expectKeyword(keyword string) error {
+	if !s.isKeyword(keyword) {
+		return newSyntaxError(s.current, "expected '%s', found %s", keyword, s.current)
+	}
+	return s.next()
+}
+
+// expect consumes a token of the given kind or fails
+func (s *state) expect(kind TokenKind) (Token, error) {
+	t := s.current
+	if t.Kind != kind {
+		return t, newSyntaxError(t, "expected %s, found %s", kind, t)
+	}
+	return t, s.next()
+}
+
+// parseStatement parses a whole statement, explain asks for the plan of the statement following it
+func (s *state) parseStatement() (*domain.Query, error) {
+	explain := s.isKeyword("explain")
+	if explain {
+		if err := s.next(); err != nil {
+			return nil, err
+		}
+	}
+	operation, err := s.parseOperation()
+	if err != nil {
+		return nil, err
+	}
+	// scan always reads all fields and may omit the from keyword (scan audit.logs)
+	fields := []string{"*"}
+	var metrics []query_types.Metric
+	if operation == query_types.Scan {
+		if s.isKeyword("from") {
+			if err := s.next(); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		if fields, metrics, err = s.parseFields(); err != nil {
+			return nil, err
+		}
+		if err := s.expectKeyword("from"); err != nil {
+			return nil, err
+		}
+	}
+	database, err := s.expect(Ident)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.expect(Dot); err != nil {
+		return nil, err
+	}
+	table, err := s.expect(Ident)
+	if err != nil {
+		return nil, err
+	}
+
+	qb := query.NewQueryBuilder(operation, database.Text, table.Text)
+	qb.SelectFields(fields...)
+	qb.SelectMetrics(metrics...)
+	if explain {
+		qb.Explain()
+	}
+	if err := s.parseClauses(qb); err != nil {
+		return nil, err
+	}
+	if s.current.Kind == Semicolon {
+		if err := s.next(); err != nil {
+			return nil, err
+		}
+	}
+	if s.current.Kind != EOF {
+		return nil, newSyntaxError(s.current, "unexpected %s", s.current)
+	}
+
+	q, err := qb.Build()
+	if err != nil {
+		return nil, err
+	}
+	if s.metric != nil && q.AggregatedBy == nil {
+		return nil, newSyntaxError(*s.metric, "metric %s needs 'aggregated by'", s.metric)
+	}
+	// a timestamp condition replaces the default time range of the builder, its other side is open
+	if s.from != nil || s.to != nil {
+		q.From, q.To = unboundedFrom, unboundedTo
+	}
+	if s.from != nil {
+		q.From = *s.from
+	}
+	if s.to != nil {
+		q.To = *s.to
+	}
+	return q, nil
+}
+
+// parseOperation parses the leading select or scan keyword
+func (s *state) parseOperation() (query_types.Operation, error) {
+	for _, op := range []query_types.Operation{query_types.Select, query_types.Scan} {
+		if s.isKeyword(string(op)) {
+			return op, s.next()
+		}
+	}
+	return "", newSyntaxError(s.current, "expected 'select' or 'scan', found %s", s.current)
+}
+
+// parseFields parses the list of selected fields and metrics, defaulting to all fields when omitted
+func (s *state) parseFields() ([]string, []query_types.Metric, error) {
+	if s.isKeyword("from") {
+		return []string{"*"}, nil, nil
+	}
+	var fields []string
+	var metrics []query_types.Metric
+	for {
+		if s.current.Kind == Star {
+			fields = append(fields, "*")
+			if err := s.next(); err != nil {
+				return nil, nil, err
+			}
+		} else {
+			first, err := s.expect(Ident)
+			if err != nil {
+				return nil, nil, err
+			}
+			if s.current.Kind == LParen {
+				metric, err := s.parseMetric(first)
+				if err != nil {
+					return nil, nil, err
+				}
+				metrics = append(metrics, metric)
+			} else {
+				field, err := s.parseFieldPathFrom(first)
+				if err != nil {
+					return nil, nil, err
+				}
+				fields = append(fields, field)
+			}
+		}
+		if s.current.Kind != Comma {
+			return fields, metrics, nil
+		}
+		if err := s.next(); err != nil {
+			return nil, nil, err
+		}
+	}
+}
+
+// parseMetric parses a metric like avg(label.duration), count(*) or percentile(label.duration, 99),
+// the name of the function is already consumed
+func (s *state) parseMetric(name Token) (query_types.Metric, error) {
+	metric := query_types.Metric{Function: query_types.MetricFunction(strings.ToLower(name.Text))}
+	if !slices.Contains(query_types.MetricFunctions, metric.Function) {
+		return metric, newSyntaxError(name, "unknown metric function %s", name)
+	}
+	if s.metric == nil {
+		s.metric = &name
+	}
+	if _, err := s.expect(LParen); err != nil {
+		return metric, err
+	}
+	if s.current.Kind == Star && metric.Function == query_types.Count {
+		metric.Field = query_types.AllRecords
+		if err := s.next(); err != nil {
+			return metric, err
+		}
+	} else {
+		fieldToken := s.current
+		field, err := s.parseFieldPath()
+		if err != nil {
+			return metric, err
+		}
+		if !strings.HasPrefix(field, "label.") || strings.HasSuffix(field, "*") {
+			return metric, newSyntaxError(fieldToken, "expected label for %s, found %s", metric.Function, field)
+		}
+		metric.Field = field
+	}
+	if metric.Function == query_types.Percentile {
+		if _, err := s.expect(Comma); err != nil {
+			return metric, err
+		}
+		t := s.current
+		percentile, err := strconv.ParseFloat(t.Text, 64)
+		if t.Kind != Number || err != nil || percentile < 0 || percentile > 100 {
+			return metric, newSyntaxError(t, "percentile must be a number between 0 and 100, found %s", t)
+		}
+		metric.Percentile = percentile
+		if err := s.next(); err != nil {
+			return metric, err
+		}
+	}
+	_, err := s.expect(RParen)
+	return metric, err
+}
+
+// parseFieldPath parses a dotted field name like message, label.foo or labels.*
+func (s *state) parseFieldPath() (string, error) {
+	first, err := s.expect(Ident)
+	if err != nil {
+		return "", err
+	}
+	return s.parseFieldPathFrom(first)
+}
+
+// parseFieldPathFrom parses the rest of a dotted field name starting with the consumed identifier
+func (s *state) parseFieldPathFrom(first Token) (string, error) {
+	parts := []string{first.Text}
+	for s.current.Kind == Dot {
+		if err := s.next(); err != nil {
+			return "", err
+		}
+		switch s.current.Kind {
+		case Ident, Star, Number:
+			parts = append(parts, s.current.Text)
+		default:
+			return "", newSyntaxError(s.current, "expected field name after '.', found %s", s.current)
+		}
+		if err := s.next(); err != nil {
+			return "", err
+		}
+	}
+	return strings.Join(parts, "."), nil
+}
+
+// parseClauses parses the optional clauses following the table name
+func (s *state) parseClauses(qb ports.QueryBuilder) error {
+	if s.isKeyword("partition") {
+		if err := s.next(); err != nil {
+			return err
+		}
+		if s.current.Kind != Ident && s.current.Kind != String {
+			return newSyntaxError(s.current, "expected partition name, found %s", s.current)
+		}
+		qb.SetPartition(s.current.Text)
+		if err := s.next(); err != nil {
+			return err
+		}
+	}
+	if s.isKeyword("where") {
+		if err := s.next(); err != nil {
+			return err
+		}
+		if err := s.parseConditions(qb); err != nil {
+			return err
+		}
+	}
+	if s.isKeyword("order") {
+		if err := s.next(); err != nil {
+			return err
+		}
+		if err := s.expectKeyword("by"); err != nil {
+			return err
+		}
+		order, err := s.parseOrder()
+		if err != nil {
+			return err
+		}
+		qb.OrderBy(order)
+	}
+	if s.isKeyword("limit") {
+		if err := s.next(); err != nil {
+			return err
+		}
+		limit, err := s.parseLimit()
+		if err != nil {
+			return err
+		}
+		qb.Limit(limit)
+	}
+	if s.isKeyword("aggregated") {
+		if err := s.next(); err != nil {
+			return err
+		}
+		if err := s.expectKeyword("by"); err != nil {
+			return err
+		}
+		dimension, err := s.parseDimension()
+		if err != nil {
+			return err
+		}
+		qb.AggregateBy(dimension)
+		if s.isKeyword("group") {
+			if err := s.next(); err != nil {
+				return err
+			}
+			if err := s.expectKeyword("by"); err != nil {
+				return err
+			}
+			fields, err := s.parseGroupBy()
+			if err != nil {
+				return err
+			}
+			qb.GroupBy(fields...)
+		}
+	}
+	if s.isKeyword("format") {
+		if err := s.next(); err != nil {
+			return err
+		}
+		format, err := s.parseFormat()
+		if err != nil {
+			return err
+		}
+		qb.SetFormat(format)
+	}
+	return nil
+}
+
+// parseGroupBy parses the comma separated list of labels grouping the aggregation
+func (s *state) parseGroupBy() ([]string, error) {
+	var fields []string
+	for {
+		fieldToken := s.current
+		field, err := s.parseFieldPath()
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(field, "label.") || strings.HasSuffix(field, "*") {
+			return nil, newSyntaxError(fieldToken, "expected label to group by, found %s", field)
+		}
+		fields = append(fields, field)
+		if s.current.Kind != Comma {
+			return fields, nil
+		}
+		if err := s.next(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parseConditions parses conditions joined with and
+func (s *state) parseConditions(qb ports.QueryBuilder) error {
+	for {
+		if err := s.parseCondition(qb); err != nil {
+			return err
+		}
+		if !s.isKeyword("and") {
+			return nil
+		}
+		if err := s.next(); err != nil {
+			return err
+		}
+	}
+}
+
+// parseCondition parses a single {field} {operator} [{value}] condition
+func (s *state) parseCondition(qb ports.QueryBuilder) error {
+	fieldToken := s.current
+	field, err := s.parseFieldPath()
+	if err != nil {
+		return err
+	}
+	operatorToken := s.current
+	operator, err := s.parseOperator()
+	if err != nil {
+		return err
+	}
+	if !hasOperand(operator) {
+		if strings.EqualFold(field, TimestampField) {
+			return newSyntaxError(operatorToken, "operator '%s' is not supported for %s", operator, TimestampField)
+		}
+		qb.Where(field, operator, nil)
+		return nil
+	}
+	valueToken := s.current
+	value, err := s.parseValue()
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(field, TimestampField) {
+		return s.applyTimeRange(fieldToken, operatorToken, valueToken, operator, value)
+	}
+	qb.Where(field, operator, value)
+	return nil
+}
+
+// parseOperator parses a comparison operator or an operator keyword, `not` negates the following operator
+func (s *state) parseOperator() (query_types.QueryOperator, error) {
+	if s.isKeyword("not") {
+		notToken := s.current
+		if err := s.next(); err != nil {
+			return "", err
+		}
+		operator, err := s.parseOperator()
+		if err != nil {
+			return "", err
+		}
+		negated, ok := negations[operator]
+		if !ok {
+			return "", newSyntaxError(notToken, "operator '%s' can not be negated", operator)
+		}
+		return negated, nil
+	}
+
+	t := s.current
+	if t.Kind == Operator {
+		return query_types.QueryOperator(t.Text), s.next()
+	}
+	switch {
+	case s.isKeyword("contains"):
+		return query_types.Contains, s.next()
+	case s.isKeyword("icontains"):
+		return query_types.IContains, s.next()
+	case s.isKeyword("starts"):
+		if err := s.next(); err != nil {
+			return "", err
+		}
+		return query_types.StartsWith, s.expectKeyword("with")
+	case s.isKeyword("ends"):
+		if err := s.next(); err != nil {
+			return "", err
+		}
+		return query_types.EndsWith, s.expectKeyword("with")
+	case s.isKeyword("exists"), s.isKeyword("exist"):
+		return query_types.Exists, s.next()
+	case s.isKeyword("is"):
+		if err := s.next(); err != nil {
+			return "", err
+		}
+		operator := query_types.IsNull
+		if s.isKeyword("not") {
+			operator = query_types.IsNotNull
+			if err := s.next(); err != nil {
+				return "", err
+			}
+		}
+		return operator, s.expectKeyword("null")
+	}
+	return "", newSyntaxError(t, "expected operator, found %s", t)
+}
+
+// negations maps operators to their negated form used by `not {operator}`
+var negations = map[query_types.QueryOperator]query_types.QueryOperator{
+	query_types.Equal:        query_types.NotEqual,
+	query_types.NotEqual:     query_types.Equal,
+	query_types.GreaterThan:  query_types.LessEqual,
+	query_types.LessEqual:    query_types.GreaterThan,
+	query_types.LessThan:     query_types.GreaterEqual,
+	query_types.GreaterEqual: query_types.LessThan,
+	query_types.Exists:       query_types.NotExists,
+	query_types.NotExists:    query_types.Exists,
+	query_types.IsNull:       query_types.IsNotNull,
+	query_types.IsNotNull:    query_types.IsNull,
+	query_types.Contains:     query_types.NotContains,
+	query_types.NotContains:  query_types.Contains,
+	query_types.Matches:      query_types.NotMatches,
+	query_types.NotMatches:   query_types.Matches,
+	query_types.IContains:    query_types.NotIContains,
+	query_types.NotIContains: query_types.IContains,
+}
+
+// hasOperand reports whether the operator expects a value on its right side
+func hasOperand(operator query_types.QueryOperator) bool {
+	switch operator {
+	case query_types.Exists, query_types.NotExists, query_types.IsNull, query_types.IsNotNull:
+		return false
+	}
+	return true
+}
+
+// parseValue parses a string or numeric literal, integers become int64 and decimals float64
+func (s *state) parseValue() (interface{}, error) {
+	t := s.current
+	switch t.Kind {
+	case String:
+		return t.Text, s.next()
+	case Number:
+		if !strings.Contains(t.Text, ".") {
+			if v, err := strconv.ParseInt(t.Text, 10, 64); err == nil {
+				return v, s.next()
+			}
+		}
+		v, err := strconv.ParseFloat(t.Text, 64)
+		if err != nil {
+			return nil, newSyntaxError(t, "invalid number %s", t)
+		}
+		return v, s.next()
+	}
+	return nil, newSyntaxError(t, "expected string or number, found %s", t)
+}
+
+// applyTimeRange turns a timestamp condition into the query time range, the range is inclusive so > and < move the
+// bound by a nanosecond. Several conditions narrow the range, a range left empty is an error.
+func (s *state) applyTimeRange(field, operatorToken, valueToken Token, operator query_types.QueryOperator, value interface{}) error {
+	var ts time.Time
+	switch v := value.(type) {
+	case string:
+		parsed, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return newSyntaxError(valueToken, "invalid timestamp %s, expected RFC3339 format", valueToken)
+		}
+		ts = parsed.UTC()
+	case int64:
+		ts = time.Unix(v, 0).UTC()
+	case float64:
+		// fractional UNIX seconds keep the sub-second part
+		sec, frac := math.Modf(v)
+		ts = time.Unix(int64(sec), int64(math.Round(frac*float64(time.Second)))).UTC()
+	default:
+		return newSyntaxError(valueToken, "invalid timestamp %s", valueToken)
+	}
+
+	switch operator {
+	case query_types.GreaterThan:
+		s.narrowFrom(ts.Add(time.Nanosecond))
+	case query_types.GreaterEqual:
+		s.narrowFrom(ts)
+	case query_types.LessThan:
+		s.narrowTo(ts.Add(-time.Nanosecond))
+	case query_types.LessEqual:
+		s.narrowTo(ts)
+	case query_types.Equal:
+		s.narrowFrom(ts)
+		s.narrowTo(ts)
+	default:
+		return newSyntaxError(operatorToken, "operator '%s' is not supported for %s", operator, field.Text)
+	}
+	if s.from != nil && s.to != nil && s.from.After(*s.to) {
+		return newSyntaxError(valueToken, "empty time range, %s is after %s", s.from.Format(time.RFC3339Nano), s.to.Format(time.RFC3339Nano))
+	}
+	return nil
+}
+
+// narrowFrom moves the start of the time range to the timestamp when it is later
+func (s *state) narrowFrom(ts time.Time) {
+	if s.from == nil || ts.After(*s.from) {
+		s.from = &ts
+	}
+}
+
+// narrowTo moves the end of the time range to the timestamp when it is earlier
+func (s *state) narrowTo(ts time.Time) {
+	if s.to == nil || ts.Before(*s.to) {
+		s.to = &ts
+	}
+}
+
+// parseLimit parses a positive integer limit
+func (s *state) parseLimit() (int, error) {
+	t := s.current
+	if t.Kind != Number {
+		return 0, newSyntaxError(t, "expected number, found %s", t)
+	}
+	limit, err := strconv.Atoi(t.Text)
+	if err != nil || limit < 1 {
+		return 0, newSyntaxError(t, "limit must be a positive integer, found %s", t)
+	}
+	return limit, s.next()
+}
+
+// parseOrder parses the field ordering the records, only the timestamp, and the optional direction
+func (s *state) parseOrder() (query_types.Order, error) {
+	if !s.isKeyword(TimestampField) {
+		return "", newSyntaxError(s.current, "expected %s to order by, found %s", TimestampField, s.current)
+	}
+	if err := s.next(); err != nil {
+		return "", err
+	}
+	for _, o := range query_types.Orders {
+		if s.isKeyword(string(o)) {
+			return o, s.next()
+		}
+	}
+	return query_types.Ascending, nil
+}
+
+// parseDimension parses an aggregation dimension
+func (s *state) parseDimension() (query_types.Dimension, error) {
+	for _, d := range query_types.Dimensions {
+		if s.isKeyword(string(d)) {
+			return d, s.next()
+		}
+	}
+	return "", newSyntaxError(s.current, "unknown aggregation dimension %s", s.current)
+}
+
+// parseFormat parses an output format
+func (s *state) parseFormat() (query_types.Format, error) {
+	for _, f := range query_types.Formats {
+		if s.isKeyword(string(f)) {
+			return f, s.next()
+		}
+	}
+	return "", newSyntaxError(s.current, "unknown format %s", s.current)
+}
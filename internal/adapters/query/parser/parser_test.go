@@ -0,0 +1,230 @@
+package parser_test
+
+import (
+	"LogDb/internal/adapters/query/parser"
+	"LogDb/internal/domain/query_types"
+	"errors"
+	"github.com/stretchr/testify/require"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseFullQuery(t *testing.T) {
+	q, err := parser.NewParser().Parse(`select * from audit.logs partition shard1
+		where timestamp > "2024-01-01T00:00:00Z" and timestamp < "2024-01-02T00:00:00Z"
+		and label.foo exists and label.bar is not null and label.baz = "fiz" and message not contains 'DELETE'
+		limit 1000 aggregated by minute format json;`)
+	require.NoError(t, err)
+	require.Equal(t, query_types.Select, q.Operation)
+	require.Equal(t, []string{"*"}, q.Fields)
+	require.Equal(t, "audit", q.Database)
+	require.Equal(t, "logs", q.Table)
+	require.NotNil(t, q.Partition)
+	require.Equal(t, "shard1", *q.Partition)
+	// > and < exclude their timestamp
+	require.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 1, time.UTC), q.From)
+	require.Equal(t, time.Date(2024, 1, 1, 23, 59, 59, 999999999, time.UTC), q.To)
+	require.Equal(t, []query_types.Condition{
+		{Field: "label.foo", Operator: query_types.Exists},
+		{Field: "label.bar", Operator: query_types.IsNotNull},
+		{Field: "label.baz", Operator: query_types.Equal, Value: "fiz"},
+		{Field: "message", Operator: query_types.NotContains, Value: "DELETE"},
+	}, q.Conditions)
+	require.NotNil(t, q.Limit)
+	require.Equal(t, 1000, *q.Limit)
+	require.NotNil(t, q.AggregatedBy)
+	require.Equal(t, query_types.Minute, *q.AggregatedBy)
+	require.Equal(t, query_types.JSON, q.Format)
+}
+
+func TestParseScanWithoutFields(t *testing.T) {
+	q, err := parser.NewParser().Parse("SCAN audit.logs")
+	require.NoError(t, err)
+	require.Equal(t, query_types.Scan, q.Operation)
+	require.Equal(t, []string{"*"}, q.Fields)
+}
+
+func TestParseExplain(t *testing.T) {
+	q, err := parser.NewParser().Parse("explain scan audit.logs where label.level = 'error' limit 10")
+	require.NoError(t, err)
+	require.True(t, q.Explain)
+	require.Equal(t, query_types.Scan, q.Operation)
+	require.Equal(t, 10, *q.Limit)
+
+	q, err = parser.NewParser().Parse("select * from audit.logs")
+	require.NoError(t, err)
+	require.False(t, q.Explain)
+}
+
+func TestParseTextOperators(t *testing.T) {
+	q, err := parser.NewParser().Parse(`select * from audit.logs where message =~ "^GET /api" and labels !~ 'eu-\\d+'
+		and message icontains "timeout" and message not icontains 'debug' and label.path starts with "/v2"
+		and label.path ends with ".json" and message not =~ "health"`)
+	require.NoError(t, err)
+	require.Equal(t, []query_types.Condition{
+		{Field: "message", Operator: query_types.Matches, Value: "^GET /api"},
+		{Field: "labels", Operator: query_types.NotMatches, Value: `eu-\d+`},
+		{Field: "message", Operator: query_types.IContains, Value: "timeout"},
+		{Field: "message", Operator: query_types.NotIContains, Value: "debug"},
+		{Field: "label.path", Operator: query_types.StartsWith, Value: "/v2"},
+		{Field: "label.path", Operator: query_types.EndsWith, Value: ".json"},
+		{Field: "message", Operator: query_types.NotMatches, Value: "health"},
+	}, q.Conditions)
+
+	_, err = parser.NewParser().Parse("select * from audit.logs where label.path not starts with '/v2'")
+	require.Error(t, err)
+}
+
+func TestParseNotExists(t *testing.T) {
+	q, err := parser.NewParser().Parse(`select * from audit.logs where label.foo not exists and label.bar not not exists
+		and label.baz not is null`)
+	require.NoError(t, err)
+	require.Equal(t, []query_types.Condition{
+		{Field: "label.foo", Operator: query_types.NotExists},
+		{Field: "label.bar", Operator: query_types.Exists},
+		{Field: "label.baz", Operator: query_types.IsNotNull},
+	}, q.Conditions)
+}
+
+func TestParseFieldList(t *testing.T) {
+	q, err := parser.NewParser().Parse("select timestamp, label.*, message from audit.logs where label.size >= 10 and label.ratio != 0.5")
+	require.NoError(t, err)
+	require.Equal(t, []string{"timestamp", "label.*", "message"}, q.Fields)
+	require.Equal(t, []query_types.Condition{
+		{Field: "label.size", Operator: query_types.GreaterEqual, Value: int64(10)},
+		{Field: "label.ratio", Operator: query_types.NotEqual, Value: 0.5},
+	}, q.Conditions)
+}
+
+func TestParseSyntaxErrorPosition(t *testing.T) {
+	_, err := parser.NewParser().Parse("select * from audit.logs\nwhere label.foo ~ 1")
+	require.Error(t, err)
+	var syntaxErr *parser.SyntaxError
+	require.True(t, errors.As(err, &syntaxErr))
+	require.Equal(t, 2, syntaxErr.Line)
+	require.Equal(t, 17, syntaxErr.Column)
+}
+
+func TestParseRejectsTrailingTokens(t *testing.T) {
+	_, err := parser.NewParser().Parse("scan audit.logs limit 10 10")
+	var syntaxErr *parser.SyntaxError
+	require.True(t, errors.As(err, &syntaxErr))
+	require.Equal(t, 1, syntaxErr.Line)
+	require.Equal(t, 26, syntaxErr.Column)
+}
+
+func TestParseSubSecondTimeRange(t *testing.T) {
+	q, err := parser.NewParser().Parse(`select * from audit.logs where timestamp >= "2024-01-01T00:00:00.123456789Z" and timestamp <= 1704067200.5`)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 123456789, time.UTC), q.From)
+	require.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 500000000, time.UTC), q.To)
+}
+
+func TestParseOneSidedTimeRange(t *testing.T) {
+	q, err := parser.NewParser().Parse(`select * from audit.logs where timestamp >= "2020-01-01T00:00:00Z"`)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), q.From)
+	require.Equal(t, time.Unix(0, math.MaxInt64).UTC(), q.To)
+
+	q, err = parser.NewParser().Parse(`select * from audit.logs where timestamp < "2020-01-01T00:00:00Z"`)
+	require.NoError(t, err)
+	require.Equal(t, time.Unix(0, 0).UTC(), q.From)
+	require.Equal(t, time.Date(2019, 12, 31, 23, 59, 59, 999999999, time.UTC), q.To)
+
+	// without timestamp condition the query keeps the default time range of the builder
+	q, err = parser.NewParser().Parse("select * from audit.logs")
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now(), q.To, time.Minute)
+	require.WithinDuration(t, q.To.Add(-24*time.Hour), q.From, time.Second)
+}
+
+func TestParseNarrowedTimeRange(t *testing.T) {
+	q, err := parser.NewParser().Parse(`select * from audit.logs where timestamp >= 1704067200 and timestamp > 1704067100
+		and timestamp <= 1704153600 and timestamp < 1704153700`)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), q.From)
+	require.Equal(t, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), q.To)
+
+	q, err = parser.NewParser().Parse(`select * from audit.logs where timestamp = "2024-01-01T10:00:00Z"`)
+	require.NoError(t, err)
+	require.Equal(t, q.From, q.To)
+	require.Equal(t, time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC), q.From)
+}
+
+func TestParseEmptyTimeRange(t *testing.T) {
+	// the error points at the value of the condition leaving the range empty, the last one
+	queries := map[string]string{
+		`select * from audit.logs where timestamp > "2024-01-02T00:00:00Z" and timestamp < "2024-01-01T00:00:00Z"`:  `"2024-01-01`,
+		`select * from audit.logs where timestamp < "2024-01-01T00:00:00Z" and timestamp >= "2024-01-01T00:00:00Z"`: `"2024-01-01`,
+		`select * from audit.logs where timestamp > 1704067200 and timestamp < 1704067200`:                          "1704067200",
+		`select * from audit.logs where timestamp = 1704067200 and timestamp > 1704067200`:                          "1704067200",
+	}
+	for text, value := range queries {
+		_, err := parser.NewParser().Parse(text)
+		var syntaxErr *parser.SyntaxError
+		require.True(t, errors.As(err, &syntaxErr), text)
+		require.Contains(t, syntaxErr.Message, "empty time range")
+		require.Equal(t, strings.LastIndex(text, value)+1, syntaxErr.Column, text)
+	}
+
+	// a single timestamp is a valid range
+	_, err := parser.NewParser().Parse(`select * from audit.logs where timestamp >= 1704067200 and timestamp <= 1704067200`)
+	require.NoError(t, err)
+}
+
+func TestParseAggregationGroupBy(t *testing.T) {
+	q, err := parser.NewParser().Parse("scan audit.logs aggregated by hour group by label.service, label.level format text")
+	require.NoError(t, err)
+	require.NotNil(t, q.AggregatedBy)
+	require.Equal(t, query_types.Hour, *q.AggregatedBy)
+	require.Equal(t, []string{"label.service", "label.level"}, q.GroupBy)
+	require.Equal(t, query_types.Text, q.Format)
+
+	_, err = parser.NewParser().Parse("scan audit.logs aggregated by hour group by message")
+	var syntaxErr *parser.SyntaxError
+	require.True(t, errors.As(err, &syntaxErr))
+	require.Equal(t, 45, syntaxErr.Column)
+}
+
+func TestParseMetrics(t *testing.T) {
+	q, err := parser.NewParser().Parse(`select count(*), avg(label.duration), percentile(label.duration, 99.9), count_distinct(label.user)
+		from audit.logs aggregated by minute group by label.service`)
+	require.NoError(t, err)
+	require.Empty(t, q.Fields)
+	require.Equal(t, []query_types.Metric{
+		{Function: query_types.Count, Field: query_types.AllRecords},
+		{Function: query_types.Avg, Field: "label.duration"},
+		{Function: query_types.Percentile, Field: "label.duration", Percentile: 99.9},
+		{Function: query_types.CountDistinct, Field: "label.user"},
+	}, q.Metrics)
+
+	for text, column := range map[string]int{
+		"select median(label.duration) from audit.logs aggregated by hour":          8,
+		"select sum(message) from audit.logs aggregated by hour":                    12,
+		"select percentile(label.duration, 101) from audit.logs aggregated by hour": 35,
+		"select max(label.duration) from audit.logs":                                8,
+	} {
+		_, err := parser.NewParser().Parse(text)
+		var syntaxErr *parser.SyntaxError
+		require.True(t, errors.As(err, &syntaxErr), text)
+		require.Equal(t, column, syntaxErr.Column, text)
+	}
+}
+
+func TestParseOrderBy(t *testing.T) {
+	q, err := parser.NewParser().Parse("scan audit.logs where label.level = 'error' order by timestamp desc limit 10")
+	require.NoError(t, err)
+	require.Equal(t, query_types.Descending, q.Order)
+	require.Equal(t, 10, *q.Limit)
+
+	q, err = parser.NewParser().Parse("scan audit.logs order by timestamp")
+	require.NoError(t, err)
+	require.Equal(t, query_types.Ascending, q.Order)
+
+	_, err = parser.NewParser().Parse("scan audit.logs order by message desc")
+	var syntaxErr *parser.SyntaxError
+	require.True(t, errors.As(err, &syntaxErr))
+	require.Equal(t, 26, syntaxErr.Column)
+}
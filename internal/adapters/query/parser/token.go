@@ -0,0 +1,48 @@
+package parser
+
+import "fmt"
+
+// TokenKind is the lexical category of a token
+type TokenKind int
+
+const (
+	EOF TokenKind = iota
+	Ident
+	String
+	Number
+	Star
+	Comma
+	Dot
+	Semicolon
+	LParen
+	RParen
+	Operator
+)
+
+// String returns the string representation of the token kind
+func (k TokenKind) String() string {
+	if k < EOF || k > Operator {
+		return "Unknown"
+	}
+	return [...]string{"end of input", "identifier", "string", "number", "'*'", "','", "'.'", "';'", "'('", "')'", "operator"}[k]
+}
+
+// Token is a single lexical unit of a query with its position in the source
+type Token struct {
+	Kind   TokenKind
+	Text   string // Raw text for identifiers, numbers and operators, unquoted value for strings
+	Line   int    // 1-based line number
+	Column int    // 1-based column number
+}
+
+// String returns the string representation of the token
+func (t Token) String() string {
+	switch t.Kind {
+	case EOF:
+		return t.Kind.String()
+	case String:
+		return fmt.Sprintf("%q", t.Text)
+	default:
+		return fmt.Sprintf("'%s'", t.Text)
+	}
+}
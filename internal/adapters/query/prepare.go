@@ -1,10 +1,16 @@
 package query
 
 import (
+	"LogDb/internal/adapters/filters"
+	"LogDb/internal/adapters/transformers"
 	"LogDb/internal/domain"
 	"LogDb/internal/domain/query_types"
+	"LogDb/internal/internal_errors"
 	"LogDb/internal/ports"
 	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -13,6 +19,11 @@ type Prepared struct {
 	to        uint64
 	r         *domain.QueryResult
 	f         ports.FilterSet
+	groups    *labelResolver // grouping labels of an aggregation
+	metrics   *labelResolver // labels of the metrics of an aggregation
+	after     *domain.Cursor // records up to the cursor were returned in the previous page
+	filters   []string       // descriptions of the compiled filters of an explained query
+	sink      ports.RecordSink
 	startTime time.Time
 	e         error
 }
@@ -20,21 +31,34 @@ type Prepared struct {
 // NewPreparedQuery creates a new PreparedQuery.
 func NewPreparedQuery(q *domain.Query, f ports.FilterSet) *Prepared {
 	return &Prepared{
-		r:    domain.NewQueryResult(q),
-		from: uint64(q.From.UTC().Unix()),
-		to:   uint64(q.To.UTC().Unix()),
-		f:    f,
+		r:     domain.NewQueryResult(q),
+		from:  uint64(q.From.UnixNano()),
+		to:    uint64(q.To.UnixNano()),
+		f:     f,
+		after: q.After,
 	}
 }
 
+// FromDateTime returns the start of the time range of the query in UNIX nanoseconds
 func (p *Prepared) FromDateTime() uint64 {
 	return p.from
 }
 
+// ToDateTime returns the end of the time range of the query in UNIX nanoseconds
 func (p *Prepared) ToDateTime() uint64 {
 	return p.to
 }
 
+// IsBefore returns true if the timestamp is before the time range of the query
+func (p *Prepared) IsBefore(timestamp uint64) bool {
+	return p.f.IsBefore(timestamp)
+}
+
+// IsAfter returns true if the timestamp is after the time range of the query
+func (p *Prepared) IsAfter(timestamp uint64) bool {
+	return p.f.IsAfter(timestamp)
+}
+
 func (p *Prepared) Begin() {
 	p.startTime = time.Now()
 }
@@ -44,14 +68,102 @@ func (p *Prepared) Skip() {
 }
 
 func (p *Prepared) Next(record *domain.LogRecord) error {
-	if p.f.IsMatch(record) {
-		p.r.Hit(record)
-	} else {
+	switch {
+	case !p.f.IsMatch(record), p.after != nil && !p.after.IsAfter(record):
 		p.r.Miss()
+	case p.r.Aggregation != nil:
+		p.r.Aggregate(record.Timestamp, p.groups.values(record), p.metrics.labels(record))
+	case p.sink != nil:
+		if !p.r.Match(record) {
+			return internal_errors.RecordsLimitReached
+		}
+		return p.sink.Write(record)
+	default:
+		p.r.Hit(record)
 	}
 	return nil
 }
 
+// Order returns the order of the records by timestamp, ascending unless the query asks for descending
+func (p *Prepared) Order() query_types.Order {
+	if p.r.IsDescending() {
+		return query_types.Descending
+	}
+	return query_types.Ascending
+}
+
+// IsSatisfied reports whether the records between from and to can no longer change the result
+func (p *Prepared) IsSatisfied(from, to uint64) bool {
+	switch {
+	case p.r.Aggregation != nil:
+		return false
+	case p.sink != nil:
+		return p.r.IsLimitReached()
+	}
+	boundary, full := p.r.IsFull()
+	switch {
+	case !full:
+		return false
+	case boundary.IsZero():
+		return true
+	case p.r.IsDescending():
+		// records sharing the timestamp of the boundary are ordered by their position
+		return to < uint64(boundary.UnixNano())
+	}
+	return from > uint64(boundary.UnixNano())
+}
+
+// After returns the cursor the query continues after, nil for the first page of results
+func (p *Prepared) After() *domain.Cursor {
+	return p.after
+}
+
+// Concurrency returns the number of workers the query asks for to scan the data files, 0 for the default
+func (p *Prepared) Concurrency() int {
+	return p.r.Query.Concurrency
+}
+
+// Timeout returns the time limit of the query, 0 without limit
+func (p *Prepared) Timeout() time.Duration {
+	return p.r.Query.Timeout
+}
+
+// Budget returns the limits of the resources the query asks for, zero limits are unlimited
+func (p *Prepared) Budget() domain.QueryBudget {
+	return p.r.Query.Budget
+}
+
+// Usage returns what the query consumed so far, it is part of the report
+func (p *Prepared) Usage() *domain.QueryUsage {
+	return &p.r.Report.Usage
+}
+
+// Explain reports whether the query asks for its plan instead of its records
+func (p *Prepared) Explain() bool {
+	return p.r.Query.Explain
+}
+
+// Filters describes the filters compiled from the conditions of an explained query
+func (p *Prepared) Filters() []string {
+	return p.filters
+}
+
+// CountPage counts the records of a data page without reading them, only an aggregation without conditions,
+// grouping and metrics counts pages. A page crossing the time range of the query must be read.
+func (p *Prepared) CountPage(start time.Time, records uint64) bool {
+	q := p.r.Query
+	if p.r.Aggregation == nil || len(q.Conditions) > 0 || len(q.GroupBy) > 0 || len(q.Metrics) > 0 {
+		return false
+	}
+	first := uint64(start.UnixNano())
+	last := uint64(start.Add(domain.DataPageDuration).UnixNano()) - 1
+	if p.IsBefore(first) || p.IsAfter(last) {
+		return false
+	}
+	p.r.Count(start, nil, records)
+	return true
+}
+
 func (p *Prepared) SetError(err error) {
 	if p.e != nil {
 		// merge errors
@@ -66,6 +178,15 @@ func (p *Prepared) Error() error {
 }
 func (p *Prepared) End() {
 	p.r.SpentTime(time.Since(p.startTime))
+	if p.sink != nil {
+		p.sink.Close(p.r.Report, p.e)
+	}
+}
+
+// Stream passes the matching records to the sink instead of the result, the records of an aggregation are not
+// passed, they are counted in the buckets of the result
+func (p *Prepared) Stream(sink ports.RecordSink) {
+	p.sink = sink
 }
 
 func (p *Prepared) Result() (*domain.QueryResult, error) {
@@ -76,35 +197,260 @@ func (p *Prepared) Result() (*domain.QueryResult, error) {
 type Preparer struct {
 	filterBuilderFactory  ports.FilterFactory
 	labelConditionFactory ports.LabelConditionFactory
+	schemas               ports.SchemaStore
 }
 
+// LabelFieldPrefix is the prefix of condition fields referring to a label, e.g. label.status
+const LabelFieldPrefix = "label."
+
+// MessageField is the condition field of the message of a record
+const MessageField = "message"
+
+// LabelsField is the condition field of any label value of a record, e.g. labels =~ "^eu-"
+const LabelsField = "labels"
+
 func (p *Preparer) PrepareQuery(q *domain.Query) (ports.PreparedQuery, error) {
-	// Create Label Conditions
-	//_ = p.labelConditionFactory.CreateConditionBuilder(query.Schema, query.Label)
+	if q.Order != "" && !q.Order.IsValid() {
+		return nil, fmt.Errorf("%w: %s", internal_errors.UnsupportedOrder, q.Order)
+	}
+	var err error
+	if q.After != nil {
+		if q, err = resumeQuery(q); err != nil {
+			return nil, err
+		}
+	}
+	matchers, err := compileTextMatchers(q)
+	if err != nil {
+		return nil, err
+	}
+	filterSet, err := p.buildFilterSet(q, matchers, filters.NewDateRangeFilter(q.From, q.To))
+	if err != nil {
+		return nil, err
+	}
+	prepared := NewPreparedQuery(q, filterSet)
+	if q.Explain {
+		prepared.filters = p.describeConditions(q)
+	}
+	if q.AggregatedBy != nil {
+		if !q.AggregatedBy.IsValid() {
+			return nil, fmt.Errorf("%w: %s", internal_errors.UnsupportedAggregationDimension, *q.AggregatedBy)
+		}
+		if prepared.groups, err = newLabelResolver(p.schemas, q.GroupBy, internal_errors.UnsupportedGroupByField); err != nil {
+			return nil, err
+		}
+		if prepared.metrics, err = newMetricLabels(p.schemas, q.Metrics); err != nil {
+			return nil, err
+		}
+	} else if len(q.Metrics) > 0 {
+		return nil, fmt.Errorf("%w: metrics need an aggregation dimension", internal_errors.UnsupportedMetric)
+	}
+	return prepared, nil
+}
 
+// resumeQuery returns a copy of the query starting at the timestamp of its cursor, the records before the cursor
+// are neither read nor returned again. Only the records of a query in the order of the cursor have a cursor.
+func resumeQuery(q *domain.Query) (*domain.Query, error) {
+	cursor := q.After
+	switch {
+	case q.AggregatedBy != nil:
+		return nil, fmt.Errorf("%w: aggregations have no cursor", internal_errors.InvalidCursor)
+	case cursor.Order != q.Order && (cursor.Order != query_types.Ascending || q.Order != ""):
+		return nil, fmt.Errorf("%w: the cursor is in %s order", internal_errors.InvalidCursor, cursor.Order)
+	case cursor.Timestamp.Before(q.From) || cursor.Timestamp.After(q.To):
+		return nil, fmt.Errorf("%w: the cursor is outside the time range", internal_errors.InvalidCursor)
+	}
+	resumed := *q
+	timeRange := *q.QueryTimeRange
+	if cursor.Order == query_types.Descending {
+		timeRange.To = cursor.Timestamp
+	} else {
+		timeRange.From = cursor.Timestamp
+	}
+	resumed.QueryTimeRange = &timeRange
+	return &resumed, nil
+}
+
+// PrepareFilter compiles the conditions of the query into a filter, the time range of the query is not part of it.
+// The filter matches records written after it was prepared, it is compiled again for schemas created later.
+func (p *Preparer) PrepareFilter(q *domain.Query) (ports.Filter, error) {
+	return newSchemaFilter(p, q)
+}
+
+// buildFilterSet compiles the conditions of the query into a filter set with the given time range filter, the
+// matchers of the text conditions are compiled once by compileTextMatchers
+func (p *Preparer) buildFilterSet(q *domain.Query, matchers []ports.TextMatcher, timeStampFilter ports.TimeStampFilter) (ports.FilterSet, error) {
 	// Create Filters
 	fb := p.filterBuilderFactory.CreateFilterBuilder()
+	if timeStampFilter != nil {
+		fb.WithTimeStampFilter(timeStampFilter)
+	}
 
-	for _, cond := range q.Conditions {
-		if cond.Field == "message" {
-			if cond.Operator == query_types.Contains {
-				fb.Contains([]byte(cond.Value.(string)))
+	for i, cond := range q.Conditions {
+		matcher := matchers[i]
+		switch {
+		case cond.Field == MessageField && cond.Operator == query_types.Contains:
+			// message contains checks the label values as well
+			fb.Contains([]byte(fmt.Sprint(cond.Value)))
+		case cond.Field == MessageField && cond.Operator == query_types.NotContains:
+			fb.NotContains([]byte(fmt.Sprint(cond.Value)))
+		case cond.Field == MessageField && matcher != nil:
+			addTextFilter(fb, cond, filters.NewText(matcher, filters.MessageOnly))
+		case cond.Field == LabelsField && matcher != nil:
+			addTextFilter(fb, cond, filters.NewText(matcher, filters.LabelsOnly))
+		case cond.Field == MessageField, cond.Field == LabelsField:
+			return nil, fmt.Errorf("%w: %s %s", internal_errors.UnsupportedQueryOperator, cond.Field, cond.Operator)
+		case strings.HasPrefix(cond.Field, LabelFieldPrefix):
+			if err := p.prepareLabelCondition(fb, cond, matcher); err != nil {
+				return nil, err
 			}
+		default:
+			return nil, fmt.Errorf("%w: %s", internal_errors.UnknownQueryField, cond.Field)
 		}
+	}
 
+	return fb.Build()
+}
+
+// addTextFilter adds the filter of a text condition, a negated operator adds its negation
+func addTextFilter(fb ports.FilterBuilder, cond query_types.Condition, filter ports.Filter) {
+	if isNegatedText(cond.Operator) {
+		fb.Not(filter)
+	} else {
+		fb.And(filter)
 	}
+}
 
-	filterSet, err := fb.Build()
+// prepareLabelCondition compiles a label condition into label filters for every schema having the label
+func (p *Preparer) prepareLabelCondition(fb ports.FilterBuilder, cond query_types.Condition, matcher ports.TextMatcher) error {
+	condition, err := p.buildLabelCondition(cond, matcher)
 	if err != nil {
-		return nil, err
+		return err
+	}
+
+	var labelFilters []ports.Filter
+	for _, position := range p.labelPositions(strings.TrimPrefix(cond.Field, LabelFieldPrefix)) {
+		labelFilters = append(labelFilters, filters.NewLabel(position.index, position.schema, condition))
+	}
+
+	labelFilter := filters.NewOr(labelFilters...)
+	if cond.Operator == query_types.IsNull || cond.Operator == query_types.NotExists {
+		fb.Not(labelFilter)
+	} else {
+		fb.And(labelFilter)
+	}
+	return nil
+}
+
+// buildLabelCondition compiles the operator of a label condition, text operators check the label value with the
+// matcher of the condition
+func (p *Preparer) buildLabelCondition(cond query_types.Condition, matcher ports.TextMatcher) (ports.LabelCondition, error) {
+	if matcher != nil {
+		var condition ports.LabelCondition = filters.NewTextCondition(matcher)
+		if !isNegatedText(cond.Operator) {
+			return condition, nil
+		}
+		// as for != records without the label do not match a negated text operator
+		return p.labelConditionFactory.CreateConditionBuilder(0, nil).Not(condition).Build()
+	}
+
+	var label *domain.Label
+	var err error
+	if hasValue(cond.Operator) {
+		if label, err = conditionValueToLabel(cond.Value); err != nil {
+			return nil, fmt.Errorf("%w: %s", err, cond.Field)
+		}
+	}
+
+	cb := p.labelConditionFactory.CreateConditionBuilder(0, label)
+	switch cond.Operator {
+	case query_types.Equal:
+		cb.Eq(label)
+	case query_types.NotEqual:
+		cb.Neq(label)
+	case query_types.GreaterThan:
+		cb.Gt(label)
+	case query_types.GreaterEqual:
+		cb.Gte(label)
+	case query_types.LessThan:
+		cb.Lt(label)
+	case query_types.LessEqual:
+		cb.Lte(label)
+	case query_types.Exists, query_types.NotExists:
+		// not exists is the negation of exists, only records without the label match
+		cb.Exists()
+	case query_types.IsNotNull, query_types.IsNull:
+		// is null is the negation of is not null, records without the label must match as well
+		cb.NotEmpty()
+	default:
+		return nil, fmt.Errorf("%w: %s %s", internal_errors.UnsupportedQueryOperator, cond.Field, cond.Operator)
+	}
+	return cb.Build()
+}
+
+// labelPosition is the position of a label in the records of a schema version
+type labelPosition struct {
+	schema uint64
+	index  int
+}
+
+// labelPositions returns the position of the label in every schema having it. The label position differs between
+// schemas, records without a schema only know positional names.
+func (p *Preparer) labelPositions(name string) []labelPosition {
+	var positions []labelPosition
+	for _, schema := range p.schemas.Schemas() {
+		if idx, ok := schema.FieldIndex(name); ok {
+			positions = append(positions, labelPosition{schema: schema.ID(), index: idx})
+		}
+	}
+	if idx, ok := positionalLabelIndex(name); ok {
+		for version := uint64(0); version < domain.FirstSchemaVersion; version++ {
+			positions = append(positions, labelPosition{schema: version, index: idx})
+		}
+	}
+	return positions
+}
+
+// positionalLabelIndex resolves the position of a label named by its position (0 or label_0)
+func positionalLabelIndex(name string) (int, bool) {
+	idx, err := strconv.Atoi(strings.TrimPrefix(name, "label_"))
+	if err != nil || idx < 0 {
+		return 0, false
+	}
+	return idx, true
+}
+
+// hasValue reports whether the operator compares the label with a value
+func hasValue(operator query_types.QueryOperator) bool {
+	switch operator {
+	case query_types.Exists, query_types.NotExists, query_types.IsNull, query_types.IsNotNull:
+		return false
+	}
+	return true
+}
+
+// conditionValueToLabel converts the value of a condition to a label of the matching type
+func conditionValueToLabel(value interface{}) (*domain.Label, error) {
+	var label domain.Label
+	switch v := value.(type) {
+	case string:
+		label = transformers.StringToLabel(v)
+	case int:
+		label = transformers.IntToLabel(v)
+	case int64:
+		label = transformers.Int64ToLabel(v)
+	case float64:
+		label = transformers.FloatToLabel(v)
+	default:
+		return nil, fmt.Errorf("%w: %T", internal_errors.UnsupportedConditionValue, value)
 	}
-	return NewPreparedQuery(q, filterSet), nil
+	return &label, nil
 }
 
 // NewPreparer creates a new Preparer.
-func NewPreparer(filterBuilder ports.FilterFactory, labelConditionBuilder ports.LabelConditionFactory) *Preparer {
+func NewPreparer(filterBuilder ports.FilterFactory, labelConditionBuilder ports.LabelConditionFactory, schemas ports.SchemaStore) *Preparer {
 	return &Preparer{
 		filterBuilderFactory:  filterBuilder,
 		labelConditionFactory: labelConditionBuilder,
+		schemas:               schemas,
 	}
 }
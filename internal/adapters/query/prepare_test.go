@@ -0,0 +1,163 @@
+package query
+
+import (
+	"LogDb/internal/adapters/filters"
+	"LogDb/internal/adapters/filters/label_conditions"
+	"LogDb/internal/adapters/schema"
+	"LogDb/internal/adapters/transformers"
+	"LogDb/internal/domain"
+	"LogDb/internal/domain/query_types"
+	"LogDb/internal/internal_errors"
+	"LogDb/internal/ports"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestConditionValueToLabel tests that the value of a condition is encoded as a label of its type
+func TestConditionValueToLabel(t *testing.T) {
+	tests := []struct {
+		value any
+		label domain.Label
+	}{
+		{"api", transformers.StringToLabel("api")},
+		{"", transformers.StringToLabel("")},
+		{-3, transformers.IntToLabel(-3)},
+		{int64(-1 << 40), transformers.Int64ToLabel(-1 << 40)},
+		{-2.5, transformers.FloatToLabel(-2.5)},
+		{float64(3), transformers.FloatToLabel(3)},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%T %v", test.value, test.value), func(t *testing.T) {
+			label, err := conditionValueToLabel(test.value)
+			require.NoError(t, err)
+			assert.Equal(t, test.label, *label)
+		})
+	}
+
+	_, err := conditionValueToLabel(true)
+	assert.ErrorIs(t, err, internal_errors.UnsupportedConditionValue)
+	_, err = conditionValueToLabel(uint8(1))
+	assert.ErrorIs(t, err, internal_errors.UnsupportedConditionValue)
+}
+
+// TestBuildLabelCondition tests the condition of every operator and that operators without a value ignore it
+func TestBuildLabelCondition(t *testing.T) {
+	p := NewPreparer(filters.Factory, label_conditions.Factory, nil)
+	ten, empty := transformers.IntToLabel(10), transformers.StringToLabel("")
+	tests := []struct {
+		operator query_types.QueryOperator
+		value    any
+		fits     []bool // ten and empty
+	}{
+		{query_types.Equal, 10, []bool{true, false}},
+		{query_types.NotEqual, 10, []bool{false, true}},
+		{query_types.GreaterThan, 9.5, []bool{true, false}},
+		{query_types.GreaterEqual, 10.0, []bool{true, false}},
+		{query_types.LessThan, -1, []bool{false, false}},
+		{query_types.LessEqual, "10", []bool{true, true}}, // the empty string is before "10"
+		{query_types.Exists, nil, []bool{true, true}},
+		{query_types.NotExists, nil, []bool{true, true}}, // negated by the label filter
+		{query_types.IsNotNull, nil, []bool{true, false}},
+		{query_types.IsNull, nil, []bool{true, false}}, // negated by the label filter
+	}
+	for _, test := range tests {
+		t.Run(string(test.operator), func(t *testing.T) {
+			condition, err := p.buildLabelCondition(query_types.Condition{Field: "label.size", Operator: test.operator, Value: test.value}, nil)
+			require.NoError(t, err)
+			assert.Equal(t, test.fits, []bool{condition.IsFit(&ten), condition.IsFit(&empty)})
+		})
+	}
+
+	_, err := p.buildLabelCondition(query_types.Condition{Field: "label.size", Operator: query_types.Equal, Value: []int{1}}, nil)
+	assert.ErrorIs(t, err, internal_errors.UnsupportedConditionValue)
+	_, err = p.buildLabelCondition(query_types.Condition{Field: "label.size", Operator: query_types.Contains, Value: "1"}, nil)
+	assert.ErrorIs(t, err, internal_errors.UnsupportedQueryOperator)
+}
+
+// newConditionRecord returns a record of the schema with the labels, fewer labels than the schema has fields leave
+// the following fields absent
+func newConditionRecord(message string, schemaVersion uint64, labels ...domain.Label) *domain.LogRecord {
+	return &domain.LogRecord{
+		Timestamp:     time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		SchemaVersion: schemaVersion,
+		Labels:        labels,
+		Message:       []byte(message),
+	}
+}
+
+// TestPrepare_LabelConditions tests typed comparisons and the presence operators on records of two schemas, one of
+// them without the compared labels
+func TestPrepare_LabelConditions(t *testing.T) {
+	store, err := schema.NewFileStore(filepath.Join(t.TempDir(), "schemas.json"))
+	require.NoError(t, err)
+	full, err := store.ResolveSchema([]ports.SchemaField{
+		schema.NewField("service", domain.StringLabelType),
+		schema.NewField("size", domain.IntLabelType),
+		schema.NewField("ratio", domain.FloatLabelType),
+	})
+	require.NoError(t, err)
+	serviceOnly, err := store.ResolveSchema([]ports.SchemaField{schema.NewField("service", domain.StringLabelType)})
+	require.NoError(t, err)
+
+	records := []*domain.LogRecord{
+		newConditionRecord("api", full.ID(), transformers.StringToLabel("api"), transformers.IntToLabel(10), transformers.FloatToLabel(0.5)),
+		newConditionRecord("empty", full.ID(), transformers.StringToLabel(""), transformers.IntToLabel(-3), transformers.FloatToLabel(2)),
+		newConditionRecord("web", serviceOnly.ID(), transformers.StringToLabel("web")),
+		newConditionRecord("db", full.ID(), transformers.StringToLabel("db")),
+	}
+	tests := []struct {
+		field    string
+		operator query_types.QueryOperator
+		value    any
+		matches  []string
+	}{
+		{"label.size", query_types.GreaterThan, -5, []string{"api", "empty"}},
+		{"label.size", query_types.LessThan, 0, []string{"empty"}},
+		{"label.size", query_types.GreaterEqual, 10.0, []string{"api"}},
+		{"label.size", query_types.LessEqual, -3.5, nil},
+		{"label.size", query_types.NotEqual, 10, []string{"empty"}},
+		{"label.ratio", query_types.GreaterThan, 1, []string{"empty"}},
+		{"label.ratio", query_types.LessEqual, 0.5, []string{"api"}},
+		{"label.ratio", query_types.Equal, "2", []string{"empty"}},
+		{"label.service", query_types.Equal, "api", []string{"api"}},
+		{"label.service", query_types.NotEqual, "api", []string{"empty", "web", "db"}},
+		{"label.service", query_types.GreaterThan, "c", []string{"web", "db"}},
+		{"label.service", query_types.Exists, nil, []string{"api", "empty", "web", "db"}},
+		{"label.service", query_types.IsNotNull, nil, []string{"api", "web", "db"}},
+		{"label.service", query_types.IsNull, nil, []string{"empty"}},
+		{"label.service", query_types.NotExists, nil, nil}, // the empty service is present
+		{"label.size", query_types.Exists, nil, []string{"api", "empty"}},
+		{"label.size", query_types.NotExists, nil, []string{"web", "db"}},
+		{"label.size", query_types.IsNotNull, nil, []string{"api", "empty"}},
+		{"label.size", query_types.IsNull, nil, []string{"web", "db"}},
+		{"label.missing", query_types.Exists, nil, nil},
+		{"label.missing", query_types.IsNull, nil, []string{"api", "empty", "web", "db"}},
+		{"label.missing", query_types.NotExists, nil, []string{"api", "empty", "web", "db"}},
+	}
+	preparer := NewPreparer(filters.Factory, label_conditions.Factory, store)
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%s %s %v", test.field, test.operator, test.value), func(t *testing.T) {
+			prepared, err := preparer.PrepareQuery(&domain.Query{
+				QueryTimeRange: &domain.QueryTimeRange{From: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)},
+				Operation:      query_types.Select,
+				Fields:         []string{"*"},
+				Conditions:     []query_types.Condition{{Field: test.field, Operator: test.operator, Value: test.value}},
+			})
+			require.NoError(t, err)
+			for _, record := range records {
+				require.NoError(t, prepared.Next(record))
+			}
+			result, err := prepared.Result()
+			require.NoError(t, err)
+			var matches []string
+			for _, record := range result.Records {
+				matches = append(matches, string(record.Message))
+			}
+			assert.Equal(t, test.matches, matches)
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package query
+
+import (
+	"LogDb/internal/adapters/filters"
+	"LogDb/internal/domain"
+	"LogDb/internal/domain/query_types"
+	"LogDb/internal/internal_errors"
+	"LogDb/internal/ports"
+	"fmt"
+	"regexp"
+)
+
+// compileTextMatchers compiles the values of the text conditions of the query, the matchers are indexed like the
+// conditions and nil for the others. A regular expression is compiled once per query, the filter of a live tail
+// reuses the matchers when it is built again for a new schema.
+func compileTextMatchers(q *domain.Query) ([]ports.TextMatcher, error) {
+	matchers := make([]ports.TextMatcher, len(q.Conditions))
+	for i, cond := range q.Conditions {
+		matcher, err := textMatcher(cond)
+		if err != nil {
+			return nil, err
+		}
+		matchers[i] = matcher
+	}
+	return matchers, nil
+}
+
+// textMatcher compiles the value of a text condition, conditions with other operators have no matcher
+func textMatcher(cond query_types.Condition) (ports.TextMatcher, error) {
+	value := fmt.Sprint(cond.Value)
+	switch cond.Operator {
+	case query_types.Contains, query_types.NotContains:
+		return filters.NewContainsMatcher([]byte(value)), nil
+	case query_types.IContains, query_types.NotIContains:
+		return filters.NewContainsFoldMatcher([]byte(value)), nil
+	case query_types.StartsWith:
+		return filters.NewPrefixMatcher([]byte(value)), nil
+	case query_types.EndsWith:
+		return filters.NewSuffixMatcher([]byte(value)), nil
+	case query_types.Matches, query_types.NotMatches:
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", internal_errors.InvalidRegexp, cond.Field, err)
+		}
+		return filters.NewRegexpMatcher(re), nil
+	}
+	return nil, nil
+}
+
+// isNegatedText reports whether a text operator matches the records its matcher does not match
+func isNegatedText(operator query_types.QueryOperator) bool {
+	switch operator {
+	case query_types.NotContains, query_types.NotIContains, query_types.NotMatches:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,26 @@
+package schema
+
+import "LogDb/internal/ports"
+
+var _ ports.SchemaField = new(Field)
+
+// Field is a named and typed label of a schema
+type Field struct {
+	FieldName string `json:"name"`
+	FieldType uint8  `json:"type"`
+}
+
+// Name returns the label name
+func (f *Field) Name() string {
+	return f.FieldName
+}
+
+// Type returns the label type (domain.StringLabelType, domain.IntLabelType or domain.FloatLabelType)
+func (f *Field) Type() uint8 {
+	return f.FieldType
+}
+
+// NewField creates a new Field
+func NewField(name string, labelType uint8) *Field {
+	return &Field{FieldName: name, FieldType: labelType}
+}
@@ -0,0 +1,194 @@
+package schema
+
+import (
+	"LogDb/internal/domain"
+	"LogDb/internal/internal_errors"
+	"LogDb/internal/ports"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+var _ ports.SchemaStore = new(FileStore)
+
+// FileStore is a SchemaStore persisted as a JSON file, the file is rewritten on every new schema
+type FileStore struct {
+	mx          sync.RWMutex
+	path        string
+	nextVersion uint64
+	schemas     map[uint64]*Schema
+	byFields    map[string]*Schema
+}
+
+// fileContent is the layout of the schema file
+type fileContent struct {
+	Schemas []*Schema `json:"schemas"`
+}
+
+// GetSchema returns the schema with the given version
+func (s *FileStore) GetSchema(version uint64) (ports.Schema, error) {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	schema, ok := s.schemas[version]
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", internal_errors.SchemaNotFound, version)
+	}
+	return schema, nil
+}
+
+// CreateSchema registers a new schema with the given fields
+func (s *FileStore) CreateSchema(fields []ports.SchemaField) (ports.Schema, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return s.create(fields)
+}
+
+// ResolveSchema returns the schema with exactly the given fields, creating it when it does not exist
+func (s *FileStore) ResolveSchema(fields []ports.SchemaField) (ports.Schema, error) {
+	key := fieldsKey(fields)
+	s.mx.RLock()
+	schema, ok := s.byFields[key]
+	s.mx.RUnlock()
+	if ok {
+		return schema, nil
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if schema, ok = s.byFields[key]; ok {
+		return schema, nil
+	}
+	return s.create(fields)
+}
+
+// Schemas returns all known schemas ordered by version
+func (s *FileStore) Schemas() []ports.Schema {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	schemas := make([]ports.Schema, 0, len(s.schemas))
+	for version := domain.FirstSchemaVersion; version < s.nextVersion; version++ {
+		if schema, ok := s.schemas[version]; ok {
+			schemas = append(schemas, schema)
+		}
+	}
+	return schemas
+}
+
+// create registers a new schema, must be called with the write lock held
+func (s *FileStore) create(fields []ports.SchemaField) (*Schema, error) {
+	schema, err := NewSchema(s.nextVersion, fields)
+	if err != nil {
+		return nil, err
+	}
+	s.add(schema)
+	if err := s.save(); err != nil {
+		delete(s.schemas, schema.Version)
+		delete(s.byFields, fieldsKey(schema.Fields()))
+		s.nextVersion--
+		return nil, err
+	}
+	return schema, nil
+}
+
+// add indexes the schema
+func (s *FileStore) add(schema *Schema) {
+	s.schemas[schema.Version] = schema
+	s.byFields[fieldsKey(schema.Fields())] = schema
+	if schema.Version >= s.nextVersion {
+		s.nextVersion = schema.Version + 1
+	}
+}
+
+// save writes all schemas to a temporary file and replaces the schema file with it, the file and the rename are
+// synced so a schema used by acknowledged records survives a crash
+func (s *FileStore) save() error {
+	content := fileContent{Schemas: make([]*Schema, 0, len(s.schemas))}
+	for _, schema := range s.schemas {
+		content.Schemas = append(content.Schemas, schema)
+	}
+	sort.Slice(content.Schemas, func(i, j int) bool {
+		return content.Schemas[i].Version < content.Schemas[j].Version
+	})
+	data, err := json.MarshalIndent(content, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := writeSynced(tmp, data); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return err
+	}
+	return syncDir(filepath.Dir(s.path))
+}
+
+// writeSynced writes the data to the file and syncs it to disk
+func writeSynced(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		return errors.Join(err, f.Close())
+	}
+	if err := f.Sync(); err != nil {
+		return errors.Join(err, f.Close())
+	}
+	return f.Close()
+}
+
+// syncDir syncs the directory so a file renamed into it is found after a crash
+func syncDir(path string) error {
+	dir, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	if err := dir.Sync(); err != nil {
+		return errors.Join(fmt.Errorf("failed to sync directory %s: %w", path, err), dir.Close())
+	}
+	return dir.Close()
+}
+
+// load reads the schemas from the schema file if it exists
+func (s *FileStore) load() error {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var content fileContent
+	if err := json.Unmarshal(data, &content); err != nil {
+		return fmt.Errorf("schema file %s: %w", s.path, err)
+	}
+	for _, schema := range content.Schemas {
+		if err := schema.buildIndex(); err != nil {
+			return err
+		}
+		s.add(schema)
+	}
+	return nil
+}
+
+// NewFileStore creates a new FileStore loading the schemas from the given file
+func NewFileStore(path string) (*FileStore, error) {
+	s := &FileStore{
+		path:        path,
+		nextVersion: domain.FirstSchemaVersion,
+		schemas:     make(map[uint64]*Schema),
+		byFields:    make(map[string]*Schema),
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
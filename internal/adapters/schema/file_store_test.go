@@ -0,0 +1,76 @@
+package schema_test
+
+import (
+	"LogDb/internal/adapters/schema"
+	"LogDb/internal/domain"
+	"LogDb/internal/ports"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveSchemaIsPersisted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schemas.json")
+	store, err := schema.NewFileStore(path)
+	require.NoError(t, err)
+
+	fields := []ports.SchemaField{
+		schema.NewField("host", domain.StringLabelType),
+		schema.NewField("method", domain.StringLabelType),
+	}
+	created, err := store.ResolveSchema(fields)
+	require.NoError(t, err)
+	require.Equal(t, domain.FirstSchemaVersion, created.ID())
+
+	resolved, err := store.ResolveSchema(fields)
+	require.NoError(t, err)
+	require.Equal(t, created.ID(), resolved.ID())
+
+	other, err := store.ResolveSchema(fields[1:])
+	require.NoError(t, err)
+	require.Equal(t, created.ID()+1, other.ID())
+
+	reloaded, err := schema.NewFileStore(path)
+	require.NoError(t, err)
+	require.Len(t, reloaded.Schemas(), 2)
+	loaded, err := reloaded.GetSchema(created.ID())
+	require.NoError(t, err)
+	idx, ok := loaded.FieldIndex("method")
+	require.True(t, ok)
+	require.Equal(t, 1, idx)
+
+	next, err := reloaded.ResolveSchema([]ports.SchemaField{schema.NewField("status", domain.IntLabelType)})
+	require.NoError(t, err)
+	require.Equal(t, other.ID()+1, next.ID())
+}
+
+func TestLabelNames(t *testing.T) {
+	store, err := schema.NewFileStore(filepath.Join(t.TempDir(), "schemas.json"))
+	require.NoError(t, err)
+	s, err := store.ResolveSchema([]ports.SchemaField{schema.NewField("host", domain.StringLabelType)})
+	require.NoError(t, err)
+
+	labels := []domain.Label{{Value: []byte("a")}, {Value: []byte("b")}}
+	require.Equal(t, []string{"host", "label_1"}, schema.LabelNames(store, &domain.LogRecord{SchemaVersion: s.ID(), Labels: labels}))
+	require.Equal(t, []string{"label_0", "label_1"}, schema.LabelNames(store, &domain.LogRecord{SchemaVersion: 1, Labels: labels}))
+}
+
+func TestSaveReplacesSchemaFile(t *testing.T) {
+	dir := t.TempDir()
+	store, err := schema.NewFileStore(filepath.Join(dir, "schemas.json"))
+	require.NoError(t, err)
+	for _, name := range []string{"host", "method"} {
+		_, err := store.ResolveSchema([]ports.SchemaField{schema.NewField(name, domain.StringLabelType)})
+		require.NoError(t, err)
+	}
+
+	// the temporary file is renamed over the schema file
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "schemas.json", entries[0].Name())
+	reloaded, err := schema.NewFileStore(filepath.Join(dir, "schemas.json"))
+	require.NoError(t, err)
+	require.Len(t, reloaded.Schemas(), 2)
+}
@@ -0,0 +1,90 @@
+package schema
+
+import (
+	"LogDb/internal/domain"
+	"LogDb/internal/internal_errors"
+	"LogDb/internal/ports"
+	"fmt"
+	"strings"
+)
+
+var _ ports.Schema = new(Schema)
+
+// Schema maps label positions to fields
+type Schema struct {
+	Version     uint64   `json:"id"`
+	FieldsList  []*Field `json:"fields"`
+	fieldsIndex map[string]int
+}
+
+// ID returns the schema version
+func (s *Schema) ID() uint64 {
+	return s.Version
+}
+
+// Fields returns the fields in label order
+func (s *Schema) Fields() []ports.SchemaField {
+	fields := make([]ports.SchemaField, len(s.FieldsList))
+	for i, field := range s.FieldsList {
+		fields[i] = field
+	}
+	return fields
+}
+
+// FieldIndex returns the label position of the field with the given name
+func (s *Schema) FieldIndex(name string) (int, bool) {
+	idx, ok := s.fieldsIndex[name]
+	return idx, ok
+}
+
+// buildIndex indexes the fields by name
+func (s *Schema) buildIndex() error {
+	s.fieldsIndex = make(map[string]int, len(s.FieldsList))
+	for i, field := range s.FieldsList {
+		if _, ok := s.fieldsIndex[field.FieldName]; ok {
+			return fmt.Errorf("%w: %s", internal_errors.SchemaFieldDuplicated, field.FieldName)
+		}
+		s.fieldsIndex[field.FieldName] = i
+	}
+	return nil
+}
+
+// NewSchema creates a new Schema with the given version and fields
+func NewSchema(version uint64, fields []ports.SchemaField) (*Schema, error) {
+	s := &Schema{Version: version, FieldsList: make([]*Field, len(fields))}
+	for i, field := range fields {
+		s.FieldsList[i] = NewField(field.Name(), field.Type())
+	}
+	if err := s.buildIndex(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// fieldsKey returns a key identifying a list of fields
+func fieldsKey(fields []ports.SchemaField) string {
+	var sb strings.Builder
+	for _, field := range fields {
+		sb.WriteString(fmt.Sprintf("%s:%d;", field.Name(), field.Type()))
+	}
+	return sb.String()
+}
+
+// LabelNames returns the names of the record labels, positional names are used when the schema is unknown
+func LabelNames(store ports.SchemaStore, record *domain.LogRecord) []string {
+	names := make([]string, len(record.Labels))
+	var fields []ports.SchemaField
+	if record.SchemaVersion >= domain.FirstSchemaVersion {
+		if s, err := store.GetSchema(record.SchemaVersion); err == nil {
+			fields = s.Fields()
+		}
+	}
+	for i := range record.Labels {
+		if i < len(fields) {
+			names[i] = fields[i].Name()
+		} else {
+			names[i] = domain.PositionalLabelName(i)
+		}
+	}
+	return names
+}
@@ -25,9 +25,9 @@ func (b *BinarySerializer) WriteLogRecord(record *domain.LogRecord, writer io.Wr
 	recordSize := uint64(domain.RecordMetaSize) + labelsSize + messageSize
 
 	recordMetaData := domain.RecordMeta{
-		Timestamp:     uint64(record.Timestamp.Unix()),
+		Timestamp:     record.MetaTimestamp(),
 		RecordSize:    recordSize,
-		SchemaVersion: 1,
+		SchemaVersion: record.SchemaVersion,
 		LabelsSize:    labelsSize,
 		LabelsCount:   labelsCount,
 		MessageSize:   messageSize,
@@ -129,6 +129,26 @@ func (b *BinarySerializer) ReadFileHeader(header *domain.DataFileHeader, reader
 	return domain.DataFileHeaderSize, binary.Read(reader, binary.LittleEndian, header)
 }
 
+// WriteDataPageDirectory writes data page directory entries to writer
+func (b *BinarySerializer) WriteDataPageDirectory(directory *domain.DataPageDirectory, writer io.Writer) (int, error) {
+	return domain.DataPageDirectorySize, binary.Write(writer, binary.LittleEndian, &directory.Entries)
+}
+
+// ReadDataPageDirectory reads data page directory entries from reader
+func (b *BinarySerializer) ReadDataPageDirectory(directory *domain.DataPageDirectory, reader io.Reader) (int, error) {
+	return domain.DataPageDirectorySize, binary.Read(reader, binary.LittleEndian, &directory.Entries)
+}
+
+// WriteDataPageChecksums writes data page checksums to writer
+func (b *BinarySerializer) WriteDataPageChecksums(directory *domain.DataPageDirectory, writer io.Writer) (int, error) {
+	return domain.DataPageChecksumsSize, binary.Write(writer, binary.LittleEndian, &directory.Checksums)
+}
+
+// ReadDataPageChecksums reads data page checksums from reader
+func (b *BinarySerializer) ReadDataPageChecksums(directory *domain.DataPageDirectory, reader io.Reader) (int, error) {
+	return domain.DataPageChecksumsSize, binary.Read(reader, binary.LittleEndian, &directory.Checksums)
+}
+
 // WriteWALHeader writes WAL header to writer
 func (b *BinarySerializer) WriteWALHeader(header *domain.WALHeader, writer io.Writer) (int, error) {
 	return domain.WALHeaderSize, binary.Write(writer, binary.LittleEndian, header)
@@ -1,17 +1,31 @@
 package transformers
 
-import "LogDb/internal/domain"
+import (
+	"LogDb/internal/domain"
+	"encoding/binary"
+	"math"
+)
 
 func StringToLabel(value string) domain.Label {
 	return domain.Label{Type: domain.StringLabelType, Value: []byte(value), Size: uint64(len(value))}
 }
 
 func IntToLabel(value int) domain.Label {
-	return domain.Label{Type: domain.IntLabelType, Value: []byte{byte(value)}, Size: 1}
+	return Int64ToLabel(int64(value))
 }
 
+// Int64ToLabel encodes the value as 8 bytes little endian
+func Int64ToLabel(value int64) domain.Label {
+	buf := make([]byte, 8)
+	binary.LittleEndian.PutUint64(buf, uint64(value))
+	return domain.Label{Type: domain.IntLabelType, Value: buf, Size: 8}
+}
+
+// FloatToLabel encodes the IEEE 754 bits of the value as 8 bytes little endian
 func FloatToLabel(value float64) domain.Label {
-	return domain.Label{Type: domain.FloatLabelType, Value: []byte{byte(value)}, Size: 1}
+	buf := make([]byte, 8)
+	binary.LittleEndian.PutUint64(buf, math.Float64bits(value))
+	return domain.Label{Type: domain.FloatLabelType, Value: buf, Size: 8}
 }
 
 func LabelToString(label domain.Label) string {
@@ -19,9 +33,15 @@ func LabelToString(label domain.Label) string {
 }
 
 func LabelToInt(label domain.Label) int {
-	return int(label.Value[0])
+	if len(label.Value) < 8 {
+		return 0
+	}
+	return int(int64(binary.LittleEndian.Uint64(label.Value)))
 }
 
 func LabelToFloat(label domain.Label) float64 {
-	return float64(label.Value[0])
+	if len(label.Value) < 8 {
+		return 0
+	}
+	return math.Float64frombits(binary.LittleEndian.Uint64(label.Value))
 }
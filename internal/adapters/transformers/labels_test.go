@@ -0,0 +1,45 @@
+package transformers
+
+import (
+	"LogDb/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+// TestLabels_RoundTrip tests that the values encoded in labels are decoded unchanged
+func TestLabels_RoundTrip(t *testing.T) {
+	for _, value := range []int64{0, 1, -1, 42, -42, math.MaxInt32 + 1, math.MinInt64, math.MaxInt64} {
+		label := Int64ToLabel(value)
+		assert.Equal(t, domain.IntLabelType, label.Type)
+		assert.Equal(t, uint64(8), label.Size)
+		assert.Equal(t, int(value), LabelToInt(label), "%d", value)
+	}
+	for _, value := range []int{0, -7, 1 << 40} {
+		assert.Equal(t, Int64ToLabel(int64(value)), IntToLabel(value))
+	}
+
+	for _, value := range []float64{0, 0.5, -0.5, 3.14159, -1e-300, math.MaxFloat64, math.SmallestNonzeroFloat64, math.Inf(1), math.Inf(-1)} {
+		label := FloatToLabel(value)
+		assert.Equal(t, domain.FloatLabelType, label.Type)
+		assert.Equal(t, uint64(8), label.Size)
+		assert.Equal(t, value, LabelToFloat(label), "%g", value)
+	}
+	assert.True(t, math.IsNaN(LabelToFloat(FloatToLabel(math.NaN()))))
+	assert.True(t, math.Signbit(LabelToFloat(FloatToLabel(math.Copysign(0, -1)))))
+
+	for _, value := range []string{"", "api", "été", "-1.5"} {
+		label := StringToLabel(value)
+		assert.Equal(t, domain.StringLabelType, label.Type)
+		assert.Equal(t, uint64(len(value)), label.Size)
+		assert.Equal(t, value, LabelToString(label))
+	}
+}
+
+// TestLabels_Truncated tests that numeric labels shorter than 8 bytes decode as zero
+func TestLabels_Truncated(t *testing.T) {
+	label := domain.Label{Type: domain.IntLabelType, Value: []byte{1, 2, 3}, Size: 3}
+	assert.Zero(t, LabelToInt(label))
+	label.Type = domain.FloatLabelType
+	assert.Zero(t, LabelToFloat(label))
+}
@@ -0,0 +1,55 @@
+package wal
+
+import (
+	"LogDb/internal/domain"
+	"LogDb/internal/ports"
+)
+
+var _ ports.HeapChunk = &TrackedChunk{}
+
+// TrackedChunk counts the records of a chunk per WAL file they were stored in.
+type TrackedChunk struct {
+	ports.HeapChunk
+	memTable *DurableMemTable
+	segments map[uint64]int
+}
+
+// Add a new log record to the heap, the record is counted for the WAL file it is being added from.
+func (c *TrackedChunk) Add(record *domain.LogRecord) error {
+	if err := c.HeapChunk.Add(record); err != nil {
+		return err
+	}
+	c.segments[c.memTable.segment]++
+	return nil
+}
+
+var _ ports.Flushable = &CheckpointFlusher{}
+
+// CheckpointFlusher releases the WAL files of a chunk once the chunk is flushed. The flushable closes its
+// data files and propagates DataFileCreated before FlushChunk returns, so the records are persisted and indexed.
+type CheckpointFlusher struct {
+	wal       *V1Writer
+	flushable ports.Flushable
+}
+
+// FlushChunk Flush a chunk of log records to persistent storage and release its records in the WAL.
+// The records of a chunk that failed stay in the WAL and are replayed on the next start.
+func (f *CheckpointFlusher) FlushChunk(chunk ports.HeapChunk) error {
+	if err := f.flushable.FlushChunk(chunk); err != nil {
+		return err
+	}
+	if tracked, ok := chunk.(*TrackedChunk); ok {
+		return f.wal.Release(tracked.segments)
+	}
+	return nil
+}
+
+// Close closes the data file writer
+func (f *CheckpointFlusher) Close() error {
+	return f.flushable.Close()
+}
+
+// NewCheckpointFlusher creates a new CheckpointFlusher releasing the WAL files of the flushed chunks
+func NewCheckpointFlusher(wal *V1Writer, flushable ports.Flushable) ports.Flushable {
+	return &CheckpointFlusher{wal: wal, flushable: flushable}
+}
@@ -0,0 +1,108 @@
+package wal
+
+import (
+	"LogDb/internal/domain"
+	"LogDb/internal/ports"
+	"errors"
+	"sync"
+)
+
+var _ ports.MemTable = &DurableMemTable{}
+
+// DurableMemTable stores every log record in the WAL before it is added to the MemTable,
+// a record is acknowledged only after it is synced according to the sync policy of the WAL.
+type DurableMemTable struct {
+	mu       sync.Mutex
+	wal      *V1Writer
+	memTable ports.MemTable
+	segment  uint64 // WAL file of the record being added to the MemTable
+}
+
+// Add stores the log record in the WAL and adds it to the MemTable.
+func (d *DurableMemTable) Add(record *domain.LogRecord) error {
+	_, err := d.AddBatch([]*domain.LogRecord{record})
+	return err
+}
+
+// AddBatch stores the log records in the WAL and adds them to the MemTable, the WAL is synced once for the batch.
+// It returns how many records were acknowledged, when a record fails the records added before it are still synced
+// and acknowledged.
+func (d *DurableMemTable) AddBatch(records []*domain.LogRecord) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	added, err := d.addBatch(records)
+	if d.wal.cfg.SyncPolicy == SyncPerBatch && added > 0 {
+		if syncErr := d.wal.Flush(); syncErr != nil {
+			// the added records are in the MemTable but not synced, none of them is acknowledged
+			return 0, errors.Join(err, syncErr)
+		}
+	}
+	return added, err
+}
+
+// addBatch stores the log records in the WAL and adds them to the MemTable until a record fails, it returns how many
+// records were added
+func (d *DurableMemTable) addBatch(records []*domain.LogRecord) (int, error) {
+	for i, record := range records {
+		segment, err := d.wal.append(record)
+		if err != nil {
+			return i, err
+		}
+		d.segment = segment
+		if err := d.memTable.Add(record); err != nil {
+			// the record is not acknowledged, it must not hold the WAL file back
+			return i, errors.Join(err, d.wal.Release(map[uint64]int{segment: 1}))
+		}
+	}
+	return len(records), nil
+}
+
+// RotateChunk Mark the current active chunk as read-only and create a new chunk.
+func (d *DurableMemTable) RotateChunk() {
+	d.memTable.RotateChunk()
+}
+
+// Flush all immutable chunks asynchronously.
+func (d *DurableMemTable) Flush() {
+	d.memTable.Flush()
+}
+
+// IsFull Check if the MemTable is full based on memory usage or record count.
+func (d *DurableMemTable) IsFull() bool {
+	return d.memTable.IsFull()
+}
+
+// Snapshot returns the records of the MemTable that may not be stored in data files yet.
+func (d *DurableMemTable) Snapshot() *domain.MemTableSnapshot {
+	return d.memTable.Snapshot()
+}
+
+// FlushSequence returns the number of chunks of the MemTable that started flushing.
+func (d *DurableMemTable) FlushSequence() uint64 {
+	return d.memTable.FlushSequence()
+}
+
+// Close stops accepting records and flushes the MemTable, the flushed records are released in the WAL.
+func (d *DurableMemTable) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.memTable.Close()
+}
+
+// NewChunk wraps the chunks of the MemTable to track the WAL files of their records, see NewCheckpointFlusher.
+func (d *DurableMemTable) NewChunk(newChunk func(maxSize, maxRecords int) ports.HeapChunk) func(maxSize, maxRecords int) ports.HeapChunk {
+	return func(maxSize, maxRecords int) ports.HeapChunk {
+		return &TrackedChunk{HeapChunk: newChunk(maxSize, maxRecords), memTable: d, segments: make(map[uint64]int)}
+	}
+}
+
+// Bind sets the MemTable the records are added to, its chunks must be created with NewChunk.
+func (d *DurableMemTable) Bind(memTable ports.MemTable) {
+	d.memTable = memTable
+}
+
+// NewDurableMemTable creates a new DurableMemTable writing to the WAL, the MemTable is set with Bind.
+func NewDurableMemTable(wal *V1Writer) *DurableMemTable {
+	return &DurableMemTable{wal: wal}
+}
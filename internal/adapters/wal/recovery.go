@@ -0,0 +1,273 @@
+package wal
+
+import (
+	"LogDb/internal/domain"
+	"LogDb/internal/domain/query_types"
+	"LogDb/internal/internal_errors"
+	"LogDb/internal/ports"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"math"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// quarantineDir is the directory inside the WAL directory for WAL files that cannot be replayed
+const quarantineDir = "quarantine"
+
+// RecoveryReport summarizes the replay of the WAL files found on startup.
+type RecoveryReport struct {
+	Segments    int // WAL files found on startup
+	Replayed    int // WAL files replayed and removed
+	Quarantined int // WAL files moved to the quarantine directory
+	Records     int // Records read from the WAL files
+	Duplicates  int // Records skipped because they are already stored in data files
+	Restored    int // Records flushed to data files
+	TornRecords int // Torn records at the end of dirty WAL files
+}
+
+// Recover replays the WAL files found on startup oldest first, it must run before records are stored.
+// Every file is read and validated, the records already stored in data files are skipped and the rest
+// is flushed as one chunk through the flushable before the file is removed. Files that cannot be read
+// are moved to the quarantine directory for the operator.
+func (w *V1Writer) Recover(storage ports.DataStorageReadable, flushable ports.Flushable, newChunk func(maxSize, maxRecords int) ports.HeapChunk) (*RecoveryReport, error) {
+	report := &RecoveryReport{Segments: len(w.recovered)}
+	seen := make(map[domain.RecordFingerprint]int)
+	for len(w.recovered) > 0 {
+		fileName := w.recovered[0]
+		records, torn, err := w.readSegment(fileName)
+		if err != nil {
+			log.WithError(err).Errorf("WAL file %s cannot be replayed, it is quarantined", fileName)
+			if err := w.quarantine(fileName); err != nil {
+				return report, fmt.Errorf("failed to quarantine WAL file %s: %w", fileName, err)
+			}
+			report.Quarantined++
+			w.recovered = w.recovered[1:]
+			continue
+		}
+		report.Records += len(records)
+		report.TornRecords += torn
+
+		records, duplicates, err := deduplicate(storage, records, seen)
+		if err != nil {
+			return report, fmt.Errorf("failed to replay WAL file %s: %w", fileName, err)
+		}
+		report.Duplicates += duplicates
+
+		chunk := newChunk(math.MaxInt, math.MaxInt)
+		for _, record := range records {
+			if err := chunk.Add(record); err != nil {
+				return report, fmt.Errorf("failed to replay WAL file %s: %w", fileName, err)
+			}
+		}
+		chunk.MakeImmutable()
+		log.Infof("Replaying %d records of WAL file %s, %d records are already stored", len(records), fileName, duplicates)
+		if chunk.Size() > 0 {
+			if err := flushable.FlushChunk(chunk); err != nil {
+				return report, fmt.Errorf("failed to replay WAL file %s: %w", fileName, err)
+			}
+		}
+		report.Restored += len(records)
+		if err := os.Remove(fileName); err != nil {
+			return report, err
+		}
+		report.Replayed++
+		w.recovered = w.recovered[1:]
+	}
+	log.WithFields(log.Fields{
+		"segments":     report.Segments,
+		"replayed":     report.Replayed,
+		"quarantined":  report.Quarantined,
+		"records":      report.Records,
+		"duplicates":   report.Duplicates,
+		"restored":     report.Restored,
+		"torn_records": report.TornRecords,
+	}).Info("WAL recovery finished")
+	return report, nil
+}
+
+// quarantine moves the WAL file to the quarantine directory, it is not replayed again
+func (w *V1Writer) quarantine(fileName string) error {
+	dir := path.Join(w.cfg.BaseDir, quarantineDir)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	return os.Rename(fileName, path.Join(dir, path.Base(fileName)))
+}
+
+// readSegment reads and validates every record of the WAL file and returns the number of torn records.
+// A torn record at the end of a dirty file is the last write before a crash, it was never acknowledged and is skipped.
+// Sealed files were synced before they were renamed, any record that cannot be read makes the file invalid.
+func (w *V1Writer) readSegment(fileName string) ([]*domain.LogRecord, int, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, 0, err
+	}
+	dirty := strings.HasSuffix(fileName, w.cfg.DirtyFileExt)
+
+	reader := bufio.NewReader(file)
+	header := &domain.WALHeader{}
+	if _, err := w.codec.ReadWALHeader(header, reader); err != nil {
+		if dirty && (errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)) {
+			// the file was created but the header was not written
+			return nil, 0, nil
+		}
+		return nil, 0, err
+	}
+	if header.Version != domain.WALVersionRecordFrames {
+		return nil, 0, fmt.Errorf("%w: %d", internal_errors.WALVersionNotSupported, header.Version)
+	}
+
+	var records []*domain.LogRecord
+	offset := int64(domain.WALHeaderSize)
+	for offset < stat.Size() {
+		record, size, err := w.readRecord(reader, stat.Size()-offset)
+		if errors.Is(err, io.ErrUnexpectedEOF) && dirty {
+			log.Warnf("WAL file %s ends with a torn record at offset %d", fileName, offset)
+			return records, 1, nil
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("%w: offset %d: %w", internal_errors.WALRecordCorrupted, offset, err)
+		}
+		records = append(records, record)
+		offset += int64(size)
+	}
+	return records, 0, nil
+}
+
+// readRecord reads the next framed log record and returns it with the size of the frame.
+// A frame that does not fit in the remaining bytes of the file or that is the last one and does not match
+// its checksum is reported as io.ErrUnexpectedEOF.
+func (w *V1Writer) readRecord(reader io.Reader, remaining int64) (*domain.LogRecord, int, error) {
+	var header domain.WALRecordHeader
+	if err := binary.Read(reader, binary.LittleEndian, &header); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, 0, err
+	}
+	size := int64(domain.WALRecordHeaderSize) + int64(header.Size)
+	if size > remaining {
+		return nil, 0, io.ErrUnexpectedEOF
+	}
+	payload := make([]byte, header.Size)
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, 0, err
+	}
+	if domain.Checksum(payload) != header.Checksum {
+		if size == remaining {
+			// the frame was partially written
+			return nil, 0, fmt.Errorf("%w: %w", io.ErrUnexpectedEOF, internal_errors.WALRecordChecksumMismatch)
+		}
+		return nil, 0, internal_errors.WALRecordChecksumMismatch
+	}
+	record, err := readLogRecord(w.codec, bytes.NewReader(payload))
+	if err != nil {
+		return nil, 0, err
+	}
+	return record, int(size), nil
+}
+
+// deduplicate removes the records already stored in data files, they were flushed before the WAL file was removed.
+// Identical records are counted, seen holds the records of the WAL files processed before, they match stored records
+// too: either they were already stored or they were replayed.
+func deduplicate(storage ports.DataStorageReadable, records []*domain.LogRecord, seen map[domain.RecordFingerprint]int) ([]*domain.LogRecord, int, error) {
+	if len(records) == 0 {
+		return records, 0, nil
+	}
+	existing := newExistingRecords(records)
+	if _, err := storage.Query(context.Background(), existing); err != nil {
+		return nil, 0, err
+	}
+	if existing.Error() != nil {
+		return nil, 0, existing.Error()
+	}
+	kept := records[:0]
+	for _, record := range records {
+		key := record.Fingerprint()
+		seen[key]++
+		if existing.fingerprints[key] >= seen[key] {
+			continue
+		}
+		kept = append(kept, record)
+	}
+	return kept, len(records) - len(kept), nil
+}
+
+var _ ports.PreparedQuery = &existingRecords{}
+
+// existingRecords is a query collecting the fingerprints of the stored records in the time range of WAL records
+type existingRecords struct {
+	from         uint64
+	to           uint64
+	wanted       map[domain.RecordFingerprint]struct{}
+	fingerprints map[domain.RecordFingerprint]int
+	usage        domain.QueryUsage
+	err          error
+}
+
+// newExistingRecords creates a query for the time range of the records
+func newExistingRecords(records []*domain.LogRecord) *existingRecords {
+	q := &existingRecords{
+		from:         math.MaxUint64,
+		wanted:       make(map[domain.RecordFingerprint]struct{}, len(records)),
+		fingerprints: make(map[domain.RecordFingerprint]int),
+	}
+	for _, record := range records {
+		timestamp := uint64(record.Timestamp.UnixNano())
+		q.from = min(q.from, timestamp)
+		q.to = max(q.to, timestamp)
+		q.wanted[record.Fingerprint()] = struct{}{}
+	}
+	return q
+}
+
+func (q *existingRecords) IsBefore(timestamp uint64) bool   { return timestamp < q.from }
+func (q *existingRecords) IsAfter(timestamp uint64) bool    { return timestamp > q.to }
+func (q *existingRecords) FromDateTime() uint64             { return q.from }
+func (q *existingRecords) ToDateTime() uint64               { return q.to }
+func (q *existingRecords) Order() query_types.Order         { return query_types.Ascending }
+func (q *existingRecords) IsSatisfied(from, to uint64) bool { return false }
+func (q *existingRecords) After() *domain.Cursor            { return nil }
+func (q *existingRecords) Concurrency() int                 { return 1 }
+func (q *existingRecords) Timeout() time.Duration           { return 0 }
+func (q *existingRecords) Budget() domain.QueryBudget       { return domain.QueryBudget{} }
+func (q *existingRecords) Usage() *domain.QueryUsage        { return &q.usage }
+func (q *existingRecords) Explain() bool                    { return false }
+func (q *existingRecords) Filters() []string                { return nil }
+func (q *existingRecords) Begin()                           {}
+func (q *existingRecords) Skip()                            {}
+func (q *existingRecords) End()                             {}
+func (q *existingRecords) SetError(err error)               { q.err = err }
+func (q *existingRecords) Error() error                     { return q.err }
+func (q *existingRecords) Stream(ports.RecordSink)          {}
+
+// Next counts the stored record when it is one of the WAL records
+func (q *existingRecords) Next(record *domain.LogRecord) error {
+	key := record.Fingerprint()
+	if _, ok := q.wanted[key]; ok {
+		q.fingerprints[key]++
+	}
+	return nil
+}
+
+// Result returns an empty result, the fingerprints are kept in the query
+func (q *existingRecords) Result() (*domain.QueryResult, error) {
+	return domain.NewQueryResult(nil), q.err
+}
@@ -0,0 +1,130 @@
+package wal
+
+import (
+	"LogDb/internal/adapters/serializer"
+	"LogDb/internal/domain"
+	"LogDb/internal/ports"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// storedRecords is a storage returning the given records to every query
+type storedRecords []*domain.LogRecord
+
+func (s storedRecords) Query(ctx context.Context, query ports.PreparedQuery) (*domain.QueryResult, error) {
+	for _, record := range s {
+		timestamp := uint64(record.Timestamp.UnixNano())
+		if query.IsBefore(timestamp) || query.IsAfter(timestamp) {
+			continue
+		}
+		if err := query.Next(record); err != nil {
+			return nil, err
+		}
+	}
+	return query.Result()
+}
+
+func (s storedRecords) Close() error {
+	return nil
+}
+
+// writeTestWAL writes the records to WAL files of 4 records and leaves the last one dirty
+func writeTestWAL(t *testing.T, dir string, records []*domain.LogRecord) []string {
+	w, err := NewWALRepository(&V1WriterConfig{BaseDir: dir, MaxRecords: 4, SyncPolicy: SyncPerRequest}, serializer.Default, nil)
+	require.NoError(t, err)
+	for _, record := range records {
+		require.NoError(t, w.StoreRecord(record))
+	}
+	return walFiles(t, dir)
+}
+
+// appendBytes appends raw bytes to the WAL file
+func appendBytes(t *testing.T, fileName string, data []byte) {
+	f, err := os.OpenFile(fileName, os.O_WRONLY|os.O_APPEND, 0)
+	require.NoError(t, err)
+	_, err = f.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+}
+
+// TestV1Writer_Recover tests that records of a WAL that was never closed are replayed and a torn tail is skipped
+func TestV1Writer_Recover(t *testing.T) {
+	dir := t.TempDir()
+	files := writeTestWAL(t, dir, testRecords(10))
+	require.Len(t, files, 3)
+	// the process crashed in the middle of the next write
+	appendBytes(t, files[2], []byte{50, 0, 0, 0, 1, 2})
+
+	recovered, err := NewWALRepository(&V1WriterConfig{BaseDir: dir}, serializer.Default, nil)
+	require.NoError(t, err)
+	defer recovered.Close()
+	flusher := &collectFlusher{}
+	report, err := recovered.Recover(storedRecords{}, flusher, newTestChunk)
+	require.NoError(t, err)
+
+	assert.Equal(t, RecoveryReport{Segments: 3, Replayed: 3, Records: 10, Restored: 10, TornRecords: 1}, *report)
+	require.Len(t, flusher.records, 10)
+	for i, record := range testRecords(10) {
+		assert.Equal(t, record.Timestamp.UnixNano(), flusher.records[i].Timestamp.UnixNano())
+		assert.Equal(t, record.Message, flusher.records[i].Message)
+	}
+	assert.Len(t, walFiles(t, dir), 1)
+}
+
+// TestV1Writer_Recover_Quarantine tests that WAL files that cannot be read are moved to the quarantine directory
+func TestV1Writer_Recover_Quarantine(t *testing.T) {
+	dir := t.TempDir()
+	files := writeTestWAL(t, dir, testRecords(10))
+	// a sealed file must not end with a torn record
+	appendBytes(t, files[0], []byte{50, 0, 0, 0, 1, 2})
+	// a record in the middle of a file does not match its checksum
+	data, err := os.ReadFile(files[1])
+	require.NoError(t, err)
+	data[domain.WALHeaderSize+domain.WALRecordHeaderSize+1] ^= 0xff
+	require.NoError(t, os.WriteFile(files[1], data, 0600))
+
+	recovered, err := NewWALRepository(&V1WriterConfig{BaseDir: dir}, serializer.Default, nil)
+	require.NoError(t, err)
+	defer recovered.Close()
+	flusher := &collectFlusher{}
+	report, err := recovered.Recover(storedRecords{}, flusher, newTestChunk)
+	require.NoError(t, err)
+
+	assert.Equal(t, RecoveryReport{Segments: 3, Replayed: 1, Quarantined: 2, Records: 2, Restored: 2}, *report)
+	assert.Len(t, flusher.records, 2)
+	quarantined, err := filepath.Glob(filepath.Join(dir, quarantineDir, "*"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, quarantineDir, filepath.Base(files[0])), filepath.Join(dir, quarantineDir, filepath.Base(files[1]))}, quarantined)
+}
+
+// TestV1Writer_Recover_Deduplicate tests that records already stored in data files are not replayed again
+func TestV1Writer_Recover_Deduplicate(t *testing.T) {
+	dir := t.TempDir()
+	records := testRecords(10)
+	// the same record was written twice and stored once
+	records = append(records, testRecords(1)...)
+	writeTestWAL(t, dir, records)
+
+	stored := storedRecords(testRecords(6))
+	// same timestamp with another message
+	stored = append(stored, &domain.LogRecord{Timestamp: records[7].Timestamp, SchemaVersion: 1, Message: []byte("other")})
+
+	recovered, err := NewWALRepository(&V1WriterConfig{BaseDir: dir}, serializer.Default, nil)
+	require.NoError(t, err)
+	defer recovered.Close()
+	flusher := &collectFlusher{}
+	report, err := recovered.Recover(stored, flusher, newTestChunk)
+	require.NoError(t, err)
+
+	assert.Equal(t, RecoveryReport{Segments: 3, Replayed: 3, Records: 11, Duplicates: 6, Restored: 5}, *report)
+	// every WAL file is flushed as one chunk
+	expected := []*domain.LogRecord{records[6], records[7], records[0], records[8], records[9]}
+	require.Len(t, flusher.records, len(expected))
+	for i, record := range expected {
+		assert.Equal(t, record.Timestamp.UnixNano(), flusher.records[i].Timestamp.UnixNano())
+	}
+}
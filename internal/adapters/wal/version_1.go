@@ -2,13 +2,18 @@ package wal
 
 import (
 	"LogDb/internal/domain"
+	"LogDb/internal/internal_errors"
 	"LogDb/internal/ports"
-	"errors"
+	"bytes"
+	"encoding/binary"
 	"fmt"
-	"log"
+	log "github.com/sirupsen/logrus"
+	"io"
 	"os"
 	"path"
 	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
@@ -21,81 +26,150 @@ const (
 	dirtyFileExt  = ".wal.dirty"      // Dirty WAL file path
 )
 
+// SyncPolicy defines when the records written to the WAL file are synced to the disk.
+type SyncPolicy uint8
+
+const (
+	// SyncPerRequest syncs the WAL file before every stored record is acknowledged
+	SyncPerRequest SyncPolicy = iota
+	// SyncPerBatch syncs the WAL file once per batch of records before the batch is acknowledged, see Flush
+	SyncPerBatch
+	// SyncOnInterval syncs the WAL file every FlushInterval, records written since the last sync can be lost on a crash
+	SyncOnInterval
+)
+
+// String returns the string representation of the sync policy
+func (p SyncPolicy) String() string {
+	switch p {
+	case SyncPerRequest:
+		return "request"
+	case SyncPerBatch:
+		return "batch"
+	case SyncOnInterval:
+		return "interval"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseSyncPolicy returns the sync policy with the given name: request, batch or interval
+func ParseSyncPolicy(name string) (SyncPolicy, error) {
+	for _, policy := range []SyncPolicy{SyncPerRequest, SyncPerBatch, SyncOnInterval} {
+		if policy.String() == name {
+			return policy, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown WAL sync policy %q, expected request, batch or interval", name)
+}
+
 // V1Callback is a callback function that is called when a WAL file is flushed.
 type V1Callback func(fileName string)
 
 type V1WriterConfig struct {
 	BaseDir       string
-	MaxSize       int
-	MaxRecords    int
-	FlushInterval time.Duration
+	MaxSize       int           // Size of a WAL file before the next one is started
+	MaxRecords    int           // Number of records of a WAL file before the next one is started
+	FlushInterval time.Duration // Sync interval of SyncOnInterval
 	FileExt       string
 	DirtyFileExt  string
+	SyncPolicy    SyncPolicy
 }
 
+// segment tracks the records of a WAL file that are not persisted in data files yet
+type segment struct {
+	sequence uint64
+	pending  int
+	sealed   bool
+}
+
+var _ ports.WALRepository = &V1Writer{}
+
 // V1Writer implements the WALRepository interface.
+// Records are appended to the dirty WAL file as they are stored, a full WAL file is sealed and the next one is started.
+// Sealed WAL files are removed once all their records are persisted in data files, see Release.
 type V1Writer struct {
 	cfg             *V1WriterConfig
 	header          *domain.WALHeader
 	codec           ports.Serializer
-	logRecords      []*domain.LogRecord // Buffer for log records
-	mu              sync.Mutex          // Mutex to protect the WAL
-	file            *os.File            // WAL file
-	timer           *time.Timer         // Timer for automatic flushing
-	walSize         int                 // Current size of the WAL
-	recordCount     int                 // Current number of records
-	onFlushCallback V1Callback          // Callback function
+	mu              sync.Mutex   // Mutex to protect the WAL
+	file            *os.File     // WAL file
+	buf             bytes.Buffer // Buffer for a serialized log record
+	sequence        uint64       // Sequence number of the current WAL file
+	walSize         int          // Current size of the WAL
+	recordCount     int          // Current number of records
+	unsynced        bool         // Records were written since the last sync
+	segments        []*segment   // WAL files with records that are not persisted yet, oldest first
+	recovered       []string     // WAL files found on startup, replayed by Recover
+	closed          bool
+	done            chan struct{}
+	onFlushCallback V1Callback // Callback function
 }
 
-// discover scans the base directory for existing WAL files
+// discover scans the base directory for existing WAL files, they are replayed by Recover
 func (w *V1Writer) discover() error {
 	files, err := os.ReadDir(w.cfg.BaseDir)
-	wals := make([]string, 0)
-	dirtyWals := make([]string, 0)
-
 	if err != nil {
 		return err
 	}
+	sequences := make(map[string]uint64)
 	for _, file := range files {
 		if file.IsDir() {
 			continue
 		}
-		if path.Ext(file.Name()) == w.cfg.DirtyFileExt {
-			log.Printf("Dirty WAL file: %s", file.Name())
-			dirtyWals = append(dirtyWals, file.Name())
+		sequence, ok := w.parseFileName(file.Name())
+		if !ok {
 			continue
 		}
-		if path.Ext(file.Name()) == w.cfg.FileExt {
-			wals = append(wals, file.Name())
-			continue
+		if strings.HasSuffix(file.Name(), w.cfg.DirtyFileExt) {
+			log.Warnf("Dirty WAL file %s was not closed, it is replayed", file.Name())
 		}
+		w.recovered = append(w.recovered, path.Join(w.cfg.BaseDir, file.Name()))
+		sequences[w.recovered[len(w.recovered)-1]] = sequence
+		w.sequence = max(w.sequence, sequence)
 	}
-	if len(dirtyWals) > 0 {
-		return errors.New("found dirty WAL files, please recover them first")
-	}
-	for _, wal := range wals {
-		w.onFlushCallback(wal)
-	}
+	// Process the WAL files in order oldest to newest
+	sort.Slice(w.recovered, func(i, j int) bool {
+		return sequences[w.recovered[i]] < sequences[w.recovered[j]]
+	})
 	return nil
 }
 
+// parseFileName returns the sequence number of the WAL file
+func (w *V1Writer) parseFileName(name string) (uint64, bool) {
+	for _, ext := range []string{w.cfg.DirtyFileExt, w.cfg.FileExt} {
+		if strings.HasSuffix(name, ext) {
+			sequence, err := strconv.ParseUint(strings.TrimSuffix(name, ext), 10, 64)
+			return sequence, err == nil
+		}
+	}
+	return 0, false
+}
+
 // reset
 func (w *V1Writer) reset() {
-	w.logRecords = w.logRecords[:0]
 	w.walSize = 0
 	w.recordCount = 0
-	w.timer.Reset(w.cfg.FlushInterval)
+	w.unsynced = false
 }
 
-// new
+// new starts the next WAL file
 func (w *V1Writer) new() error {
 	var err error
+	w.sequence++
 	w.header = domain.NewWALHeader()
-	w.file, err = os.OpenFile(w.dirtyFileName(), os.O_CREATE|os.O_RDWR, 0600)
-	return err
+	w.file, err = os.OpenFile(w.dirtyFileName(), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := w.codec.WriteWALHeader(w.header, w.file); err != nil {
+		return err
+	}
+	w.segments = append(w.segments, &segment{sequence: w.sequence})
+	w.reset()
+	return nil
 }
 
-// markDone
+// markDone seals the current WAL file
 func (w *V1Writer) markDone() error {
 	if err := w.file.Sync(); err != nil {
 		return err
@@ -103,20 +177,24 @@ func (w *V1Writer) markDone() error {
 	if err := w.file.Close(); err != nil {
 		return err
 	}
-	if err := os.Rename(w.dirtyFileName(), w.fileName()); err != nil {
+	if err := os.Rename(w.dirtyFileName(), w.fileName(w.sequence)); err != nil {
 		return err
 	}
+	w.segments[len(w.segments)-1].sealed = true
+	if w.onFlushCallback != nil {
+		w.onFlushCallback(w.fileName(w.sequence))
+	}
 	return nil
 }
 
 // fileName
-func (w *V1Writer) fileName() string {
-	return path.Join(w.cfg.BaseDir, fmt.Sprintf("%d%s", w.header.CreatedAt, w.cfg.FileExt))
+func (w *V1Writer) fileName(sequence uint64) string {
+	return path.Join(w.cfg.BaseDir, fmt.Sprintf("%020d%s", sequence, w.cfg.FileExt))
 }
 
 // dirtyFileName
 func (w *V1Writer) dirtyFileName() string {
-	return path.Join(w.cfg.BaseDir, fmt.Sprintf("%d%s", w.header.CreatedAt, w.cfg.DirtyFileExt))
+	return path.Join(w.cfg.BaseDir, fmt.Sprintf("%020d%s", w.sequence, w.cfg.DirtyFileExt))
 }
 
 // NewWALRepository creates a new WAL datafile with the given WAL file.
@@ -144,12 +222,9 @@ func NewWALRepository(cfg *V1WriterConfig, codec ports.Serializer, callback V1Ca
 
 	repo := &V1Writer{
 		cfg:             cfg,
-		walSize:         0,
-		recordCount:     0,
-		timer:           time.NewTimer(cfg.FlushInterval),
-		logRecords:      make([]*domain.LogRecord, 0),
 		onFlushCallback: callback,
 		codec:           codec,
+		done:            make(chan struct{}),
 	}
 	if _, err := os.Stat(cfg.BaseDir); os.IsNotExist(err) {
 		if err := os.MkdirAll(cfg.BaseDir, 0700); err != nil {
@@ -160,81 +235,177 @@ func NewWALRepository(cfg *V1WriterConfig, codec ports.Serializer, callback V1Ca
 	if err != nil {
 		return nil, err
 	}
-	repo.new()
+	if err := repo.new(); err != nil {
+		return nil, err
+	}
 
-	// Start the flush timer
-	go repo.flushTimer()
+	// Start the sync timer
+	if cfg.SyncPolicy == SyncOnInterval {
+		go repo.flushTimer()
+	}
 
 	return repo, nil
 }
 
-// StoreRecord stores a new log record in the WAL.
+// StoreRecord stores a new log record in the WAL, it is synced before returning with SyncPerRequest.
 func (w *V1Writer) StoreRecord(r *domain.LogRecord) error {
-	// Serialize the log record (you may want to use your BinarySerializer here).
-	recordSize := len(r.Message) // Simplified; actual size will depend on full serialization
-	w.logRecords = append(w.logRecords, r)
-	w.walSize += recordSize
-	w.recordCount++
+	_, err := w.append(r)
+	return err
+}
 
-	// Check if we need to flush due to size or record count.
+// append writes the log record to the WAL file and returns the sequence number of the file
+func (w *V1Writer) append(r *domain.LogRecord) (uint64, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, internal_errors.WALClosed
+	}
+	// Start the next WAL file if the current one is full
 	if w.walSize >= w.cfg.MaxSize || w.recordCount >= w.cfg.MaxRecords {
-		return w.Flush()
+		if err := w.markDone(); err != nil {
+			return 0, err
+		}
+		if err := w.new(); err != nil {
+			return 0, err
+		}
 	}
-	return nil
+
+	// Frame the serialized log record with its size and checksum
+	w.buf.Reset()
+	w.buf.Write(make([]byte, domain.WALRecordHeaderSize))
+	if _, err := w.codec.WriteLogRecord(r, &w.buf); err != nil {
+		return 0, err
+	}
+	frame := w.buf.Bytes()
+	record := frame[domain.WALRecordHeaderSize:]
+	binary.LittleEndian.PutUint32(frame[0:4], uint32(len(record)))
+	binary.LittleEndian.PutUint32(frame[4:8], domain.Checksum(record))
+	if _, err := w.file.Write(frame); err != nil {
+		return 0, err
+	}
+	w.walSize += len(frame)
+	w.recordCount++
+	w.unsynced = true
+	w.segments[len(w.segments)-1].pending++
+
+	if w.cfg.SyncPolicy == SyncPerRequest {
+		if err := w.sync(); err != nil {
+			return 0, err
+		}
+	}
+	return w.sequence, nil
 }
 
-// Flush writes the log records to the WAL file and resets the buffer.
+// Flush syncs the records written to the WAL file to the disk.
 func (w *V1Writer) Flush() error {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
-	if len(w.logRecords) == 0 {
-		return nil // Nothing to flush
+	if w.closed {
+		return nil
 	}
-	w.codec.WriteWALHeader(w.header, w.file)
-	// Sort By Timestamp oldest to newest
-	sort.Slice(w.logRecords, func(i, j int) bool {
-		return w.logRecords[i].Timestamp.Before(w.logRecords[j].Timestamp)
-	})
-	// Serialize and write log records to file
-	for _, record := range w.logRecords {
-		if _, err := w.codec.WriteLogRecord(record, w.file); err != nil {
+	return w.sync()
+}
+
+// sync syncs the current WAL file if records were written since the last sync
+func (w *V1Writer) sync() error {
+	if !w.unsynced {
+		return nil
+	}
+	if err := w.file.Sync(); err != nil {
+		return err
+	}
+	w.unsynced = false
+	return nil
+}
+
+// Release marks the given number of records per WAL file sequence as persisted in data files.
+// Sealed WAL files are removed oldest first as soon as all their records are persisted.
+func (w *V1Writer) Release(persisted map[uint64]int) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, s := range w.segments {
+		s.pending -= persisted[s.sequence]
+	}
+	return w.removeReleased()
+}
+
+// removeReleased removes the sealed WAL files oldest first until a file has records that are not persisted
+func (w *V1Writer) removeReleased() error {
+	for len(w.segments) > 0 && w.segments[0].sealed && w.segments[0].pending <= 0 {
+		if err := os.Remove(w.fileName(w.segments[0].sequence)); err != nil {
 			return err
 		}
+		w.segments = w.segments[1:]
 	}
+	return nil
+}
 
-	w.markDone()
-	w.onFlushCallback(w.fileName())
-	w.reset()
-	return w.new()
+// readLogRecord reads a log record written by WriteLogRecord
+func readLogRecord(codec ports.Serializer, reader io.Reader) (*domain.LogRecord, error) {
+	meta := &domain.RecordMeta{}
+	if _, err := codec.ReadLogRecordMeta(meta, reader); err != nil {
+		return nil, err
+	}
+	labels := make([]domain.Label, meta.LabelsCount)
+	for i := range labels {
+		if _, err := codec.ReadLogLabel(&labels[i], reader); err != nil {
+			return nil, err
+		}
+	}
+	message := make([]byte, meta.MessageSize)
+	if _, err := codec.ReadLogRecordMessage(message, reader); err != nil {
+		return nil, err
+	}
+	return &domain.LogRecord{
+		Timestamp:     meta.Time(),
+		SchemaVersion: meta.SchemaVersion,
+		Labels:        labels,
+		Message:       message,
+	}, nil
 }
 
-// flushTimer triggers automatic flushing based on a timer.
+// flushTimer syncs the WAL file every FlushInterval.
 func (w *V1Writer) flushTimer() {
+	ticker := time.NewTicker(w.cfg.FlushInterval)
+	defer ticker.Stop()
 	for {
 		select {
-		case <-w.timer.C:
+		case <-ticker.C:
 			if err := w.Flush(); err != nil {
-				log.Fatalf("Error flushing WAL: %v", err)
+				log.WithError(err).Error("Error syncing WAL")
 			}
-			w.timer.Reset(w.cfg.FlushInterval)
+		case <-w.done:
+			return
 		}
 	}
 }
 
-// Close closes the WAL file and processes the contents.
+// Close syncs and seals the WAL file, the sealed files with released records are removed and the rest is replayed on the next start.
 func (w *V1Writer) Close() error {
-	// Flush remaining data before closing
-	if err := w.Flush(); err != nil {
-		return err
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return nil
 	}
-	if w.walSize == 0 {
-		// Remove the empty dirty file if it exists
-		if err := os.Remove(w.dirtyFileName()); err != nil {
+	w.closed = true
+	close(w.done)
+	if w.recordCount == 0 {
+		// Remove the empty dirty file
+		w.segments = w.segments[:len(w.segments)-1]
+		if err := w.file.Close(); err != nil {
 			return err
 		}
+		return os.Remove(w.dirtyFileName())
 	}
-	return nil
+	if err := w.markDone(); err != nil {
+		return err
+	}
+	// every record is released after a clean shutdown
+	return w.removeReleased()
 }
 
 type V1Processor struct {
@@ -0,0 +1,89 @@
+package wal
+
+import (
+	"LogDb/internal/adapters/memtable"
+	"LogDb/internal/adapters/serializer"
+	"LogDb/internal/domain"
+	"LogDb/internal/ports"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// collectFlusher keeps the records of every flushed chunk in timestamp order
+type collectFlusher struct {
+	mu      sync.Mutex
+	records []*domain.LogRecord
+}
+
+func (f *collectFlusher) FlushChunk(chunk ports.HeapChunk) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for chunk.Size() > 0 {
+		record, err := chunk.Pop()
+		if err != nil {
+			return err
+		}
+		f.records = append(f.records, record)
+	}
+	return nil
+}
+
+func (f *collectFlusher) Close() error {
+	return nil
+}
+
+func (f *collectFlusher) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.records)
+}
+
+func newTestChunk(maxSize, maxRecords int) ports.HeapChunk {
+	return memtable.NewHeapChunk(maxSize, maxRecords)
+}
+
+func testRecords(n int) []*domain.LogRecord {
+	base := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	records := make([]*domain.LogRecord, n)
+	for i := range records {
+		records[i] = &domain.LogRecord{Timestamp: base.Add(time.Duration(i) * time.Nanosecond), SchemaVersion: 1, Message: []byte("message")}
+	}
+	return records
+}
+
+func walFiles(t *testing.T, dir string) []string {
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	require.NoError(t, err)
+	return files
+}
+
+// TestDurableMemTable_Release tests that WAL files are removed once their records are flushed
+func TestDurableMemTable_Release(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewWALRepository(&V1WriterConfig{BaseDir: dir, MaxRecords: 4, SyncPolicy: SyncPerBatch}, serializer.Default, nil)
+	require.NoError(t, err)
+	flusher := &collectFlusher{}
+	durable := NewDurableMemTable(w)
+	memTable := memtable.NewMemTable(context.Background(), 1<<20, 1000, durable.NewChunk(newTestChunk), NewCheckpointFlusher(w, flusher), time.Hour)
+	durable.Bind(memTable)
+
+	added, err := durable.AddBatch(testRecords(10))
+	require.NoError(t, err)
+	require.Equal(t, 10, added)
+	assert.Len(t, walFiles(t, dir), 3)
+
+	memTable.RotateChunk()
+	require.Eventually(t, func() bool {
+		return len(walFiles(t, dir)) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 10, flusher.count())
+
+	require.NoError(t, w.Close())
+	// the records of the active file are released too
+	assert.Empty(t, walFiles(t, dir))
+}
@@ -339,13 +339,13 @@ func (h *V2Writer) flush() {
 		// Iterate over all MinuteHolders
 		for _, m := range minutes {
 			logs := m.GetLogs()
-			err := dataFileWriter.CreateDataPage(m.header.Number)
+			err := dataFileWriter.AppendDataPage(domain.NewDataPageHeaderForMinute(m.header.Number))
 			if err != nil {
 				return
 			}
 			log.Debugf("Flushing %d logs from MinuteHolder: %d", len(logs), m.header.Number)
 			for _, logRecord := range logs {
-				err := dataFileWriter.WriteLogRecord(logRecord)
+				err := dataFileWriter.AppendLogRecordToCurrentDataPage(logRecord)
 				if err != nil {
 					return
 				}
@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"LogDb/internal/domain/query_types"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AggregationBucket is the number of matching records in a time bucket having the same values of the grouping labels
+type AggregationBucket struct {
+	Start   time.Time // start of the bucket in UTC
+	Labels  []string  // values of the grouping labels in order, empty when the record has no such label
+	Count   uint64
+	Metrics []*MetricAccumulator // metrics of the aggregation in order
+}
+
+// Aggregation counts the matching records of a query per time bucket and values of the grouping labels
+type Aggregation struct {
+	Dimension query_types.Dimension
+	GroupBy   []string             // fields of the grouping labels, e.g. label.service
+	Metrics   []query_types.Metric // metrics computed per bucket
+	Total     uint64
+	buckets   map[string]*AggregationBucket
+}
+
+// NewAggregation creates an empty aggregation
+func NewAggregation(dimension query_types.Dimension, groupBy []string, metrics []query_types.Metric) *Aggregation {
+	return &Aggregation{
+		Dimension: dimension,
+		GroupBy:   groupBy,
+		Metrics:   metrics,
+		buckets:   make(map[string]*AggregationBucket),
+	}
+}
+
+// Add counts the records in the bucket of the timestamp and the label values
+func (a *Aggregation) Add(timestamp time.Time, labels []string, count uint64) {
+	a.bucket(timestamp, labels).Count += count
+	a.Total += count
+}
+
+// AddRecord counts a record in the bucket of the timestamp and the label values and accumulates the labels
+// of its metrics, values holds a label per metric, nil when the record does not have it
+func (a *Aggregation) AddRecord(timestamp time.Time, labels []string, values []*Label) {
+	bucket := a.bucket(timestamp, labels)
+	bucket.Count++
+	a.Total++
+	for i, metric := range bucket.Metrics {
+		metric.Add(values[i])
+	}
+}
+
+// bucket returns the bucket of the timestamp and the label values, it is created on first use
+func (a *Aggregation) bucket(timestamp time.Time, labels []string) *AggregationBucket {
+	start := a.Dimension.Truncate(timestamp)
+	var key strings.Builder
+	key.WriteString(strconv.FormatInt(start.UnixNano(), 10))
+	for _, label := range labels {
+		key.WriteByte(0)
+		key.WriteString(label)
+	}
+	bucket, ok := a.buckets[key.String()]
+	if !ok {
+		bucket = &AggregationBucket{Start: start, Labels: labels}
+		for _, metric := range a.Metrics {
+			bucket.Metrics = append(bucket.Metrics, NewMetricAccumulator(metric))
+		}
+		a.buckets[key.String()] = bucket
+	}
+	return bucket
+}
+
+// Buckets returns the buckets ordered by start and label values
+func (a *Aggregation) Buckets() []*AggregationBucket {
+	buckets := make([]*AggregationBucket, 0, len(a.buckets))
+	for _, bucket := range a.buckets {
+		buckets = append(buckets, bucket)
+	}
+	sort.Slice(buckets, func(i, j int) bool {
+		if !buckets[i].Start.Equal(buckets[j].Start) {
+			return buckets[i].Start.Before(buckets[j].Start)
+		}
+		for k := range buckets[i].Labels {
+			if buckets[i].Labels[k] != buckets[j].Labels[k] {
+				return buckets[i].Labels[k] < buckets[j].Labels[k]
+			}
+		}
+		return false
+	})
+	return buckets
+}
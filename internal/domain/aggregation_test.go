@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"LogDb/internal/domain/query_types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestDimension_Truncate(t *testing.T) {
+	// Thursday
+	at := time.Date(2024, 8, 15, 13, 45, 30, 500, time.UTC)
+	for dimension, start := range map[query_types.Dimension]time.Time{
+		query_types.Minute:  time.Date(2024, 8, 15, 13, 45, 0, 0, time.UTC),
+		query_types.Hour:    time.Date(2024, 8, 15, 13, 0, 0, 0, time.UTC),
+		query_types.Day:     time.Date(2024, 8, 15, 0, 0, 0, 0, time.UTC),
+		query_types.Week:    time.Date(2024, 8, 12, 0, 0, 0, 0, time.UTC),
+		query_types.Month:   time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC),
+		query_types.Quarter: time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC),
+		query_types.Year:    time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	} {
+		assert.Equal(t, start, dimension.Truncate(at), dimension)
+	}
+	// Sunday belongs to the week started on Monday
+	assert.Equal(t, time.Date(2024, 8, 12, 0, 0, 0, 0, time.UTC), query_types.Week.Truncate(time.Date(2024, 8, 18, 23, 0, 0, 0, time.UTC)))
+}
+
+func TestAggregation_Buckets(t *testing.T) {
+	aggregation := NewAggregation(query_types.Hour, []string{"label.service"}, nil)
+	at := time.Date(2024, 8, 15, 13, 45, 0, 0, time.UTC)
+	aggregation.Add(at.Add(time.Hour), []string{"api"}, 1)
+	aggregation.Add(at, []string{"db"}, 2)
+	aggregation.Add(at.Add(10*time.Minute), []string{"api"}, 3)
+	aggregation.Add(at, []string{"api"}, 4)
+
+	buckets := aggregation.Buckets()
+	require.Len(t, buckets, 3)
+	hour := time.Date(2024, 8, 15, 13, 0, 0, 0, time.UTC)
+	assert.Equal(t, &AggregationBucket{Start: hour, Labels: []string{"api"}, Count: 7}, buckets[0])
+	assert.Equal(t, &AggregationBucket{Start: hour, Labels: []string{"db"}, Count: 2}, buckets[1])
+	assert.Equal(t, &AggregationBucket{Start: hour.Add(time.Hour), Labels: []string{"api"}, Count: 1}, buckets[2])
+	assert.Equal(t, uint64(10), aggregation.Total)
+}
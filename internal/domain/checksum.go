@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+)
+
+// ChecksumTable is the CRC32C (Castagnoli) table used for all checksums of the data files
+var ChecksumTable = crc32.MakeTable(crc32.Castagnoli)
+
+// Checksum returns the CRC32C of the data.
+func Checksum(data []byte) uint32 {
+	return crc32.Checksum(data, ChecksumTable)
+}
+
+// ChecksumReader returns the CRC32C of everything read from the reader.
+func ChecksumReader(reader io.Reader) (uint32, error) {
+	hash := crc32.New(ChecksumTable)
+	if _, err := io.Copy(hash, reader); err != nil {
+		return 0, err
+	}
+	return hash.Sum32(), nil
+}
+
+// checksumOf returns the CRC32C of the fixed size structure in its on-disk (little endian) encoding
+func checksumOf(data any) uint32 {
+	buffer := &bytes.Buffer{}
+	if err := binary.Write(buffer, binary.LittleEndian, data); err != nil {
+		panic(err)
+	}
+	return Checksum(buffer.Bytes())
+}
@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"LogDb/internal/domain/query_types"
+	"time"
+)
+
+// RecordPosition locates a record in the storage, it orders the records sharing a timestamp. The records of
+// the MemTable have no data file, they are numbered in timestamp order and come after the records of data files.
+type RecordPosition struct {
+	DataFile string // name of the data file, e.g. 2024-10-25.4164052702, empty for the MemTable
+	Page     uint32 // number of the data page, the minute of the day
+	Offset   uint32 // index of the record in the data page
+}
+
+// Before reports whether the position comes before the other one
+func (p *RecordPosition) Before(other *RecordPosition) bool {
+	switch {
+	case p.DataFile != other.DataFile:
+		if p.DataFile == "" || other.DataFile == "" {
+			return other.DataFile == ""
+		}
+		return p.DataFile < other.DataFile
+	case p.Page != other.Page:
+		return p.Page < other.Page
+	}
+	return p.Offset < other.Offset
+}
+
+// Cursor is the last record of a page of results, the next page continues after it in the order of the query
+type Cursor struct {
+	Order     query_types.Order
+	Timestamp time.Time
+	Position  RecordPosition
+}
+
+// IsAfter reports whether the record comes after the cursor in the order of the cursor
+func (c *Cursor) IsAfter(record *LogRecord) bool {
+	if c.Order == query_types.Descending {
+		return record.Timestamp.Before(c.Timestamp) || record.Timestamp.Equal(c.Timestamp) && record.Position != nil && record.Position.Before(&c.Position)
+	}
+	return record.Timestamp.After(c.Timestamp) || record.Timestamp.Equal(c.Timestamp) && record.Position != nil && c.Position.Before(record.Position)
+}
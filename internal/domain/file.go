@@ -23,7 +23,9 @@ type DataFileHeader struct {
 	LastDataPageNumber  uint32    // 4 bytes (0 - 1439 pages / minutes)
 	FirstDataPageNumber uint32    // 4 bytes
 	Compressed          bool      // 1 byte
-	Reserved            [251]byte // 251 bytes reserved for future use
+	FooterOffset        uint64    // 8 bytes - Offset of the data page directory, 0 when the file has no footer
+	FooterChecksum      uint32    // 4 bytes - CRC32C of the footer (version 3)
+	Reserved            [239]byte // 239 bytes reserved for future use
 	Checksum            uint64    // 8 bytes
 }
 
@@ -41,12 +43,25 @@ const DataFileHeaderSize = int(unsafe.Sizeof(DataFileHeader{}.Version) +
 	unsafe.Sizeof(DataFileHeader{}.LastDataPageNumber) +
 	unsafe.Sizeof(DataFileHeader{}.FirstDataPageNumber) +
 	unsafe.Sizeof(DataFileHeader{}.Compressed) +
+	unsafe.Sizeof(DataFileHeader{}.FooterOffset) +
+	unsafe.Sizeof(DataFileHeader{}.FooterChecksum) +
 	unsafe.Sizeof(DataFileHeader{}.Reserved) +
 	unsafe.Sizeof(DataFileHeader{}.Checksum),
 ) // 312 bytes
 
 const MaxDataPagesInDataFile = 1440
 
+const (
+	// DataFileVersionPagesOnly is the data file format with data pages only
+	DataFileVersionPagesOnly uint64 = 1
+	// DataFileVersionPageDirectory is the data file format with a data page directory in the footer
+	DataFileVersionPageDirectory uint64 = 2
+	// DataFileVersionChecksums is the data file format with CRC32C checksums of the header, the footer and the data pages
+	DataFileVersionChecksums uint64 = 3
+	// DataFileVersion is the data file format written for new data files
+	DataFileVersion = DataFileVersionChecksums
+)
+
 // NewDataFileHeader creates a new DataFileHeader.
 func NewDataFileHeader(version uint64, id uint32, year uint64, month uint64, day uint64) *DataFileHeader {
 	return &DataFileHeader{
@@ -68,16 +83,81 @@ func NewEmptyDataFileHeader() *DataFileHeader {
 	}
 }
 
+// HasDataPageChecksums returns true when the footer holds the checksums of the data pages.
+func (h *DataFileHeader) HasDataPageChecksums() bool {
+	return h.Version >= DataFileVersionChecksums && h.FooterOffset != 0
+}
+
+// FooterSize returns the size of the footer in bytes.
+func (h *DataFileHeader) FooterSize() int {
+	if h.HasDataPageChecksums() {
+		return DataPageDirectorySize + DataPageChecksumsSize
+	}
+	return DataPageDirectorySize
+}
+
 // UpdateChecksum calculates the checksum of the header.
+// Since version 3 it is the CRC32C of the header with the zero checksum field.
 func (h *DataFileHeader) UpdateChecksum() {
+	if h.Version >= DataFileVersionChecksums {
+		h.Checksum = uint64(h.crc32c())
+		return
+	}
 	h.Checksum = h.Month + h.Day + h.Year + h.RecordCount + uint64(h.LastDataPageNumber) + uint64(h.Id) + h.Version
 }
 
+// VerifyChecksum returns false when the header was corrupted.
+// The checksums of the headers before version 3 are not verified.
+func (h *DataFileHeader) VerifyChecksum() bool {
+	if h.Version < DataFileVersionChecksums {
+		return true
+	}
+	return h.Checksum == uint64(h.crc32c())
+}
+
+// crc32c returns the CRC32C of the header without the checksum field
+func (h *DataFileHeader) crc32c() uint32 {
+	header := *h
+	header.Checksum = 0
+	return checksumOf(&header)
+}
+
+// HasDataPageDirectory returns true when the data file has a data page directory in the footer.
+func (h *DataFileHeader) HasDataPageDirectory() bool {
+	return h.Version >= DataFileVersionPageDirectory && h.FooterOffset != 0
+}
+
 // Time returns the year, month, and day as go time.
 func (h *DataFileHeader) Time() time.Time {
 	return time.Date(int(h.Year), time.Month(h.Month), int(h.Day), 0, 0, 0, 0, time.UTC)
 }
 
+// DataPageRange returns the data page numbers (minutes of the day) of the file overlapping the given time range.
+// The last value is false when the file has no data pages in the range.
+func (h *DataFileHeader) DataPageRange(from, to time.Time) (uint32, uint32, bool) {
+	day := h.Time()
+	nextDay := day.AddDate(0, 0, 1)
+	if to.Before(day) || !from.Before(nextDay) || to.Before(from) {
+		return 0, 0, false
+	}
+	first, last := h.FirstDataPageNumber, h.LastDataPageNumber
+	if from.After(day) {
+		first = max(first, uint32(from.Sub(day)/time.Minute))
+	}
+	if to.Before(nextDay) {
+		last = min(last, uint32(to.Sub(day)/time.Minute))
+	}
+	if first > last {
+		return 0, 0, false
+	}
+	return first, last, true
+}
+
+// DataPageStart returns the time of the first record the data page can hold.
+func (h *DataFileHeader) DataPageStart(number uint32) time.Time {
+	return h.Time().Add(time.Duration(number) * DataPageDuration)
+}
+
 // String returns the string representation of the header
 // Example: "2024-10-25.4164052702"
 func (h *DataFileHeader) String() string {
@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// TestDataFileHeader_DataPageRange tests the data pages of a time range starting or ending on a minute, around
+// midnight and outside the pages of the file
+func TestDataFileHeader_DataPageRange(t *testing.T) {
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	full := &DataFileHeader{Year: 2024, Month: 5, Day: 1, FirstDataPageNumber: 0, LastDataPageNumber: MaxDataPagesInDataFile - 1}
+	partial := &DataFileHeader{Year: 2024, Month: 5, Day: 1, FirstDataPageNumber: 10, LastDataPageNumber: 700}
+	at := func(hour, minute int, d time.Duration) time.Time {
+		return day.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute + d)
+	}
+	tests := []struct {
+		name        string
+		header      *DataFileHeader
+		from, to    time.Time
+		first, last uint32
+		ok          bool
+	}{
+		{"on minutes", full, at(10, 0, 0), at(10, 5, 0), 600, 605, true},
+		{"before minutes", full, at(10, 0, -time.Nanosecond), at(10, 5, -time.Nanosecond), 599, 604, true},
+		{"inside a minute", full, at(10, 0, time.Second), at(10, 0, 59*time.Second), 600, 600, true},
+		{"single instant on a minute", full, at(10, 0, 0), at(10, 0, 0), 600, 600, true},
+		{"whole day", full, day, day.AddDate(0, 0, 1).Add(-time.Nanosecond), 0, MaxDataPagesInDataFile - 1, true},
+		{"from the previous day", full, day.Add(-time.Minute), at(0, 2, 0), 0, 2, true},
+		{"to the next day", full, at(23, 58, 0), day.AddDate(0, 0, 1).Add(time.Minute), 1438, MaxDataPagesInDataFile - 1, true},
+		{"ends at midnight", full, day.Add(-time.Hour), day, 0, 0, true},
+		{"ends before midnight", full, day.Add(-time.Hour), day.Add(-time.Nanosecond), 0, 0, false},
+		{"starts at the next midnight", full, day.AddDate(0, 0, 1), day.AddDate(0, 0, 1).Add(time.Hour), 0, 0, false},
+		{"starts before the next midnight", full, day.AddDate(0, 0, 1).Add(-time.Nanosecond), day.AddDate(0, 0, 2), MaxDataPagesInDataFile - 1, MaxDataPagesInDataFile - 1, true},
+		{"reversed", full, at(10, 5, 0), at(10, 0, 0), 0, 0, false},
+		{"clamped to the pages of the file", partial, day.Add(-time.Hour), day.AddDate(0, 0, 1), 10, 700, true},
+		{"on the first page of the file", partial, at(0, 9, 0), at(0, 10, 0), 10, 10, true},
+		{"before the first page of the file", partial, day, at(0, 10, -time.Nanosecond), 0, 0, false},
+		{"after the last page of the file", partial, at(11, 41, 0), at(12, 0, 0), 0, 0, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			first, last, ok := test.header.DataPageRange(test.from, test.to)
+			assert.Equal(t, test.ok, ok)
+			if test.ok {
+				assert.Equal(t, test.first, first, "first")
+				assert.Equal(t, test.last, last, "last")
+			}
+		})
+	}
+}
@@ -1,5 +1,11 @@
 package domain
 
+import (
+	"encoding/binary"
+	"math"
+	"strconv"
+)
+
 // StringLabelType IntLabelType FloatLabelType are constants that represent the type of the label value
 const StringLabelType uint8 = 0
 const IntLabelType uint8 = 1
@@ -10,3 +16,36 @@ type Label struct {
 	Size  uint64 // Size of the label value in bytes (uint64 - 8 bytes)
 	Value []byte // The raw value of the label in a byte slice (variable size)
 }
+
+// String returns the value of the label formatted according to its type
+func (l Label) String() string {
+	switch {
+	case l.Type == IntLabelType && len(l.Value) == 8:
+		return strconv.FormatInt(int64(binary.LittleEndian.Uint64(l.Value)), 10)
+	case l.Type == FloatLabelType && len(l.Value) == 8:
+		return strconv.FormatFloat(math.Float64frombits(binary.LittleEndian.Uint64(l.Value)), 'g', -1, 64)
+	}
+	return string(l.Value)
+}
+
+// Float64 returns the numeric value of the label, numeric strings are parsed.
+// The second value is false when the label is not a number.
+func (l Label) Float64() (float64, bool) {
+	switch l.Type {
+	case IntLabelType:
+		if len(l.Value) != 8 {
+			return 0, false
+		}
+		return float64(int64(binary.LittleEndian.Uint64(l.Value))), true
+	case FloatLabelType:
+		if len(l.Value) != 8 {
+			return 0, false
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(l.Value)), true
+	}
+	value, err := strconv.ParseFloat(string(l.Value), 64)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, false
+	}
+	return value, true
+}
@@ -1,6 +1,8 @@
 package domain
 
 import (
+	"encoding/binary"
+	"hash/fnv"
 	"time"
 )
 
@@ -13,6 +15,8 @@ type LogRecord struct {
 	SchemaVersion uint64    `json:"schema_version"`
 	Labels        []Label   `json:"labels"`
 	Message       []byte    `json:"message"`
+	// Position is set on the records of a query result, where the record was found
+	Position *RecordPosition `json:"-"`
 }
 
 // NewEmptyLogRecord creates a new LogRecord with the current time
@@ -25,7 +29,43 @@ func (r *LogRecord) AddLabel(label Label) {
 	r.Labels = append(r.Labels, label)
 }
 
+// Size returns the number of bytes of the timestamp, the labels and the message of the record
+func (r *LogRecord) Size() uint64 {
+	size := uint64(8 + len(r.Message))
+	for _, label := range r.Labels {
+		size += uint64(1 + len(label.Value))
+	}
+	return size
+}
+
 // DataPageNumber returns the number of data pages needed to store the record
 func (r *LogRecord) DataPageNumber() uint32 {
 	return uint32(r.Timestamp.Hour()*60 + r.Timestamp.Minute())
 }
+
+// MetaTimestamp returns the timestamp of the record as stored in the record meta
+func (r *LogRecord) MetaTimestamp() uint64 {
+	return NewRecordTimestamp(r.Timestamp)
+}
+
+// RecordFingerprint identifies a log record by its timestamp and a hash of its content
+type RecordFingerprint struct {
+	Timestamp int64
+	Hash      uint64
+}
+
+// Fingerprint returns the fingerprint of the record, identical records have the same fingerprint
+func (r *LogRecord) Fingerprint() RecordFingerprint {
+	h := fnv.New64a()
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], r.SchemaVersion)
+	h.Write(buf[:])
+	for _, label := range r.Labels {
+		binary.LittleEndian.PutUint64(buf[:], uint64(len(label.Value)))
+		h.Write([]byte{label.Type})
+		h.Write(buf[:])
+		h.Write(label.Value)
+	}
+	h.Write(r.Message)
+	return RecordFingerprint{Timestamp: r.Timestamp.UnixNano(), Hash: h.Sum64()}
+}
@@ -0,0 +1,16 @@
+package domain
+
+// MemTableSnapshot holds the records of the MemTable that may not be stored in data files yet.
+// Chunks are numbered in the order they are flushed, a chunk that started flushing may be in data files already.
+type MemTableSnapshot struct {
+	Sequence uint64         // Flush sequence of the first chunk
+	Chunks   [][]*LogRecord // Records of every chunk in flush order, the last one is the active chunk
+}
+
+// Flushing returns the number of chunks of the snapshot that started flushing before the flush sequence
+func (s *MemTableSnapshot) Flushing(sequence uint64) int {
+	if sequence <= s.Sequence {
+		return 0
+	}
+	return min(int(sequence-s.Sequence), len(s.Chunks))
+}
@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"LogDb/internal/domain/query_types"
+	"math"
+)
+
+// MetricAccumulator computes a metric of an aggregation bucket from the labels of its records
+type MetricAccumulator struct {
+	Metric   query_types.Metric
+	count    uint64 // records counted by count, numeric values of the other functions
+	sum      float64
+	min      float64
+	max      float64
+	distinct map[string]struct{}
+	sketch   *DDSketch
+}
+
+// NewMetricAccumulator creates an accumulator without values
+func NewMetricAccumulator(metric query_types.Metric) *MetricAccumulator {
+	m := &MetricAccumulator{Metric: metric, min: math.Inf(1), max: math.Inf(-1)}
+	switch metric.Function {
+	case query_types.CountDistinct:
+		m.distinct = make(map[string]struct{})
+	case query_types.Percentile:
+		m.sketch = NewDDSketch(SketchRelativeAccuracy)
+	}
+	return m
+}
+
+// Add accumulates the label of a record, the label is nil when the record does not have it.
+// Labels that are not numbers are ignored by the numeric functions.
+func (m *MetricAccumulator) Add(label *Label) {
+	switch m.Metric.Function {
+	case query_types.Count:
+		if label != nil || m.Metric.Field == query_types.AllRecords {
+			m.count++
+		}
+		return
+	case query_types.CountDistinct:
+		if label != nil {
+			m.distinct[label.String()] = struct{}{}
+		}
+		return
+	}
+	if label == nil {
+		return
+	}
+	value, ok := label.Float64()
+	if !ok {
+		return
+	}
+	m.count++
+	m.sum += value
+	m.min = math.Min(m.min, value)
+	m.max = math.Max(m.max, value)
+	if m.sketch != nil {
+		m.sketch.Add(value)
+	}
+}
+
+// Value returns the value of the metric, the second value is false when no value was accumulated
+func (m *MetricAccumulator) Value() (float64, bool) {
+	switch m.Metric.Function {
+	case query_types.Count:
+		return float64(m.count), true
+	case query_types.CountDistinct:
+		return float64(len(m.distinct)), true
+	case query_types.Sum:
+		return m.sum, true
+	}
+	if m.count == 0 {
+		return 0, false
+	}
+	switch m.Metric.Function {
+	case query_types.Avg:
+		return m.sum / float64(m.count), true
+	case query_types.Min:
+		return m.min, true
+	case query_types.Max:
+		return m.max, true
+	case query_types.Percentile:
+		return m.sketch.Quantile(m.Metric.Percentile / 100)
+	}
+	return 0, false
+}
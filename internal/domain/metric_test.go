@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"LogDb/internal/domain/query_types"
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+func intLabel(value int64) *Label {
+	label := &Label{Type: IntLabelType, Size: 8, Value: make([]byte, 8)}
+	binary.LittleEndian.PutUint64(label.Value, uint64(value))
+	return label
+}
+
+func stringLabel(value string) *Label {
+	return &Label{Type: StringLabelType, Size: uint64(len(value)), Value: []byte(value)}
+}
+
+func TestMetricAccumulator_Value(t *testing.T) {
+	labels := []*Label{intLabel(10), stringLabel("30"), nil, stringLabel("n/a"), intLabel(20), stringLabel("10")}
+	for metric, expected := range map[query_types.Metric]float64{
+		{Function: query_types.Count, Field: query_types.AllRecords}:                 6,
+		{Function: query_types.Count, Field: "label.duration"}:                       5,
+		{Function: query_types.CountDistinct, Field: "label.duration"}:               4,
+		{Function: query_types.Sum, Field: "label.duration"}:                         70,
+		{Function: query_types.Avg, Field: "label.duration"}:                         17.5,
+		{Function: query_types.Min, Field: "label.duration"}:                         10,
+		{Function: query_types.Max, Field: "label.duration"}:                         30,
+		{Function: query_types.Percentile, Field: "label.duration", Percentile: 100}: 30,
+	} {
+		accumulator := NewMetricAccumulator(metric)
+		for _, label := range labels {
+			accumulator.Add(label)
+		}
+		value, ok := accumulator.Value()
+		require.True(t, ok, metric)
+		assert.InDelta(t, expected, value, expected*SketchRelativeAccuracy, metric)
+	}
+
+	_, ok := NewMetricAccumulator(query_types.Metric{Function: query_types.Avg, Field: "label.duration"}).Value()
+	assert.False(t, ok)
+}
+
+func TestDDSketch_Quantile(t *testing.T) {
+	sketch := NewDDSketch(SketchRelativeAccuracy)
+	random := rand.New(rand.NewSource(1))
+	values := make([]float64, 10_000)
+	for i := range values {
+		// response times with a long tail and a few negative values
+		values[i] = math.Exp(random.NormFloat64()*2) - 0.05
+		sketch.Add(values[i])
+	}
+	sort.Float64s(values)
+
+	for _, q := range []float64{0, 0.01, 0.25, 0.5, 0.9, 0.99, 0.999, 1} {
+		expected := values[int(q*float64(len(values)-1))]
+		value, ok := sketch.Quantile(q)
+		require.True(t, ok)
+		assert.InDelta(t, expected, value, math.Abs(expected)*SketchRelativeAccuracy+1e-9, strconv.FormatFloat(q, 'g', -1, 64))
+	}
+	_, ok := NewDDSketch(SketchRelativeAccuracy).Quantile(0.5)
+	assert.False(t, ok)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"time"
 	"unsafe"
 )
 
@@ -12,6 +13,9 @@ func init() {
 	log.Printf("Initialized with DataPageHeaderSize: %d\n", DataPageHeaderSize)
 }
 
+// DataPageDuration is the time span of the records of a data page
+const DataPageDuration = time.Minute
+
 type DataPageHeader struct {
 	Number               uint32                            // 4 bytes - Minute number in 24 hours (0-1439)
 	PageSize             uint64                            // 8 bytes - Size of the page in bytes
@@ -32,6 +36,11 @@ const DataPageHeaderSize = int(unsafe.Sizeof(DataPageHeader{}.Number) +
 	unsafe.Sizeof(DataPageHeader{}.CompressedPageSize),
 ) // 29 bytes
 
+// Checksum returns the CRC32C of the header.
+func (h *DataPageHeader) Checksum() uint32 {
+	return checksumOf(h)
+}
+
 // NewEmptyDataPageHeader creates a new DataPageHeader.
 func NewEmptyDataPageHeader() *DataPageHeader {
 	return &DataPageHeader{}
@@ -0,0 +1,135 @@
+package domain
+
+import (
+	"log"
+	"unsafe"
+)
+
+func init() {
+	log.Printf("Initialized with DataPageDirectorySize: %d\n", DataPageDirectorySize)
+}
+
+// DataPageDirectoryEntry describes a single data page in the data page directory.
+type DataPageDirectoryEntry struct {
+	Offset       uint64 // 8 bytes - Offset of the data page header in the data file, 0 when the page does not exist
+	RecordCount  uint64 // 8 bytes - Number of records in the page
+	MinTimestamp uint64 // 8 bytes - Smallest record timestamp in the page in nanoseconds
+	MaxTimestamp uint64 // 8 bytes - Largest record timestamp in the page in nanoseconds
+} // 32 bytes
+
+const DataPageDirectoryEntrySize = int(unsafe.Sizeof(DataPageDirectoryEntry{}.Offset) +
+	unsafe.Sizeof(DataPageDirectoryEntry{}.RecordCount) +
+	unsafe.Sizeof(DataPageDirectoryEntry{}.MinTimestamp) +
+	unsafe.Sizeof(DataPageDirectoryEntry{}.MaxTimestamp),
+) // 32 bytes
+
+// Exists returns true when the entry points to a data page.
+func (e *DataPageDirectoryEntry) Exists() bool {
+	return e.Offset != 0
+}
+
+// DataPageChecksum holds the checksums of a single data page.
+type DataPageChecksum struct {
+	Header  uint32 // 4 bytes - CRC32C of the data page header
+	Payload uint32 // 4 bytes - CRC32C of the data page payload as stored, compressed or raw
+} // 8 bytes
+
+const DataPageChecksumSize = int(unsafe.Sizeof(DataPageChecksum{}.Header) +
+	unsafe.Sizeof(DataPageChecksum{}.Payload),
+) // 8 bytes
+
+// DataPageDirectory maps every data page number (minute of the day) to its entry.
+// It is stored in the footer of the data file, the checksums follow the entries since version 3.
+type DataPageDirectory struct {
+	Entries   [MaxDataPagesInDataFile]DataPageDirectoryEntry
+	Checksums [MaxDataPagesInDataFile]DataPageChecksum
+}
+
+const DataPageDirectorySize = DataPageDirectoryEntrySize * MaxDataPagesInDataFile // 46080 bytes
+const DataPageChecksumsSize = DataPageChecksumSize * MaxDataPagesInDataFile       // 11520 bytes
+
+// NewDataPageDirectory creates a new empty DataPageDirectory.
+func NewDataPageDirectory() *DataPageDirectory {
+	return &DataPageDirectory{}
+}
+
+// SetChecksum sets the checksums of the data page.
+func (d *DataPageDirectory) SetChecksum(number uint32, checksum DataPageChecksum) {
+	d.Checksums[number] = checksum
+}
+
+// SetOffset sets the offset of the data page header.
+func (d *DataPageDirectory) SetOffset(number uint32, offset uint64) {
+	d.Entries[number].Offset = offset
+}
+
+// AddRecord updates the record count and the timestamp range in nanoseconds of the data page.
+func (d *DataPageDirectory) AddRecord(number uint32, timestamp uint64) {
+	d.addRecords(number, 1, timestamp, timestamp)
+}
+
+// Merge adds the record counts and timestamp ranges of the other directory. Offsets are not merged.
+func (d *DataPageDirectory) Merge(other *DataPageDirectory) {
+	for number := range other.Entries {
+		entry := &other.Entries[number]
+		if entry.RecordCount == 0 {
+			continue
+		}
+		d.addRecords(uint32(number), entry.RecordCount, entry.MinTimestamp, entry.MaxTimestamp)
+	}
+}
+
+// Next returns the number of the first existing data page with the number equal to or greater than the given one.
+func (d *DataPageDirectory) Next(number uint32) (uint32, bool) {
+	for ; number < MaxDataPagesInDataFile; number++ {
+		if d.Entries[number].Exists() {
+			return number, true
+		}
+	}
+	return 0, false
+}
+
+// DataPageLocation locates a data page with records in a data file
+type DataPageLocation struct {
+	Number      uint32
+	Offset      uint64 // offset of the data page header in the data file
+	Size        uint64 // bytes of the payload as stored, compressed or raw
+	RecordCount uint64
+}
+
+// Locations returns the data pages with records from the first to the last page number in file order. The size of
+// a page ends at the next existing page, the last page ends at the given end of the data pages (the footer offset).
+func (d *DataPageDirectory) Locations(first, last uint32, end uint64) []DataPageLocation {
+	var locations []DataPageLocation
+	var previous *DataPageLocation
+	for number, ok := d.Next(first); ok; number, ok = d.Next(number + 1) {
+		entry := &d.Entries[number]
+		if previous != nil {
+			previous.Size = entry.Offset - previous.Offset - uint64(DataPageHeaderSize)
+			previous = nil
+		}
+		if number > last {
+			break
+		}
+		if entry.RecordCount == 0 {
+			continue
+		}
+		locations = append(locations, DataPageLocation{Number: number, Offset: entry.Offset, RecordCount: entry.RecordCount})
+		previous = &locations[len(locations)-1]
+	}
+	if previous != nil {
+		previous.Size = end - previous.Offset - uint64(DataPageHeaderSize)
+	}
+	return locations
+}
+
+func (d *DataPageDirectory) addRecords(number uint32, count uint64, minTimestamp uint64, maxTimestamp uint64) {
+	entry := &d.Entries[number]
+	if entry.RecordCount == 0 || minTimestamp < entry.MinTimestamp {
+		entry.MinTimestamp = minTimestamp
+	}
+	if entry.RecordCount == 0 || maxTimestamp > entry.MaxTimestamp {
+		entry.MaxTimestamp = maxTimestamp
+	}
+	entry.RecordCount += count
+}
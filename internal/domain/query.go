@@ -21,14 +21,29 @@ type Query struct {
 	Table        string                  // table name
 	Partition    *string                 // optional partition (shard)
 	Conditions   []query_types.Condition // where conditions
+	Order        query_types.Order       // order of the records by timestamp, oldest first unless descending
 	Limit        *int                    // optional limit for results
+	After        *Cursor                 // optional last record of the previous page of results
+	Concurrency  int                     // optional number of workers scanning the data files, 0 for the default
+	Timeout      time.Duration           // optional time limit of the query, 0 without limit
+	Budget       QueryBudget             // optional limits of the resources of the query, at most the budget of the storage
 	AggregatedBy *query_types.Dimension  // optional aggregation by a dimension (minute, hour, etc.)
+	GroupBy      []string                // optional labels grouping the aggregation, e.g. label.service
+	Metrics      []query_types.Metric    // optional metrics computed per aggregation bucket, e.g. avg(label.duration)
 	Format       query_types.Format      // output format
+	Explain      bool                    // return the plan of the query instead of its records
 }
 
 // String representation of the Query for debugging
 func (q Query) String() string {
-	queryStr := fmt.Sprintf("%s %s from %s.%s", q.Operation, strings.Join(q.Fields, ", "), q.Database, q.Table)
+	fields := append([]string(nil), q.Fields...)
+	for _, metric := range q.Metrics {
+		fields = append(fields, metric.String())
+	}
+	queryStr := fmt.Sprintf("%s %s from %s.%s", q.Operation, strings.Join(fields, ", "), q.Database, q.Table)
+	if q.Explain {
+		queryStr = "explain " + queryStr
+	}
 
 	if q.Partition != nil {
 		queryStr += fmt.Sprintf(" partition %s", *q.Partition)
@@ -42,14 +57,23 @@ func (q Query) String() string {
 		queryStr += fmt.Sprintf(" where %s", strings.Join(conditionsStr, " and "))
 	}
 
+	if q.Order == query_types.Descending {
+		queryStr += fmt.Sprintf(" order by timestamp %s", q.Order)
+	}
+
 	if q.Limit != nil {
 		queryStr += fmt.Sprintf(" limit %d", *q.Limit)
 	}
 
 	if q.AggregatedBy != nil {
 		queryStr += fmt.Sprintf(" aggregated by %s", *q.AggregatedBy)
+		if len(q.GroupBy) > 0 {
+			queryStr += fmt.Sprintf(" group by %s", strings.Join(q.GroupBy, ", "))
+		}
 	}
 
-	queryStr += fmt.Sprintf(" format %s", q.Format)
+	if q.Format != query_types.DefaultFormat {
+		queryStr += fmt.Sprintf(" format %s", q.Format)
+	}
 	return queryStr
 }
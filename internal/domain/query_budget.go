@@ -0,0 +1,35 @@
+package domain
+
+// QueryBudget limits the resources a query may consume, a zero limit is unlimited
+type QueryBudget struct {
+	ScannedBytes   uint64 // bytes of the data pages read and of the MemTable records
+	ScannedRecords uint64 // records read, in and out of the time range
+	ResultBytes    uint64 // bytes of the records kept in the result or streamed
+	DataFiles      int    // data files opened
+}
+
+// QueryUsage is what a query consumed of its budget
+type QueryUsage struct {
+	ScannedBytes   uint64 `json:"scanned_bytes"`
+	ScannedRecords uint64 `json:"scanned_records"`
+	ResultBytes    uint64 `json:"result_bytes"`
+	DataFiles      int    `json:"data_files"`
+}
+
+// Lower returns the budget with the lower of both limits, a zero limit does not lower the other one
+func (b QueryBudget) Lower(other QueryBudget) QueryBudget {
+	return QueryBudget{
+		ScannedBytes:   lowerLimit(b.ScannedBytes, other.ScannedBytes),
+		ScannedRecords: lowerLimit(b.ScannedRecords, other.ScannedRecords),
+		ResultBytes:    lowerLimit(b.ResultBytes, other.ResultBytes),
+		DataFiles:      int(lowerLimit(uint64(b.DataFiles), uint64(other.DataFiles))),
+	}
+}
+
+// lowerLimit returns the lower limit, zero is unlimited
+func lowerLimit(limit, other uint64) uint64 {
+	if limit == 0 || (other > 0 && other < limit) {
+		return other
+	}
+	return limit
+}
@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"LogDb/internal/domain/query_types"
+	"time"
+)
+
+// QueryPlan is how a query would read the storage, it is built from the headers and the page directories of the
+// data files without reading their pages. The estimated records are the records of the visited pages and of the MemTable in
+// the time range of the query, before the conditions and the limit.
+type QueryPlan struct {
+	From             time.Time         `json:"from"`
+	To               time.Time         `json:"to"`
+	Order            query_types.Order `json:"order"`
+	Indexes          []string          `json:"indexes"`    // indexes selecting the data files
+	Filters          []string          `json:"filters"`    // filters compiled from the conditions
+	DataFiles        []*DataFilePlan   `json:"data_files"` // data files selected by the index in the order of the query
+	MemTableRecords  uint64            `json:"memtable_records"`
+	EstimatedRecords uint64            `json:"estimated_records"`
+}
+
+// DataFilePlan is a data file selected for a query and the pages it would visit after time pruning
+type DataFilePlan struct {
+	Name             string      `json:"name"`
+	Pages            []*PagePlan `json:"pages"` // in the order of the query
+	EstimatedRecords uint64      `json:"estimated_records"`
+}
+
+// PagePlan is a data page a query would visit
+type PagePlan struct {
+	Number  uint32    `json:"number"`
+	Start   time.Time `json:"start"`
+	Records uint64    `json:"records"` // records of the page from the page directory
+	Bytes   uint64    `json:"bytes"`   // bytes of the page as stored, compressed or raw
+}
+
+// AddDataFile adds the data file to the plan and counts its records in the estimation
+func (p *QueryPlan) AddDataFile(dataFile *DataFilePlan) {
+	for _, page := range dataFile.Pages {
+		dataFile.EstimatedRecords += page.Records
+	}
+	p.DataFiles = append(p.DataFiles, dataFile)
+	p.EstimatedRecords += dataFile.EstimatedRecords
+}
+
+// AddMemTableRecords counts records of the MemTable in the estimation
+func (p *QueryPlan) AddMemTableRecords(records uint64) {
+	p.MemTableRecords += records
+	p.EstimatedRecords += records
+}
@@ -11,6 +11,10 @@ type QueryReport struct {
 	Miss         int           `json:"miss"`
 	Hits         int           `json:"hits"`
 	ElapsedTime  time.Duration `json:"elapsed_time"`
+	// Truncated is set when the query was cancelled or timed out, the result holds the records found until then
+	Truncated bool `json:"truncated"`
+	// Usage is what the query consumed of its budget
+	Usage QueryUsage `json:"usage"`
 }
 
 // NewQueryReport creates a new query_types report with the given ID and count.
@@ -1,19 +1,24 @@
 package domain
 
 import (
+	"LogDb/internal/domain/query_types"
+	"container/heap"
 	"github.com/google/uuid"
+	"sort"
 	"time"
 )
 
 type QueryResult struct {
-	Query   *Query
-	Report  *QueryReport
-	Records []*LogRecord
+	Query       *Query
+	Report      *QueryReport
+	Records     []*LogRecord // with a limit, a heap with the last record in the order of the query first until Sort
+	Aggregation *Aggregation // set for aggregated queries instead of the records
+	Plan        *QueryPlan   // set for explained queries instead of the records
 }
 
 // NewQueryResult creates a new QueryResult instance.
 func NewQueryResult(q *Query) *QueryResult {
-	return &QueryResult{
+	result := &QueryResult{
 		Query: q,
 		Report: &QueryReport{
 			Id:           uuid.New(),
@@ -22,6 +27,10 @@ func NewQueryResult(q *Query) *QueryResult {
 			ElapsedTime:  0,
 		},
 	}
+	if q != nil && q.AggregatedBy != nil {
+		result.Aggregation = NewAggregation(*q.AggregatedBy, q.GroupBy, q.Metrics)
+	}
+	return result
 }
 
 // Miss increments the count of missed records in the query_types result.
@@ -30,17 +39,164 @@ func (qr *QueryResult) Miss() {
 	qr.Report.ScannedItems++
 }
 
-// Hit increments the count of matched records in the query_types result.
+// Hit increments the count of matched records in the query_types result. With a limit only the first records in
+// the order of the query are kept, a record coming before the last kept one takes its place. The result bytes of
+// the usage are the bytes of the kept records.
 func (qr *QueryResult) Hit(record *LogRecord) {
 	qr.Report.ScannedItems++
 	qr.Report.Hits++
-	if qr.Report.Hits > *qr.Query.Limit {
+	limit, ok := qr.limit()
+	switch {
+	case !ok:
+		qr.Records = append(qr.Records, record)
+	case len(qr.Records) < limit:
+		heap.Push(qr.kept(), record)
+	case limit > 0 && qr.before(record, qr.Records[0]):
+		qr.Report.Usage.ResultBytes -= qr.Records[0].Size()
+		qr.Records[0] = record
+		heap.Fix(qr.kept(), 0)
+	default:
 		return
 	}
-	qr.Records = append(qr.Records, record)
+	qr.Report.Usage.ResultBytes += record.Size()
+}
+
+// Match counts a matched record without keeping it, it reports false when the limit of the query was reached
+// before the record. The bytes of the record are counted as result bytes of the usage.
+func (qr *QueryResult) Match(record *LogRecord) bool {
+	qr.Report.ScannedItems++
+	if qr.IsLimitReached() {
+		return false
+	}
+	qr.Report.Hits++
+	qr.Report.Usage.ResultBytes += record.Size()
+	return true
+}
+
+// IsLimitReached reports whether the matched records reached the limit of the query
+func (qr *QueryResult) IsLimitReached() bool {
+	limit, ok := qr.limit()
+	return ok && qr.Report.Hits >= limit
+}
+
+// IsFull reports whether the result holds the records of the limit of the query, the boundary is the timestamp of
+// the last kept record in the order of the query. Records after the boundary do not change a full result.
+func (qr *QueryResult) IsFull() (boundary time.Time, full bool) {
+	limit, ok := qr.limit()
+	if !ok || len(qr.Records) < limit {
+		return time.Time{}, false
+	}
+	if limit == 0 {
+		return time.Time{}, true
+	}
+	return qr.Records[0].Timestamp, true
+}
+
+// IsDescending reports whether the records are ordered newest first
+func (qr *QueryResult) IsDescending() bool {
+	return qr.Query != nil && qr.Query.Order == query_types.Descending
+}
+
+// limit returns the limit of the query, false when the query has none
+func (qr *QueryResult) limit() (int, bool) {
+	if qr.Query == nil || qr.Query.Limit == nil {
+		return 0, false
+	}
+	return max(*qr.Query.Limit, 0), true
+}
+
+// NextCursor returns the cursor of the last record of a full result, nil when the result holds all the records.
+// A truncated result has no cursor, records before its last record may not have been read. The result must be sorted.
+func (qr *QueryResult) NextCursor() *Cursor {
+	limit, ok := qr.limit()
+	if !ok || limit == 0 || len(qr.Records) < limit || qr.Aggregation != nil || qr.Report.Truncated {
+		return nil
+	}
+	last := qr.Records[len(qr.Records)-1]
+	cursor := &Cursor{Order: query_types.Ascending, Timestamp: last.Timestamp}
+	if qr.IsDescending() {
+		cursor.Order = query_types.Descending
+	}
+	if last.Position != nil {
+		cursor.Position = *last.Position
+	}
+	return cursor
+}
+
+// before reports whether the record comes before the other in the order of the query, the records sharing
+// a timestamp are ordered by their position
+func (qr *QueryResult) before(record, other *LogRecord) bool {
+	if !record.Timestamp.Equal(other.Timestamp) {
+		if qr.IsDescending() {
+			return record.Timestamp.After(other.Timestamp)
+		}
+		return record.Timestamp.Before(other.Timestamp)
+	}
+	if record.Position == nil || other.Position == nil {
+		return false
+	}
+	if qr.IsDescending() {
+		return other.Position.Before(record.Position)
+	}
+	return record.Position.Before(other.Position)
+}
+
+// kept returns the records as a heap with the last record in the order of the query first
+func (qr *QueryResult) kept() *keptRecords {
+	return &keptRecords{result: qr}
+}
+
+// Count adds matched records to the aggregation in the bucket of the timestamp and the label values.
+func (qr *QueryResult) Count(timestamp time.Time, labels []string, count uint64) {
+	qr.Report.ScannedItems += int(count)
+	qr.Report.Hits += int(count)
+	qr.Aggregation.Add(timestamp, labels, count)
+}
+
+// Aggregate adds a matched record to the aggregation with the values of the grouping labels and the labels of the metrics.
+func (qr *QueryResult) Aggregate(timestamp time.Time, labels []string, values []*Label) {
+	qr.Report.ScannedItems++
+	qr.Report.Hits++
+	qr.Aggregation.AddRecord(timestamp, labels, values)
 }
 
 // SpentTime sets the time spent on the query_types.
 func (qr *QueryResult) SpentTime(elapsedTime time.Duration) {
 	qr.Report.ElapsedTime = elapsedTime
 }
+
+// Sort orders the records by timestamp in the order of the query, records with the same timestamp keep their order.
+// No records are added to a sorted result.
+func (qr *QueryResult) Sort() {
+	sort.SliceStable(qr.Records, func(i, j int) bool {
+		return qr.before(qr.Records[i], qr.Records[j])
+	})
+}
+
+// keptRecords is the heap of the records of a result with a limit, the last record in the order of the query is
+// at the top to be replaced by a record coming before it
+type keptRecords struct {
+	result *QueryResult
+}
+
+func (k *keptRecords) Len() int {
+	return len(k.result.Records)
+}
+
+func (k *keptRecords) Less(i, j int) bool {
+	return k.result.before(k.result.Records[j], k.result.Records[i])
+}
+
+func (k *keptRecords) Swap(i, j int) {
+	k.result.Records[i], k.result.Records[j] = k.result.Records[j], k.result.Records[i]
+}
+
+func (k *keptRecords) Push(x any) {
+	k.result.Records = append(k.result.Records, x.(*LogRecord))
+}
+
+func (k *keptRecords) Pop() any {
+	last := k.result.Records[len(k.result.Records)-1]
+	k.result.Records = k.result.Records[:len(k.result.Records)-1]
+	return last
+}
@@ -0,0 +1,112 @@
+package domain
+
+import (
+	"LogDb/internal/domain/query_types"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func newLimitedResult(order query_types.Order, limit int) *QueryResult {
+	return NewQueryResult(&Query{Order: order, Limit: &limit})
+}
+
+func messages(records []*LogRecord) []string {
+	var result []string
+	for _, record := range records {
+		result = append(result, string(record.Message))
+	}
+	return result
+}
+
+// TestQueryResult_HitKeepsFirstRecords tests that a limited result keeps the first records in the order of the query
+// whatever the order they are found in, the result bytes are the bytes of the kept records
+func TestQueryResult_HitKeepsFirstRecords(t *testing.T) {
+	base := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	found := []int{5, 1, 4, 2, 3}
+	for order, expected := range map[query_types.Order][]string{
+		query_types.Ascending:  {"1", "2"},
+		query_types.Descending: {"5", "4"},
+	} {
+		result := newLimitedResult(order, 2)
+		for _, minute := range found {
+			result.Hit(&LogRecord{Timestamp: base.Add(time.Duration(minute) * time.Minute), Message: []byte{byte('0' + minute)}})
+		}
+		boundary, full := result.IsFull()
+		result.Sort()
+
+		assert.Equal(t, expected, messages(result.Records), order)
+		assert.Equal(t, 5, result.Report.Hits, order)
+		assert.True(t, full, order)
+		assert.Equal(t, result.Records[1].Timestamp, boundary, order)
+		assert.Equal(t, result.Records[0].Size()+result.Records[1].Size(), result.Report.Usage.ResultBytes, order)
+	}
+}
+
+// TestQueryResult_Match tests that a streamed result counts the records until the limit
+func TestQueryResult_Match(t *testing.T) {
+	result := newLimitedResult(query_types.Ascending, 2)
+	record := &LogRecord{Message: []byte("streamed")}
+
+	assert.True(t, result.Match(record))
+	assert.False(t, result.IsLimitReached())
+	assert.True(t, result.Match(record))
+	assert.True(t, result.IsLimitReached())
+	assert.False(t, result.Match(record))
+	assert.Equal(t, 2, result.Report.Hits)
+	assert.Equal(t, 3, result.Report.ScannedItems)
+	assert.Equal(t, 2*record.Size(), result.Report.Usage.ResultBytes)
+
+	unlimited := NewQueryResult(nil)
+	unlimited.Hit(&LogRecord{})
+	assert.Len(t, unlimited.Records, 1)
+	_, full := unlimited.IsFull()
+	assert.False(t, full)
+}
+
+// TestQueryResult_NextCursor tests that the records sharing a timestamp are ordered by position and that the cursor
+// of a full result continues after its last record, a truncated result has no cursor
+func TestQueryResult_NextCursor(t *testing.T) {
+	at := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	positions := map[string]*RecordPosition{
+		"memtable": {Offset: 0},
+		"file b":   {DataFile: "2024-05-01.2", Page: 600, Offset: 0},
+		"file a 1": {DataFile: "2024-05-01.1", Page: 600, Offset: 1},
+		"file a 0": {DataFile: "2024-05-01.1", Page: 600, Offset: 0},
+	}
+	for order, expected := range map[query_types.Order][]string{
+		query_types.Ascending:  {"file a 0", "file a 1"},
+		query_types.Descending: {"memtable", "file b"},
+	} {
+		result := newLimitedResult(order, 2)
+		for message, position := range positions {
+			result.Hit(&LogRecord{Timestamp: at, Message: []byte(message), Position: position})
+		}
+		result.Sort()
+		assert.Equal(t, expected, messages(result.Records), order)
+
+		cursor := result.NextCursor()
+		if assert.NotNil(t, cursor, order) {
+			assert.Equal(t, order, cursor.Order)
+			assert.Equal(t, *positions[expected[1]], cursor.Position)
+			var following []string
+			for message, position := range positions {
+				if cursor.IsAfter(&LogRecord{Timestamp: at, Position: position}) {
+					following = append(following, message)
+				}
+			}
+			assert.Len(t, following, 2, order)
+			assert.NotContains(t, following, expected[0], order)
+			assert.NotContains(t, following, expected[1], order)
+		}
+	}
+
+	notFull := newLimitedResult(query_types.Ascending, 5)
+	notFull.Hit(&LogRecord{Timestamp: at})
+	assert.Nil(t, notFull.NextCursor())
+
+	truncated := newLimitedResult(query_types.Ascending, 1)
+	truncated.Hit(&LogRecord{Timestamp: at})
+	truncated.Report.Truncated = true
+	assert.Nil(t, truncated.NextCursor())
+}
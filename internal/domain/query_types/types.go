@@ -1,5 +1,12 @@
 package query_types
 
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+)
+
 // Operation types
 type Operation string
 
@@ -21,17 +28,74 @@ const (
 	Year    Dimension = "year"
 )
 
-// Format options for the result
+// Dimensions lists the aggregation dimensions from the shortest to the longest bucket
+var Dimensions = []Dimension{Minute, Hour, Day, Week, Month, Quarter, Year}
+
+// IsValid reports whether the dimension is known
+func (d Dimension) IsValid() bool {
+	for _, dimension := range Dimensions {
+		if d == dimension {
+			return true
+		}
+	}
+	return false
+}
+
+// Truncate returns the start of the bucket of the time in UTC, weeks start on Monday
+func (d Dimension) Truncate(t time.Time) time.Time {
+	t = t.UTC()
+	year, month, day := t.Date()
+	switch d {
+	case Minute:
+		return t.Truncate(time.Minute)
+	case Hour:
+		return t.Truncate(time.Hour)
+	case Day:
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	case Week:
+		return time.Date(year, month, day-(int(t.Weekday())+6)%7, 0, 0, 0, 0, time.UTC)
+	case Month:
+		return time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	case Quarter:
+		return time.Date(year, (month-1)/3*3+1, 1, 0, 0, 0, 0, time.UTC)
+	case Year:
+		return time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return t
+}
+
+// Format options for the result, DefaultFormat leaves the choice to the client (text in the cli, json in the API)
 type Format string
 
 const (
-	Text   Format = "text"
-	CSV    Format = "csv"
-	JSON   Format = "json"
-	YAML   Format = "yaml"
-	Binary Format = "binary"
+	DefaultFormat Format = ""
+	Text          Format = "text"
+	CSV           Format = "csv"
+	JSON          Format = "json"
+	NDJSON        Format = "ndjson" // a JSON document per line, the records are streamed
+	YAML          Format = "yaml"
+	Binary        Format = "binary"
 )
 
+// Formats lists the result formats a query can ask for
+var Formats = []Format{Text, CSV, JSON, NDJSON, YAML, Binary}
+
+// Order of the records of the result by timestamp
+type Order string
+
+const (
+	Ascending  Order = "asc"  // oldest first
+	Descending Order = "desc" // newest first
+)
+
+// Orders lists the orders a query can ask for
+var Orders = []Order{Ascending, Descending}
+
+// IsValid reports whether the order is one of the Orders
+func (o Order) IsValid() bool {
+	return slices.Contains(Orders, o)
+}
+
 // QueryOperator for conditions
 type QueryOperator string
 
@@ -43,11 +107,19 @@ const (
 	LessEqual    QueryOperator = "<="
 	NotEqual     QueryOperator = "!="
 	Exists       QueryOperator = "exists"
+	NotExists    QueryOperator = "not exists"
 	IsNull       QueryOperator = "is null"
 	IsNotNull    QueryOperator = "is not null"
 	And          QueryOperator = "and"
 	Or           QueryOperator = "or"
 	Contains     QueryOperator = "contains"
+	NotContains  QueryOperator = "not contains"
+	Matches      QueryOperator = "=~" // regular expression match
+	NotMatches   QueryOperator = "!~"
+	IContains    QueryOperator = "icontains" // case-insensitive contains
+	NotIContains QueryOperator = "not icontains"
+	StartsWith   QueryOperator = "starts with"
+	EndsWith     QueryOperator = "ends with"
 )
 
 // Condition represents a single condition in the where clause
@@ -56,3 +128,46 @@ type Condition struct {
 	Operator QueryOperator
 	Value    interface{}
 }
+
+// MetricFunction computes a value of an aggregation bucket from the labels of its records
+type MetricFunction string
+
+const (
+	Count         MetricFunction = "count"
+	Sum           MetricFunction = "sum"
+	Avg           MetricFunction = "avg"
+	Min           MetricFunction = "min"
+	Max           MetricFunction = "max"
+	CountDistinct MetricFunction = "count_distinct"
+	Percentile    MetricFunction = "percentile"
+)
+
+// MetricFunctions lists the metric functions
+var MetricFunctions = []MetricFunction{Count, Sum, Avg, Min, Max, CountDistinct, Percentile}
+
+// IsNumeric reports whether the function computes a value from numeric labels
+func (f MetricFunction) IsNumeric() bool {
+	switch f {
+	case Sum, Avg, Min, Max, Percentile:
+		return true
+	}
+	return false
+}
+
+// AllRecords is the field of the count metric counting every record of the bucket
+const AllRecords = "*"
+
+// Metric represents a value computed per aggregation bucket, e.g. avg(label.response_time)
+type Metric struct {
+	Function   MetricFunction
+	Field      string  // label of the values or AllRecords for count
+	Percentile float64 // percentile computed by the percentile function, 0 to 100
+}
+
+// String returns the metric as written in a query
+func (m Metric) String() string {
+	if m.Function == Percentile {
+		return fmt.Sprintf("%s(%s, %s)", m.Function, m.Field, strconv.FormatFloat(m.Percentile, 'g', -1, 64))
+	}
+	return fmt.Sprintf("%s(%s)", m.Function, m.Field)
+}
@@ -2,6 +2,7 @@ package domain
 
 import (
 	"log"
+	"time"
 	"unsafe"
 )
 
@@ -12,7 +13,7 @@ func init() {
 // RecordMeta represents the metadata of a record.
 // The total size of RecordMeta is 64 bytes.
 type RecordMeta struct {
-	Timestamp     uint64 // 8 bytes - UNIX timestamp
+	Timestamp     uint64 // 8 bytes - UNIX timestamp in nanoseconds with RecordNanosecondsFlag, seconds without it
 	RecordSize    uint64 // 8 bytes - Size of the entire record (including metadata, labels, and message)
 	SchemaVersion uint64 // 8 bytes - Version of the schema
 	LabelsSize    uint64 // 8 bytes - Total size of the labels section in bytes
@@ -27,6 +28,52 @@ const RecordMetaSize = int(unsafe.Sizeof(RecordMeta{}.Timestamp) +
 	unsafe.Sizeof(RecordMeta{}.LabelsCount) +
 	unsafe.Sizeof(RecordMeta{}.MessageSize))
 
+const (
+	// RecordFormatSeconds is the record format with UNIX timestamps in seconds
+	RecordFormatSeconds uint8 = 1
+	// RecordFormatNanoseconds is the record format with UNIX timestamps in nanoseconds
+	RecordFormatNanoseconds uint8 = 2
+)
+
+// RecordNanosecondsFlag is the highest bit of the stored timestamp, set by the nanosecond record format.
+const RecordNanosecondsFlag uint64 = 1 << 63
+
+// NewRecordTimestamp returns the timestamp stored in the record meta for the given time.
+func NewRecordTimestamp(t time.Time) uint64 {
+	return uint64(t.UnixNano()) | RecordNanosecondsFlag
+}
+
+// RecordTimestampNano returns the stored timestamp in nanoseconds for every record format.
+func RecordTimestampNano(timestamp uint64) uint64 {
+	if timestamp&RecordNanosecondsFlag == 0 {
+		return timestamp * uint64(time.Second)
+	}
+	return timestamp &^ RecordNanosecondsFlag
+}
+
+// RecordTimestampTime returns the stored timestamp as UTC time for every record format.
+func RecordTimestampTime(timestamp uint64) time.Time {
+	return time.Unix(0, int64(RecordTimestampNano(timestamp))).UTC()
+}
+
+// Format returns the record format flagged in the timestamp.
+func (m *RecordMeta) Format() uint8 {
+	if m.Timestamp&RecordNanosecondsFlag == 0 {
+		return RecordFormatSeconds
+	}
+	return RecordFormatNanoseconds
+}
+
+// UnixNano returns the timestamp of the record in nanoseconds.
+func (m *RecordMeta) UnixNano() uint64 {
+	return RecordTimestampNano(m.Timestamp)
+}
+
+// Time returns the timestamp of the record as UTC time.
+func (m *RecordMeta) Time() time.Time {
+	return RecordTimestampTime(m.Timestamp)
+}
+
 // Record represents a complete record, including metadata, labels, and message.
 type Record struct {
 	Meta    RecordMeta
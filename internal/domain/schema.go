@@ -0,0 +1,12 @@
+package domain
+
+import "fmt"
+
+// FirstSchemaVersion is the first version assigned by the schema store.
+// Records with a lower version were written without a schema and their labels are only known by position.
+const FirstSchemaVersion uint64 = 2
+
+// PositionalLabelName returns the name of a label that has no schema
+func PositionalLabelName(idx int) string {
+	return fmt.Sprintf("label_%d", idx)
+}
@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"math"
+	"sort"
+)
+
+// SketchRelativeAccuracy is the relative error of the quantiles estimated by a DDSketch
+const SketchRelativeAccuracy = 0.01
+
+// sketchMinValue is the smallest magnitude counted in a logarithmic bin, smaller values are counted as zero
+const sketchMinValue = 1e-9
+
+// DDSketch estimates quantiles with a relative accuracy guarantee (Masson et al., DDSketch, VLDB 2019).
+// The values are counted in logarithmic bins, a quantile is the center of the bin holding its rank.
+type DDSketch struct {
+	gamma    float64
+	logGamma float64
+	positive map[int]uint64
+	negative map[int]uint64
+	zero     uint64
+	count    uint64
+	min      float64
+	max      float64
+}
+
+// NewDDSketch creates an empty sketch with the relative accuracy, e.g. 0.01 for 1%
+func NewDDSketch(relativeAccuracy float64) *DDSketch {
+	gamma := (1 + relativeAccuracy) / (1 - relativeAccuracy)
+	return &DDSketch{
+		gamma:    gamma,
+		logGamma: math.Log(gamma),
+		positive: make(map[int]uint64),
+		negative: make(map[int]uint64),
+		min:      math.Inf(1),
+		max:      math.Inf(-1),
+	}
+}
+
+// Add counts the value
+func (s *DDSketch) Add(value float64) {
+	switch {
+	case value > sketchMinValue:
+		s.positive[s.index(value)]++
+	case value < -sketchMinValue:
+		s.negative[s.index(-value)]++
+	default:
+		s.zero++
+	}
+	s.count++
+	s.min = math.Min(s.min, value)
+	s.max = math.Max(s.max, value)
+}
+
+// Count returns the number of values
+func (s *DDSketch) Count() uint64 {
+	return s.count
+}
+
+// Quantile returns the estimated value at the quantile between 0 and 1, the second value is false for an empty sketch
+func (s *DDSketch) Quantile(q float64) (float64, bool) {
+	if s.count == 0 || q < 0 || q > 1 {
+		return 0, false
+	}
+	rank := q * float64(s.count-1)
+	var seen uint64
+	// the largest magnitudes of negative values come first
+	for _, idx := range sortedBins(s.negative, true) {
+		seen += s.negative[idx]
+		if float64(seen) > rank {
+			return s.clamp(-s.value(idx)), true
+		}
+	}
+	seen += s.zero
+	if float64(seen) > rank {
+		return s.clamp(0), true
+	}
+	for _, idx := range sortedBins(s.positive, false) {
+		seen += s.positive[idx]
+		if float64(seen) > rank {
+			return s.clamp(s.value(idx)), true
+		}
+	}
+	return s.max, true
+}
+
+// index returns the bin of a positive value
+func (s *DDSketch) index(value float64) int {
+	return int(math.Ceil(math.Log(value) / s.logGamma))
+}
+
+// value returns the center of the bin, its relative distance to every value of the bin is within the accuracy
+func (s *DDSketch) value(idx int) float64 {
+	return 2 * math.Pow(s.gamma, float64(idx)) / (s.gamma + 1)
+}
+
+// clamp keeps the estimate within the values added
+func (s *DDSketch) clamp(value float64) float64 {
+	return math.Max(s.min, math.Min(s.max, value))
+}
+
+// sortedBins returns the bin indexes in ascending or descending order
+func sortedBins(bins map[int]uint64, descending bool) []int {
+	indexes := make([]int, 0, len(bins))
+	for idx := range bins {
+		indexes = append(indexes, idx)
+	}
+	if descending {
+		sort.Sort(sort.Reverse(sort.IntSlice(indexes)))
+	} else {
+		sort.Ints(indexes)
+	}
+	return indexes
+}
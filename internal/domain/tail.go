@@ -0,0 +1,22 @@
+package domain
+
+// SlowConsumerPolicy defines what happens to a subscriber of stored records whose buffer is full.
+type SlowConsumerPolicy uint8
+
+const (
+	// DropRecords drops the records that do not fit in the buffer and counts them
+	DropRecords SlowConsumerPolicy = iota
+	// DisconnectSlowConsumer ends the subscription when a record does not fit in the buffer
+	DisconnectSlowConsumer
+)
+
+// String returns the name of the policy
+func (p SlowConsumerPolicy) String() string {
+	switch p {
+	case DropRecords:
+		return "drop"
+	case DisconnectSlowConsumer:
+		return "disconnect"
+	}
+	return "unknown"
+}
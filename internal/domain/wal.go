@@ -3,6 +3,7 @@ package domain
 import (
 	"github.com/google/uuid"
 	"time"
+	"unsafe"
 )
 
 // WALHeader represents the header of the WAL file.
@@ -13,11 +14,28 @@ type WALHeader struct {
 	ReadCursor uint64 // 8 bytes (Offset of the last read record)
 }
 
+const (
+	// WALVersionRecords is the WAL version with the serialized log records following the header
+	WALVersionRecords = 1
+	// WALVersionRecordFrames is the WAL version with every log record framed by its size and checksum
+	WALVersionRecordFrames = 2
+)
+
 // DefaultWALVersion is the default version of the WAL file.
-const DefaultWALVersion = 1
+const DefaultWALVersion = WALVersionRecordFrames
 
 // WALHeaderSize is the size of the WAL header in bytes.
-const WALHeaderSize = 4 + 1 + 8
+const WALHeaderSize = 4 + 1 + 8 + 8
+
+// WALRecordHeader precedes every log record in the WAL file since WALVersionRecordFrames.
+type WALRecordHeader struct {
+	Size     uint32 // 4 bytes - Size of the serialized log record
+	Checksum uint32 // 4 bytes - CRC32C of the serialized log record
+}
+
+const WALRecordHeaderSize = int(unsafe.Sizeof(WALRecordHeader{}.Size) +
+	unsafe.Sizeof(WALRecordHeader{}.Checksum),
+) // 8 bytes
 
 // NewWALHeader creates a new WAL header with the given ID and creation time.
 func NewWALHeader() *WALHeader {
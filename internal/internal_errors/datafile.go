@@ -11,3 +11,7 @@ var DataPageNumberMismatch = errors.New("DataPageNumberMismatch")
 var DataFileNumberMismatch = errors.New("DataFileNumberMismatch")
 var DataPageRecordSizeMismatch = errors.New("DataPageRecordSizeMismatch")
 var DataFileAlreadyCompressed = errors.New("DataFileAlreadyCompressed")
+var DataFileHeaderChecksumMismatch = errors.New("DataFileHeaderChecksumMismatch")
+var DataFileFooterChecksumMismatch = errors.New("DataFileFooterChecksumMismatch")
+var DataPageHeaderChecksumMismatch = errors.New("DataPageHeaderChecksumMismatch")
+var DataPagePayloadChecksumMismatch = errors.New("DataPagePayloadChecksumMismatch")
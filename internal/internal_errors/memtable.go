@@ -0,0 +1,9 @@
+package internal_errors
+
+import "errors"
+
+// MemTableClosed is returned when a record is added after the MemTable was closed.
+var MemTableClosed = errors.New("MemTableClosed")
+
+// FlusherClosed is returned when a chunk is flushed after the flusher was closed.
+var FlusherClosed = errors.New("FlusherClosed")
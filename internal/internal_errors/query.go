@@ -0,0 +1,30 @@
+package internal_errors
+
+import "errors"
+
+// UnknownQueryField is returned when a query condition refers to a field that does not exist.
+var UnknownQueryField = errors.New("UnknownQueryField")
+
+// UnsupportedQueryOperator is returned when an operator cannot be applied to a field.
+var UnsupportedQueryOperator = errors.New("UnsupportedQueryOperator")
+
+// UnsupportedConditionValue is returned when the value of a condition has an unsupported type.
+var UnsupportedConditionValue = errors.New("UnsupportedConditionValue")
+
+// InvalidRegexp is returned when the regular expression of a condition does not compile.
+var InvalidRegexp = errors.New("InvalidRegexp")
+
+// UnsupportedAggregationDimension is returned when a query is aggregated by an unknown dimension.
+var UnsupportedAggregationDimension = errors.New("UnsupportedAggregationDimension")
+
+// UnsupportedGroupByField is returned when an aggregation is grouped by a field that is not a label.
+var UnsupportedGroupByField = errors.New("UnsupportedGroupByField")
+
+// UnsupportedMetric is returned when a metric has an unknown function or is not computed from a label.
+var UnsupportedMetric = errors.New("UnsupportedMetric")
+
+// UnsupportedOrder is returned when a query orders the records by an unknown order.
+var UnsupportedOrder = errors.New("UnsupportedOrder")
+
+// UnsupportedFormat is returned when a result cannot be presented in the requested format.
+var UnsupportedFormat = errors.New("UnsupportedFormat")
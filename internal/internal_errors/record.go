@@ -0,0 +1,21 @@
+package internal_errors
+
+import "errors"
+
+// RecordMissing is returned when a record of a request is null.
+var RecordMissing = errors.New("RecordMissing")
+
+// RecordTimestampMissing is returned when a record has no timestamp.
+var RecordTimestampMissing = errors.New("RecordTimestampMissing")
+
+// RecordMessageMissing is returned when a record has an empty message.
+var RecordMessageMissing = errors.New("RecordMessageMissing")
+
+// UnsupportedContentEncoding is returned when a request body is compressed with an unknown algorithm.
+var UnsupportedContentEncoding = errors.New("UnsupportedContentEncoding")
+
+// NDJSONLineTooLong is returned when a line of a newline-delimited JSON stream exceeds the size limit.
+var NDJSONLineTooLong = errors.New("NDJSONLineTooLong")
+
+// RequestBodyTooLarge is returned when a decompressed request body exceeds the size limit.
+var RequestBodyTooLarge = errors.New("RequestBodyTooLarge")
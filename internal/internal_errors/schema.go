@@ -0,0 +1,9 @@
+package internal_errors
+
+import "errors"
+
+// SchemaNotFound is returned when a schema version is not known by the schema store.
+var SchemaNotFound = errors.New("SchemaNotFound")
+
+// SchemaFieldDuplicated is returned when a schema has several fields with the same name.
+var SchemaFieldDuplicated = errors.New("SchemaFieldDuplicated")
@@ -10,5 +10,14 @@ var RecordsLimitReached = errors.New("RecordsLimitReached")
 // RecordsOutOfRange is an error that is returned when the limit of records has been reached.
 var RecordsOutOfRange = errors.New("RecordsOutOfRange")
 
+// InvalidCursor is an error that is returned when a cursor does not continue the query it is given with.
+var InvalidCursor = errors.New("InvalidCursor")
+
+// CursorDataFileRewritten is an error that is returned when the data file of a cursor was merged or removed.
+var CursorDataFileRewritten = errors.New("CursorDataFileRewritten")
+
+// QueryBudgetExceeded is an error that is returned when a query consumed more than its budget.
+var QueryBudgetExceeded = errors.New("QueryBudgetExceeded")
+
 // PageEndReached is an error that is returned when the limit of records has been reached.
 var PageEndReached = errors.New("PageEndReached")
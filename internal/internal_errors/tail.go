@@ -0,0 +1,12 @@
+package internal_errors
+
+import "errors"
+
+// TailSlowConsumer is returned when a subscription ended because the subscriber did not keep up with the stored records.
+var TailSlowConsumer = errors.New("TailSlowConsumer")
+
+// TailClosed is returned when a subscription ended because the records bus was closed.
+var TailClosed = errors.New("TailClosed")
+
+// SlowConsumerPolicyNotSupported is returned when a subscription asks for an unknown slow consumer policy.
+var SlowConsumerPolicyNotSupported = errors.New("SlowConsumerPolicyNotSupported")
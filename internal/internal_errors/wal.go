@@ -0,0 +1,15 @@
+package internal_errors
+
+import "errors"
+
+// WALVersionNotSupported is returned when a WAL file was written in a version that cannot be replayed.
+var WALVersionNotSupported = errors.New("WALVersionNotSupported")
+
+// WALClosed is returned when a record is stored after the WAL was closed.
+var WALClosed = errors.New("WALClosed")
+
+// WALRecordChecksumMismatch is returned when a record of a WAL file does not match its checksum.
+var WALRecordChecksumMismatch = errors.New("WALRecordChecksumMismatch")
+
+// WALRecordCorrupted is returned when a record of a WAL file cannot be read and it is not a torn write at the end of the file.
+var WALRecordCorrupted = errors.New("WALRecordCorrupted")
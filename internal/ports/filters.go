@@ -22,6 +22,11 @@ type LabelCondition interface {
 	IsFit(l *domain.Label) bool
 }
 
+// TextMatcher matches the text of a message or a label value
+type TextMatcher interface {
+	Match(text []byte) bool
+}
+
 type LabelConditionBuilder interface {
 	And(condition LabelCondition) LabelConditionBuilder
 	Or(condition LabelCondition) LabelConditionBuilder
@@ -33,6 +38,8 @@ type LabelConditionBuilder interface {
 	Gte(l *domain.Label) LabelConditionBuilder
 	Lt(l *domain.Label) LabelConditionBuilder
 	Lte(l *domain.Label) LabelConditionBuilder
+	Exists() LabelConditionBuilder
+	NotEmpty() LabelConditionBuilder
 
 	Build() (LabelCondition, error)
 }
@@ -2,14 +2,19 @@ package ports
 
 import (
 	"LogDb/internal/domain"
+	"context"
 )
 
 type Index interface {
+	// Name returns the name of the index shown in query plans
+	Name() string
 	BindStorage(storage DataStorage) error
 	AddDataFile(df *domain.DataFileHeader) error
 	//deleteDataFile(df *domain.DataFileHeader) error
 
-	GetDataFilesForRead(q PreparedQuery) ([]IndexOperation, error)
+	// GetDataFilesForRead returns the data files of the time range of the query with read access, until the context
+	// is done
+	GetDataFilesForRead(ctx context.Context, q PreparedQuery) ([]IndexOperation, error)
 }
 
 // IndexOperation defines the interface for an index operation.
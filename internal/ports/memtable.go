@@ -6,6 +6,10 @@ type MemTable interface {
 	// Add a new log record to the MemTable.
 	Add(record *domain.LogRecord) error
 
+	// AddBatch Add the log records to the MemTable in order, it returns how many records were acknowledged before an
+	// error, the records that follow them were not added.
+	AddBatch(records []*domain.LogRecord) (int, error)
+
 	// RotateChunk Mark the current active chunk as read-only and create a new chunk.
 	RotateChunk()
 
@@ -14,6 +18,15 @@ type MemTable interface {
 
 	// IsFull Check if the MemTable is full based on memory usage or record count.
 	IsFull() bool
+
+	// Close Stop accepting records and flush every chunk before it returns.
+	Close() error
+
+	// Snapshot Get the records of the active chunk and of the chunks waiting for or being flushed.
+	Snapshot() *domain.MemTableSnapshot
+
+	// FlushSequence Get the number of chunks that started flushing, see domain.MemTableSnapshot.
+	FlushSequence() uint64
 }
 
 type HeapChunk interface {
@@ -36,6 +49,9 @@ type HeapChunk interface {
 	IsImmutable() bool
 
 	SizeInBytes() int
+
+	// Records Get a copy of the records in no particular order, the heap is not modified.
+	Records() []*domain.LogRecord
 }
 
 type Flushable interface {
@@ -13,3 +13,28 @@ type DataFilesChangesSubscriber interface {
 	OnDataFileDeleted(func(header *domain.DataFileHeader))
 	OnDataFileCreated(func(header *domain.DataFileHeader))
 }
+
+// RecordsPropagator defines the interface for a propagator of stored log records.
+type RecordsPropagator interface {
+	RecordsStored(records []*domain.LogRecord)
+}
+
+// RecordsSubscriber defines the interface for a subscriber of stored log records.
+type RecordsSubscriber interface {
+	// Subscribe receives the stored records matching the filter, a nil filter matches every record.
+	Subscribe(filter Filter, bufferSize int, policy domain.SlowConsumerPolicy) RecordsSubscription
+}
+
+// RecordsSubscription defines the interface for a subscription to stored log records.
+type RecordsSubscription interface {
+	// Records Get the channel of the matching records.
+	Records() <-chan *domain.LogRecord
+	// Done Get a channel closed when the subscription ended, see Err.
+	Done() <-chan struct{}
+	// Err Get the reason the subscription ended.
+	Err() error
+	// Dropped Get the number of records dropped because the buffer was full.
+	Dropped() uint64
+	// Close ends the subscription.
+	Close()
+}
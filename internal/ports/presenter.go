@@ -1,11 +1,21 @@
 package ports
 
-import "LogDb/internal/domain"
+import (
+	"LogDb/internal/domain"
+	"LogDb/internal/domain/query_types"
+)
 
 type LogRecordPresenter interface {
 	Present(record *domain.LogRecord) string
 }
 
 type QueryResultPresenter interface {
-	Present(result *domain.QueryResult) string
+	Present(result *domain.QueryResult) (string, error)
+	// ContentType returns the media type of the presentation
+	ContentType() string
+}
+
+// QueryResultPresenterFactory provides the presenter of a result format
+type QueryResultPresenterFactory interface {
+	Presenter(format query_types.Format) (QueryResultPresenter, error)
 }
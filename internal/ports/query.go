@@ -15,6 +15,9 @@ type QueryBuilder interface {
 	// SelectFields sets the fields to select in the query
 	SelectFields(fields ...string) QueryBuilder
 
+	// SelectMetrics sets the metrics computed per aggregation bucket (optional)
+	SelectMetrics(metrics ...query_types.Metric) QueryBuilder
+
 	// SetPartition sets the partition (optional)
 	SetPartition(partition string) QueryBuilder
 
@@ -24,12 +27,33 @@ type QueryBuilder interface {
 	// Limit sets the maximum number of records to return (optional)
 	Limit(limit int) QueryBuilder
 
+	// OrderBy sets the order of the records by timestamp (optional)
+	OrderBy(order query_types.Order) QueryBuilder
+
+	// After continues the query after the last record of the previous page of results (optional)
+	After(cursor *domain.Cursor) QueryBuilder
+
+	// Concurrency sets the number of workers scanning the data files, at most the workers of the storage (optional)
+	Concurrency(workers int) QueryBuilder
+
+	// Timeout sets the time limit of the query, the records found until then are returned (optional)
+	Timeout(timeout time.Duration) QueryBuilder
+
+	// Budget sets the limits of the resources of the query, at most the budget of the storage (optional)
+	Budget(budget domain.QueryBudget) QueryBuilder
+
 	// AggregateBy sets the aggregation dimension (optional)
 	AggregateBy(dimension query_types.Dimension) QueryBuilder
 
+	// GroupBy sets the labels grouping the aggregation (optional)
+	GroupBy(fields ...string) QueryBuilder
+
 	// SetFormat sets the output format (json, csv, etc.)
 	SetFormat(format query_types.Format) QueryBuilder
 
+	// Explain asks for the plan of the query instead of its records (optional)
+	Explain() QueryBuilder
+
 	// SetTimeRange sets the time range for the query
 	SetTimeRange(startTime, endTime time.Time) QueryBuilder
 
@@ -37,13 +61,42 @@ type QueryBuilder interface {
 	Build() (*domain.Query, error)
 }
 
+// QueryParser turns a textual query into a Query
+type QueryParser interface {
+	Parse(text string) (*domain.Query, error)
+}
+
 type QueryPreparer interface {
 	PrepareQuery(query *domain.Query) (PreparedQuery, error)
+	// PrepareFilter compiles the conditions of the query into a filter without the time range
+	PrepareFilter(query *domain.Query) (Filter, error)
 }
 
 type PreparedQuery interface {
-	FromDateTime() uint64
-	ToDateTime() uint64
+	TimeStampFilter
+	FromDateTime() uint64 // UNIX nanoseconds
+	ToDateTime() uint64   // UNIX nanoseconds
+	// Order returns the order of the records by timestamp, the data is read in this order
+	Order() query_types.Order
+	// IsSatisfied reports whether the records between from and to (UNIX nanoseconds) can no longer change the
+	// result, the data following them in the order of the query is not read
+	IsSatisfied(from, to uint64) bool
+	// After returns the cursor the query continues after, nil for the first page of results
+	After() *domain.Cursor
+	// Concurrency returns the number of workers the query asks for to scan the data files, 0 for the default
+	// of the storage. The time range and the order of the query are read by the workers concurrently.
+	Concurrency() int
+	// Timeout returns the time limit of the query, 0 without limit
+	Timeout() time.Duration
+	// Budget returns the limits of the resources the query asks for, zero limits are unlimited
+	Budget() domain.QueryBudget
+	// Usage returns what the query consumed so far, the storage adds the data it scans and the query the records
+	// of its result
+	Usage() *domain.QueryUsage
+	// Explain reports whether the query asks for its plan instead of its records
+	Explain() bool
+	// Filters describes the filters compiled from the conditions of an explained query
+	Filters() []string
 
 	Begin()
 	Skip()
@@ -53,5 +106,22 @@ type PreparedQuery interface {
 	SetError(err error)
 	Error() error
 
+	// Stream passes the matching records to the sink as they are found instead of collecting them in the result,
+	// the sink receives the report when the query ends
+	Stream(sink RecordSink)
 	Result() (*domain.QueryResult, error)
 }
+
+// RecordSink receives the records of a streamed query, in the order they are found
+type RecordSink interface {
+	// Write receives a matching record, an error stops the query
+	Write(record *domain.LogRecord) error
+	// Close receives the report and the error of the query after the last record
+	Close(report *domain.QueryReport, err error)
+}
+
+// PageCounter is implemented by prepared queries that count records without reading them
+type PageCounter interface {
+	// CountPage counts the records of a data page starting at the time, it reports false when the page must be read
+	CountPage(start time.Time, records uint64) bool
+}
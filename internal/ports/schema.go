@@ -1,19 +1,27 @@
 package ports
 
-import "io"
-
-type LogRecordLabelsWriter interface {
-	WriteLogRecordLabels(labels map[string]string, writer io.Writer) (int, error)
-}
-
-type LogRecordLabelsReader interface {
-	ReadLogRecordLabels(reader io.Reader) (map[string]string, int, error)
+// SchemaStore keeps the schemas that map label positions of records to names and types
+type SchemaStore interface {
+	// GetSchema returns the schema with the given version
+	GetSchema(version uint64) (Schema, error)
+	// CreateSchema registers a new schema with the given fields
+	CreateSchema(fields []SchemaField) (Schema, error)
+	// ResolveSchema returns the schema with exactly the given fields, creating it when it does not exist
+	ResolveSchema(fields []SchemaField) (Schema, error)
+	// Schemas returns all known schemas
+	Schemas() []Schema
 }
 
-type LogRecordMessageWriter interface {
-	WriteLogRecordMessage(message string) (int, error)
+// Schema describes the labels of records written with the schema version
+type Schema interface {
+	ID() uint64
+	Fields() []SchemaField
+	// FieldIndex returns the label position of the field with the given name
+	FieldIndex(name string) (int, bool)
 }
 
-type LogRecordMessageReader interface {
-	ReadLogRecordMessage() (string, int, error)
+// SchemaField describes a single label
+type SchemaField interface {
+	Name() string
+	Type() uint8
 }
@@ -12,6 +12,11 @@ type Serializer interface {
 	WriteFileHeader(header *domain.DataFileHeader, writer io.Writer) (int, error)
 	ReadFileHeader(header *domain.DataFileHeader, reader io.Reader) (int, error)
 
+	WriteDataPageDirectory(directory *domain.DataPageDirectory, writer io.Writer) (int, error)
+	ReadDataPageDirectory(directory *domain.DataPageDirectory, reader io.Reader) (int, error)
+	WriteDataPageChecksums(directory *domain.DataPageDirectory, writer io.Writer) (int, error)
+	ReadDataPageChecksums(directory *domain.DataPageDirectory, reader io.Reader) (int, error)
+
 	ReadLogRecordMeta(header *domain.RecordMeta, reader io.Reader) (int, error)
 	ReadLogLabel(label *domain.Label, reader io.Reader) (int, error)
 	ReadLogRecordMessage(message []byte, reader io.Reader) (int, error)
@@ -2,6 +2,7 @@ package ports
 
 import (
 	"LogDb/internal/domain"
+	"context"
 	"io"
 )
 
@@ -17,7 +18,7 @@ type DataStorageWritable interface {
 }
 
 type DataStorageReadable interface {
-	Query(query PreparedQuery) (*domain.QueryResult, error)
+	Query(ctx context.Context, query PreparedQuery) (*domain.QueryResult, error)
 	Close() error
 }
 
@@ -36,7 +37,12 @@ type DataStorage interface {
 
 	StoreLogRecord(record *domain.LogRecord) error
 
-	Query(query PreparedQuery) (*domain.QueryResult, error)
+	// StoreLogRecords stores the log records in order, it returns how many records were stored before an error,
+	// the error applies to the records that follow them
+	StoreLogRecords(records []*domain.LogRecord) (int, error)
+
+	// Query scans the records of the query until the context is done, the result of a cancelled query is truncated
+	Query(ctx context.Context, query PreparedQuery) (*domain.QueryResult, error)
 
 	//GetFileExt() string
 
@@ -64,8 +70,22 @@ type DataFileReader interface {
 	// NextDataPage moves to and returns the next data page in the data file
 	NextDataPage() (*domain.DataPageHeader, error)
 
+	// SeekDataPage moves to and returns the first data page with a number greater or equal to the given one
+	SeekDataPage(pageNumber uint32) (*domain.DataPageHeader, error)
+
+	// DataPages lists the data pages with records from the first to the last page number in file order
+	DataPages(firstPage, lastPage uint32) ([]domain.DataPageLocation, error)
+
+	// SeekDataPageAt moves to and returns the data page listed by DataPages
+	SeekDataPageAt(page domain.DataPageLocation) (*domain.DataPageHeader, error)
+
 	// GetDataPageReader returns a reader for the current data in the data page it's limited by page size
 	GetDataPageReader() io.ReadSeeker
+
+	// VerifyDataPage checks the payload of the current data page against its checksum before it is decoded,
+	// reading a header only checks the header
+	VerifyDataPage() error
+
 	// Close closes the data file manager
 	Close() error
 }